@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+)
+
+// Transport submits and evaluates transactions of the legal agreement chaincode. The function is
+// called with the JSON request as its only argument, or with no argument if the request is nil.
+// A transport returns the payload of a successful response, and the error returned by
+// NewResponseError for a response with an error status.
+type Transport interface {
+	// Submit runs a transaction that is endorsed and committed to the ledger
+	Submit(ctx context.Context, function string, args ...string) ([]byte, error)
+	// Evaluate runs a transaction that only reads the ledger and is not committed
+	Evaluate(ctx context.Context, function string, args ...string) ([]byte, error)
+}
+
+// Client calls the transactions of the legal agreement chaincode with the request and response
+// types of the common package
+type Client struct {
+	transport Transport
+	tenantID  string
+}
+
+// New returns a client calling the chaincode over the given transport. Requests without a tenant
+// ID are sent for the given tenant, which is empty for the default tenant.
+func New(transport Transport, tenantID string) *Client {
+	return &Client{transport: transport, tenantID: tenantID}
+}
+
+// TenantID returns the tenant of the requests without a tenant ID
+func (client *Client) TenantID() string {
+	return client.tenantID
+}
+
+// tenant returns the tenant ID of a request, or the tenant of the client if it is empty
+func (client *Client) tenant(tenantID string) string {
+	if tenantID == "" {
+		return client.tenantID
+	}
+	return tenantID
+}
+
+// submit runs a write transaction and decodes the document of its response into the given value
+func (client *Client) submit(ctx context.Context, function string, request interface{}, document interface{}) (*WriteResponse, error) {
+	payload, err := client.call(ctx, client.transport.Submit, function, request)
+	if err != nil {
+		return nil, err
+	}
+
+	writeResponse := WriteResponse{Document: document}
+	if err := json.Unmarshal(payload, &writeResponse); err != nil {
+		return nil, fmt.Errorf("Error unmarshaling the response of %s: %s", function, err)
+	}
+	return &writeResponse, nil
+}
+
+// evaluate runs a read transaction and decodes its response into the given value
+func (client *Client) evaluate(ctx context.Context, function string, request interface{}, response interface{}) error {
+	payload, err := client.call(ctx, client.transport.Evaluate, function, request)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(payload, response); err != nil {
+		return fmt.Errorf("Error unmarshaling the response of %s: %s", function, err)
+	}
+	return nil
+}
+
+// call runs a transaction with the JSON request as its argument
+func (client *Client) call(ctx context.Context, run func(context.Context, string, ...string) ([]byte, error), function string, request interface{}) ([]byte, error) {
+	if request == nil {
+		return run(ctx, function)
+	}
+
+	requestAsBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling the request of %s: %s", function, err)
+	}
+	return run(ctx, function, string(requestAsBytes))
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// ReadConfig returns the configuration of the chaincode
+func (client *Client) ReadConfig(ctx context.Context) (*Config, error) {
+	var config Config
+	if err := client.evaluate(ctx, "readConfig", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateConfig changes the fields of the configuration present in the request, which requires an
+// admin identity
+func (client *Client) UpdateConfig(ctx context.Context, request UpdateConfigRequest) (*Config, *WriteResponse, error) {
+	var config Config
+	writeResponse, err := client.submit(ctx, "updateConfig", request, &config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &config, writeResponse, nil
+}
+
+// Migrate migrates the next chunk of stored records to the current schema version, which requires
+// an admin identity. A page size of zero migrates the default page size.
+func (client *Client) Migrate(ctx context.Context, pageSize int32) (*MigrationStatus, *WriteResponse, error) {
+	var status MigrationStatus
+	writeResponse, err := client.submit(ctx, "migrate", MigrateRequest{PageSize: pageSize}, &status)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &status, writeResponse, nil
+}
+
+// MigrationStatus returns the progress of the migration to the current schema version
+func (client *Client) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	var status MigrationStatus
+	if err := client.evaluate(ctx, "migrationStatus", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// ReadConsentStatus returns the consent status of a user against the legal agreement in force
+func (client *Client) ReadConsentStatus(ctx context.Context, userID string) (*ConsentStatus, error) {
+	var consentStatus ConsentStatus
+	request := ReadConsentStatusRequest{TenantID: client.tenantID, UserID: userID}
+	if err := client.evaluate(ctx, "readConsentStatus", request, &consentStatus); err != nil {
+		return nil, err
+	}
+	return &consentStatus, nil
+}
+
+// ReadScopeConsent returns whether a user consented to a scope of the legal agreement in force
+func (client *Client) ReadScopeConsent(ctx context.Context, userID string, scope string) (*ScopeConsent, error) {
+	var scopeConsent ScopeConsent
+	request := ReadScopeConsentRequest{TenantID: client.tenantID, UserID: userID, Scope: scope}
+	if err := client.evaluate(ctx, "readScopeConsent", request, &scopeConsent); err != nil {
+		return nil, err
+	}
+	return &scopeConsent, nil
+}
+
+// ListUsersRequiringReconsent returns the users who must consent again among a page of the users
+// who signed. The bookmark of a page continues the list, and a page size of zero reads the default
+// page size.
+func (client *Client) ListUsersRequiringReconsent(ctx context.Context, pageSize int32, bookmark string) (*ListUsersRequiringReconsentResponse, error) {
+	var response ListUsersRequiringReconsentResponse
+	request := ListUsersRequiringReconsentRequest{TenantID: client.tenantID, PageSize: pageSize, Bookmark: bookmark}
+	if err := client.evaluate(ctx, "listUsersRequiringReconsent", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// GrantDelegation allows a user identity to sign legal agreements on behalf of another
+func (client *Client) GrantDelegation(ctx context.Context, request GrantDelegationRequest) (*Delegation, *WriteResponse, error) {
+	request.TenantID = client.tenant(request.TenantID)
+	var delegation Delegation
+	writeResponse, err := client.submit(ctx, "grantDelegation", request, &delegation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &delegation, writeResponse, nil
+}
+
+// RevokeDelegation revokes the delegation with the given ID
+func (client *Client) RevokeDelegation(ctx context.Context, id string) (*Delegation, *WriteResponse, error) {
+	var delegation Delegation
+	request := RevokeDelegationRequest{TenantID: client.tenantID, ID: id}
+	writeResponse, err := client.submit(ctx, "revokeDelegation", request, &delegation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &delegation, writeResponse, nil
+}
+
+// ReadDelegation returns the delegation with the given ID
+func (client *Client) ReadDelegation(ctx context.Context, id string) (*Delegation, error) {
+	var delegation Delegation
+	request := ReadDelegationRequest{TenantID: client.tenantID, ID: id}
+	if err := client.evaluate(ctx, "readDelegation", request, &delegation); err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+)
+
+// Error is an error response of the chaincode without a more specific type. The chaincode
+// responds with status 400 to invalid requests.
+type Error struct {
+	Status  int32
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("chaincode response %d: %s", err.Status, err.Message)
+}
+
+// NotFoundError is the error response of the chaincode, with status 404, when the requested
+// record does not exist
+type NotFoundError struct {
+	Message string
+}
+
+func (err *NotFoundError) Error() string {
+	return err.Message
+}
+
+// ForbiddenError is the error response of the chaincode, with status 403, when the identity is
+// not allowed to run the request
+type ForbiddenError struct {
+	Message string
+}
+
+func (err *ForbiddenError) Error() string {
+	return err.Message
+}
+
+// ConflictError is the error response of the chaincode, with status 409, when the record to create
+// already exists or the idempotency key was used by another request
+type ConflictError struct {
+	Message string
+}
+
+func (err *ConflictError) Error() string {
+	return err.Message
+}
+
+// NewResponseError returns the typed error of a chaincode response with the given error status
+func NewResponseError(status int32, message string) error {
+	switch status {
+	case 403:
+		return &ForbiddenError{Message: message}
+	case 404:
+		return &NotFoundError{Message: message}
+	case 409:
+		return &ConflictError{Message: message}
+	default:
+		return &Error{Status: status, Message: message}
+	}
+}
+
+// IsNotFound reports whether the error is a NotFoundError
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// IsForbidden reports whether the error is a ForbiddenError
+func IsForbidden(err error) bool {
+	_, ok := err.(*ForbiddenError)
+	return ok
+}
+
+// IsConflict reports whether the error is a ConflictError
+func IsConflict(err error) bool {
+	_, ok := err.(*ConflictError)
+	return ok
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"google.golang.org/grpc/status"
+)
+
+// GatewayContract is the part of a chaincode contract of the Fabric Gateway client API the
+// gateway transport uses. It is implemented by *client.Contract of
+// github.com/hyperledger/fabric-gateway/pkg/client.
+type GatewayContract interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// chaincodeResponse matches the error status and message of a chaincode response in the errors of
+// Fabric Gateway
+var chaincodeResponse = regexp.MustCompile(`(?s)chaincode response (\d+), (.*)`)
+
+// GatewayTransport is a Transport calling the chaincode through Fabric Gateway
+type GatewayTransport struct {
+	contract GatewayContract
+}
+
+// NewGatewayTransport returns a transport calling the given contract of Fabric Gateway. The
+// timeouts of the calls are the ones of the gateway connection, and a context is only checked
+// before a call.
+func NewGatewayTransport(contract GatewayContract) *GatewayTransport {
+	return &GatewayTransport{contract: contract}
+}
+
+// Submit runs a transaction that is endorsed and committed to the ledger
+func (transport *GatewayTransport) Submit(ctx context.Context, function string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	payload, err := transport.contract.SubmitTransaction(function, args...)
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	return payload, nil
+}
+
+// Evaluate runs a transaction that only reads the ledger and is not committed
+func (transport *GatewayTransport) Evaluate(ctx context.Context, function string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	payload, err := transport.contract.EvaluateTransaction(function, args...)
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	return payload, nil
+}
+
+// gatewayError returns the typed error of the chaincode response found in an error of Fabric
+// Gateway, either in its message or in the messages of its gRPC status details, or the error
+// itself if it holds no chaincode response
+func gatewayError(err error) error {
+	messages := []string{err.Error()}
+	if grpcStatus, ok := status.FromError(err); ok {
+		for _, detail := range grpcStatus.Details() {
+			if detail, ok := detail.(interface{ GetMessage() string }); ok {
+				messages = append(messages, detail.GetMessage())
+			}
+		}
+	}
+
+	for _, message := range messages {
+		if match := chaincodeResponse.FindStringSubmatch(message); match != nil {
+			responseStatus, _ := strconv.Atoi(match[1])
+			return NewResponseError(int32(responseStatus), match[2])
+		}
+	}
+	return err
+}
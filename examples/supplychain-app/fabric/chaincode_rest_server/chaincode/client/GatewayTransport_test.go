@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayContract returns the given payload and error to every transaction
+type gatewayContract struct {
+	payload []byte
+	err     error
+}
+
+func (contract gatewayContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return contract.payload, contract.err
+}
+
+func (contract gatewayContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return contract.payload, contract.err
+}
+
+func TestGatewayTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	ctx := context.Background()
+
+	g.Describe("Gateway Transport", func() {
+		g.It("should return the payload of the transaction", func() {
+			transport := NewGatewayTransport(gatewayContract{payload: []byte(`{"schemaVersion":2}`)})
+
+			config, err := New(transport, "").ReadConfig(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.SchemaVersion).To(Equal(2))
+		})
+
+		g.It("should map the chaincode response in the message of an error", func() {
+			transport := NewGatewayTransport(gatewayContract{err: errors.New("transaction failed: chaincode response 404, Legal Agreement 001 does not exist")})
+
+			_, err := transport.Evaluate(ctx, "readLegalAgreement", `{"ID":"001"}`)
+			Expect(err).To(Equal(&NotFoundError{Message: "Legal Agreement 001 does not exist"}))
+		})
+
+		g.It("should map the chaincode response in the details of a gRPC status", func() {
+			grpcStatus, _ := status.New(codes.Aborted, "failed to endorse transaction, see attached details for more info").
+				WithDetails(&peer.Response{Message: "chaincode response 409, Legal Agreement 001 already exists"})
+			transport := NewGatewayTransport(gatewayContract{err: grpcStatus.Err()})
+
+			_, err := transport.Submit(ctx, "createLegalAgreement", `{"ID":"001"}`)
+			Expect(err).To(Equal(&ConflictError{Message: "Legal Agreement 001 already exists"}))
+		})
+
+		g.It("should return other errors as they are", func() {
+			transport := NewGatewayTransport(gatewayContract{err: errors.New("connection refused")})
+
+			_, err := transport.Submit(ctx, "createLegalAgreement", `{}`)
+			Expect(err).To(MatchError("connection refused"))
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// CreateLegalAgreement creates a legal agreement
+func (client *Client) CreateLegalAgreement(ctx context.Context, request LegalAgreementRequest) (*LegalAgreement, *WriteResponse, error) {
+	request.TenantID = client.tenant(request.TenantID)
+	var legalAgreement LegalAgreement
+	writeResponse, err := client.submit(ctx, "createLegalAgreement", request, &legalAgreement)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &legalAgreement, writeResponse, nil
+}
+
+// ReadLegalAgreement returns the legal agreement with the given ID
+func (client *Client) ReadLegalAgreement(ctx context.Context, id string) (*LegalAgreement, error) {
+	var legalAgreement LegalAgreement
+	request := ReadLegalAgreementRequest{TenantID: client.tenantID, ID: id}
+	if err := client.evaluate(ctx, "readLegalAgreement", request, &legalAgreement); err != nil {
+		return nil, err
+	}
+	return &legalAgreement, nil
+}
+
+// ReadLatestVersionLegalAgreement returns the latest published version of the legal agreement,
+// which has an empty ID if no version is published
+func (client *Client) ReadLatestVersionLegalAgreement(ctx context.Context) (*LegalAgreement, error) {
+	var legalAgreement LegalAgreement
+	request := ReadLatestVersionLegalAgreementRequest{TenantID: client.tenantID}
+	if err := client.evaluate(ctx, "readLatestVersionLegalAgreement", request, &legalAgreement); err != nil {
+		return nil, err
+	}
+	return &legalAgreement, nil
+}
+
+// ReadEffectiveLegalAgreement returns the legal agreement in force at the given unix timestamp,
+// or at the transaction timestamp if it is zero
+func (client *Client) ReadEffectiveLegalAgreement(ctx context.Context, asOf int64) (*LegalAgreement, error) {
+	var legalAgreement LegalAgreement
+	request := ReadEffectiveLegalAgreementRequest{TenantID: client.tenantID, AsOf: asOf}
+	if err := client.evaluate(ctx, "readEffectiveLegalAgreement", request, &legalAgreement); err != nil {
+		return nil, err
+	}
+	return &legalAgreement, nil
+}
+
+// UpdateLegalAgreementStatus moves a legal agreement to another lifecycle state
+func (client *Client) UpdateLegalAgreementStatus(ctx context.Context, request UpdateLegalAgreementStatusRequest) (*LegalAgreement, *WriteResponse, error) {
+	request.TenantID = client.tenant(request.TenantID)
+	var legalAgreement LegalAgreement
+	writeResponse, err := client.submit(ctx, "updateLegalAgreementStatus", request, &legalAgreement)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &legalAgreement, writeResponse, nil
+}
+
+// ComputeContentHash returns the hash the chaincode expects for the given content, computed with
+// the given hash algorithm or the default one if it is empty
+func (client *Client) ComputeContentHash(ctx context.Context, content string, hashAlgorithm string) (string, error) {
+	var response ComputeContentHashResponse
+	request := ComputeContentHashRequest{Content: content, HashAlgorithm: hashAlgorithm}
+	if err := client.evaluate(ctx, "computeContentHash", request, &response); err != nil {
+		return "", err
+	}
+	return response.Hash, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// CreateLegalAgreementSigning records that a user accepted or declined a legal agreement
+func (client *Client) CreateLegalAgreementSigning(ctx context.Context, request LegalAgreementSigningRequest) (*LegalAgreementSigning, *WriteResponse, error) {
+	request.TenantID = client.tenant(request.TenantID)
+	var legalAgreementSigning LegalAgreementSigning
+	writeResponse, err := client.submit(ctx, "createLegalAgreementSigning", request, &legalAgreementSigning)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &legalAgreementSigning, writeResponse, nil
+}
+
+// ReadLegalAgreementSigning returns the legal agreement signing with the given ID
+func (client *Client) ReadLegalAgreementSigning(ctx context.Context, id string) (*LegalAgreementSigning, error) {
+	var legalAgreementSigning LegalAgreementSigning
+	request := ReadLegalAgreementSigningRequest{TenantID: client.tenantID, ID: id}
+	if err := client.evaluate(ctx, "readLegalAgreementSigning", request, &legalAgreementSigning); err != nil {
+		return nil, err
+	}
+	return &legalAgreementSigning, nil
+}
+
+// ReadLatestLegalAgreementSigningByUserID returns the latest legal agreement signing of a user
+func (client *Client) ReadLatestLegalAgreementSigningByUserID(ctx context.Context, userID string) (*LegalAgreementSigning, error) {
+	var legalAgreementSigning LegalAgreementSigning
+	request := ReadLatestLegalAgreementSigningByUserIDRequest{TenantID: client.tenantID, UserID: userID}
+	if err := client.evaluate(ctx, "readLatestLegalAgreementSigningByUserID", request, &legalAgreementSigning); err != nil {
+		return nil, err
+	}
+	return &legalAgreementSigning, nil
+}
+
+// ReadAgreementExecutionStatus returns which parties signed a multi-party legal agreement
+func (client *Client) ReadAgreementExecutionStatus(ctx context.Context, legalAgreementID string) (*AgreementExecutionStatus, error) {
+	var executionStatus AgreementExecutionStatus
+	request := ReadAgreementExecutionStatusRequest{TenantID: client.tenantID, LegalAgreementID: legalAgreementID}
+	if err := client.evaluate(ctx, "readAgreementExecutionStatus", request, &executionStatus); err != nil {
+		return nil, err
+	}
+	return &executionStatus, nil
+}
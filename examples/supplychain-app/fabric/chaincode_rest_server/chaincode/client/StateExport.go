@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/chaincode/common"
+)
+
+// ExportState returns the next chunk of the state export of the ledger, with its header, which
+// requires an admin identity. An empty bookmark exports the first chunk, and the next bookmark of
+// the header is empty after the last one.
+func (client *Client) ExportState(ctx context.Context, request ExportStateRequest) ([]byte, *StateExportHeader, error) {
+	chunk, err := client.call(ctx, client.transport.Evaluate, "exportState", request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers, _, err := DecodeStateExport(chunk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error decoding the response of exportState: %s", err)
+	}
+	return chunk, &headers[0], nil
+}
+
+// ImportState imports chunks of a state export, which requires an admin identity. The records
+// whose key already exists are reported as conflicts and left as they are.
+func (client *Client) ImportState(ctx context.Context, chunk []byte) (*ImportStateResponse, *WriteResponse, error) {
+	var response ImportStateResponse
+	writeResponse, err := client.submit(ctx, "importState", ImportStateRequest{Chunk: string(chunk)}, &response)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &response, writeResponse, nil
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// OnboardTenant onboards a tenant, which requires an admin identity
+func (client *Client) OnboardTenant(ctx context.Context, request OnboardTenantRequest) (*Tenant, *WriteResponse, error) {
+	request.TenantID = client.tenant(request.TenantID)
+	var tenant Tenant
+	writeResponse, err := client.submit(ctx, "onboardTenant", request, &tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &tenant, writeResponse, nil
+}
+
+// ReadTenant returns the tenant of the client
+func (client *Client) ReadTenant(ctx context.Context) (*Tenant, error) {
+	var tenant Tenant
+	request := ReadTenantRequest{TenantID: client.tenantID}
+	if err := client.evaluate(ctx, "readTenant", request, &tenant); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
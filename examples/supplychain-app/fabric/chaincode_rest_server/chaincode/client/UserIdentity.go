@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+
+	. "github.com/chaincode/common"
+)
+
+// CreateUserIdentity creates a user identity
+func (client *Client) CreateUserIdentity(ctx context.Context, request UserIdentityRequest) (*UserIdentity, *WriteResponse, error) {
+	request.TenantID = client.tenant(request.TenantID)
+	var userIdentity UserIdentity
+	writeResponse, err := client.submit(ctx, "createUserIdentity", request, &userIdentity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &userIdentity, writeResponse, nil
+}
+
+// ReadUserIdentity returns the identity of a user
+func (client *Client) ReadUserIdentity(ctx context.Context, userID string) (*UserIdentity, error) {
+	var userIdentity UserIdentity
+	request := ReadUserIdentityRequest{TenantID: client.tenantID, UserID: userID}
+	if err := client.evaluate(ctx, "readUserIdentity", request, &userIdentity); err != nil {
+		return nil, err
+	}
+	return &userIdentity, nil
+}
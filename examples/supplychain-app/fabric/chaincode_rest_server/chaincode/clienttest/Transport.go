@@ -0,0 +1,79 @@
+package clienttest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/chaincode/client"
+	"github.com/chaincode/lglagrmt"
+	"github.com/chaincode/stubtest"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// Transport is a client.Transport that calls the legal agreement contract in process, on the
+// in-memory ledger of stubtest, so code using the client can be tested without a network
+type Transport struct {
+	mutex   sync.Mutex
+	ledger  *stubtest.Stub
+	txCount int
+}
+
+var _ client.Transport = (*Transport)(nil)
+
+// New returns a transport calling a new legal agreement contract, instantiated on an empty ledger
+// by an identity of Org1MSP, so the identities of Org1MSP with the admin role are admins
+func New() (*Transport, error) {
+	transport := &Transport{ledger: stubtest.New("legalagreement", new(lglagrmt.SmartContract))}
+
+	identity, err := stubtest.NewIdentity("Org1MSP", "instantiate", nil)
+	if err != nil {
+		return nil, err
+	}
+	transport.ledger.SetCreator(identity)
+	response := transport.ledger.Init("tx0", [][]byte{[]byte("init")})
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return nil, fmt.Errorf("Error instantiating the chaincode: %s", response.Message)
+	}
+	transport.ledger.SetCreator(nil)
+	return transport, nil
+}
+
+// Ledger returns the ledger of the contract, to set the identity calling it or the clock
+func (transport *Transport) Ledger() *stubtest.Stub {
+	return transport.ledger
+}
+
+// Submit runs a transaction that is committed to the ledger if it succeeds
+func (transport *Transport) Submit(ctx context.Context, function string, args ...string) ([]byte, error) {
+	return transport.call(ctx, transport.ledger.Invoke, function, args)
+}
+
+// Evaluate runs a transaction that is never committed
+func (transport *Transport) Evaluate(ctx context.Context, function string, args ...string) ([]byte, error) {
+	return transport.call(ctx, transport.ledger.Query, function, args)
+}
+
+// call runs a transaction with a new transaction ID and maps its response
+func (transport *Transport) call(ctx context.Context, run func(string, [][]byte) peer.Response, function string, args []string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	byteArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		byteArgs = append(byteArgs, []byte(arg))
+	}
+	transport.txCount++
+	response := run(fmt.Sprintf("tx%d", transport.txCount), byteArgs)
+
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return nil, client.NewResponseError(response.Status, response.Message)
+	}
+	return response.Payload, nil
+}
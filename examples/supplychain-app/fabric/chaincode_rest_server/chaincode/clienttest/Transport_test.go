@@ -0,0 +1,122 @@
+package clienttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaincode/client"
+	. "github.com/chaincode/common"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	ctx := context.Background()
+	var transport *Transport
+	var legalAgreements *client.Client
+
+	g.Describe("Client", func() {
+		g.BeforeEach(func() {
+			var err error
+			transport, err = New()
+			Expect(err).NotTo(HaveOccurred())
+			legalAgreements = client.New(transport, "")
+
+			_, _, err = legalAgreements.CreateLegalAgreement(ctx, LegalAgreementRequest{ID: "001", Content: "first version", Timestamp: 1654027884, Version: 1})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		g.It("should create and read typed records", func() {
+			contentHash, err := legalAgreements.ComputeContentHash(ctx, "first version", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			legalAgreementSigning, writeResponse, err := legalAgreements.CreateLegalAgreementSigning(ctx, LegalAgreementSigningRequest{
+				ID:                        "0001",
+				UserID:                    "u-001",
+				LegalAgreementID:          "001",
+				LegalAgreementContentHash: contentHash,
+				Accepted:                  true,
+				Timestamp:                 1654027900,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(legalAgreementSigning.UserID).To(Equal("u-001"))
+			Expect(writeResponse.CreatedID).To(Equal("0001"))
+			Expect(writeResponse.Events[0].Name).To(Equal(EventLegalAgreementSigningCreated))
+
+			consentStatus, err := legalAgreements.ReadConsentStatus(ctx, "u-001")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consentStatus.Status).To(Equal(ConsentStatusUpToDate))
+			Expect(consentStatus.LegalAgreementSigningID).To(Equal("0001"))
+		})
+
+		g.It("should map error responses to typed errors", func() {
+			_, err := legalAgreements.ReadLegalAgreement(ctx, "002")
+			Expect(client.IsNotFound(err)).To(BeTrue())
+			Expect(err).To(MatchError("Legal Agreement 002 does not exist"))
+
+			_, _, err = legalAgreements.CreateLegalAgreement(ctx, LegalAgreementRequest{ID: "001", Content: "first version", Timestamp: 1654027884, Version: 2})
+			Expect(client.IsConflict(err)).To(BeTrue())
+
+			_, _, err = legalAgreements.CreateLegalAgreement(ctx, LegalAgreementRequest{ID: "002", Content: "second version", Timestamp: 1654027884, Version: 1})
+			Expect(err).To(BeAssignableToTypeOf(&client.Error{}))
+			Expect(err.(*client.Error).Status).To(BeEquivalentTo(400))
+		})
+
+		g.It("should send the requests for the tenant of the client as the identity of the ledger", func() {
+			admin, _ := stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			transport.Ledger().SetCreator(admin)
+			_, _, err := client.New(transport, "acme").OnboardTenant(ctx, OnboardTenantRequest{Name: "Acme", AllowedRoles: []string{"user"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			user, _ := stubtest.NewIdentity("Org1MSP", "user", map[string]string{"role": "user", "tenantID": "acme"})
+			transport.Ledger().SetCreator(user)
+			tenant, err := client.New(transport, "acme").ReadTenant(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tenant.Name).To(Equal("Acme"))
+
+			_, err = legalAgreements.ReadLegalAgreement(ctx, "001")
+			Expect(client.IsForbidden(err)).To(BeTrue())
+		})
+
+		g.It("should export the state of a ledger and import it into another", func() {
+			admin, _ := stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			transport.Ledger().SetCreator(admin)
+			chunk, header, err := legalAgreements.ExportState(ctx, ExportStateRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.RecordsCount).To(BeEquivalentTo(1))
+			Expect(header.NextBookmark).To(BeEmpty())
+
+			other, err := New()
+			Expect(err).NotTo(HaveOccurred())
+			other.Ledger().SetCreator(admin)
+			result, _, err := client.New(other, "").ImportState(ctx, chunk)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ImportedRecordsCount).To(BeEquivalentTo(1))
+
+			legalAgreement, err := client.New(other, "").ReadLegalAgreement(ctx, "001")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(legalAgreement.Content).To(Equal("first version"))
+		})
+
+		g.It("should not commit evaluated transactions", func() {
+			_, err := transport.Evaluate(ctx, "createLegalAgreement", `{"ID":"002","content":"second version","timestamp":1654027884,"version":2}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = legalAgreements.ReadLegalAgreement(ctx, "002")
+			Expect(client.IsNotFound(err)).To(BeTrue())
+		})
+
+		g.It("should return the error of a done context", func() {
+			canceled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, err := legalAgreements.ReadConfig(canceled)
+			Expect(err).To(Equal(context.Canceled))
+		})
+	})
+}
@@ -0,0 +1,27 @@
+package common
+
+// Party declares a party that must sign a multi-party legal agreement, such as the buyer or the
+// supplier of a bilateral contract, identified by the user identity that signs for it
+type Party struct {
+	UserID string `json:"userID"`
+	Role   string `json:"role"`
+}
+
+// PartySigningStatus reports whether a party signed a multi-party legal agreement
+type PartySigningStatus struct {
+	UserID                  string `json:"userID"`
+	Role                    string `json:"role"`
+	Signed                  bool   `json:"signed"`
+	LegalAgreementSigningID string `json:"legalAgreementSigningID"`
+	Timestamp               int64  `json:"timestamp"`
+}
+
+// AgreementExecutionStatus reports the parties of a multi-party legal agreement that signed it.
+// The legal agreement is executed once every party accepted it.
+type AgreementExecutionStatus struct {
+	LegalAgreementID   string               `json:"legalAgreementID"`
+	Version            int64                `json:"version"`
+	Parties            []PartySigningStatus `json:"parties"`
+	SignedPartiesCount int                  `json:"signedPartiesCount"`
+	Executed           bool                 `json:"executed"`
+}
@@ -0,0 +1,7 @@
+package common
+
+// ReadAgreementExecutionStatusRequest models the request to read which parties signed a legal agreement
+type ReadAgreementExecutionStatusRequest struct {
+	TenantID         string `json:"tenantID"`
+	LegalAgreementID string `json:"legalAgreementID"`
+}
@@ -0,0 +1,203 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxExactInteger is the largest magnitude up to which doubles hold every integer exactly
+var maxExactInteger = new(big.Int).Lsh(big.NewInt(1), 53)
+
+// canonicalMember is a member of a JSON object with its value in canonical form
+type canonicalMember struct {
+	key   string
+	value []byte
+}
+
+// MarshalCanonical returns the canonical JSON of a value, as written to the ledger. See
+// CanonicalizeJSON.
+func MarshalCanonical(value interface{}) ([]byte, error) {
+	marshaled, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(marshaled)
+}
+
+// CanonicalizeJSON returns the canonical form of a JSON document, as defined by the JSON
+// Canonicalization Scheme of RFC 8785, so that any implementation writes the same document with
+// the same bytes:
+//
+//  1. There is no whitespace between tokens.
+//  2. The members of objects are ordered by the UTF-16 code units of their names.
+//  3. Strings are written as UTF-8, escaping only the quote, the backslash and control
+//     characters, with the short escapes where they exist and \u00xx otherwise.
+//  4. Numbers are written as ECMAScript writes doubles.
+//
+// Documents that are not valid UTF-8, that have an object with duplicate names or that have an
+// integer beyond 2^53, which a double cannot hold exactly, are rejected.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("The JSON document is not valid UTF-8")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	canonical, err := canonicalValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("The JSON document has data after its value")
+	}
+	return canonical, nil
+}
+
+// canonicalValue reads the next value of a decoder and returns it in canonical form
+func canonicalValue(decoder *json.Decoder) ([]byte, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			result := []byte{'['}
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					result = append(result, ',')
+				}
+				value, err := canonicalValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, value...)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return append(result, ']'), nil
+		}
+
+		members := []canonicalMember{}
+		names := map[string]bool{}
+		for decoder.More() {
+			name, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := name.(string)
+			if names[key] {
+				return nil, fmt.Errorf("The JSON document has duplicate name %q", key)
+			}
+			names[key] = true
+
+			value, err := canonicalValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, canonicalMember{key: key, value: value})
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		sort.Slice(members, func(i, j int) bool { return lessUTF16(members[i].key, members[j].key) })
+		result := []byte{'{'}
+		for i, member := range members {
+			if i > 0 {
+				result = append(result, ',')
+			}
+			result = append(result, canonicalString(member.key)...)
+			result = append(result, ':')
+			result = append(result, member.value...)
+		}
+		return append(result, '}'), nil
+	case string:
+		return canonicalString(token), nil
+	case json.Number:
+		return canonicalNumber(token)
+	case bool:
+		return []byte(strconv.FormatBool(token)), nil
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// canonicalString returns a string in canonical form
+func canonicalString(value string) []byte {
+	result := []byte{'"'}
+	for _, r := range value {
+		switch r {
+		case '"':
+			result = append(result, '\\', '"')
+		case '\\':
+			result = append(result, '\\', '\\')
+		case '\b':
+			result = append(result, '\\', 'b')
+		case '\f':
+			result = append(result, '\\', 'f')
+		case '\n':
+			result = append(result, '\\', 'n')
+		case '\r':
+			result = append(result, '\\', 'r')
+		case '\t':
+			result = append(result, '\\', 't')
+		default:
+			if r < 0x20 {
+				result = append(result, fmt.Sprintf(`\u%04x`, r)...)
+			} else {
+				result = append(result, string(r)...)
+			}
+		}
+	}
+	return append(result, '"')
+}
+
+// canonicalNumber returns a number in canonical form, which is the shortest decimal that reads back
+// as the same double, in exponent notation outside of [1e-6, 1e21)
+func canonicalNumber(number json.Number) ([]byte, error) {
+	value, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil {
+		return nil, fmt.Errorf("The JSON document has the invalid number %s", number)
+	}
+
+	// Integers written without a fraction or an exponent must not be rounded
+	if integer, ok := new(big.Int).SetString(number.String(), 10); ok && integer.CmpAbs(maxExactInteger) > 0 {
+		return nil, fmt.Errorf("The JSON document has the integer %s beyond 2^53", number)
+	}
+	if value == 0 {
+		return []byte("0"), nil
+	}
+	if abs := math.Abs(value); abs >= 1e-6 && abs < 1e21 {
+		return []byte(strconv.FormatFloat(value, 'f', -1, 64)), nil
+	}
+
+	// ECMAScript writes the exponent without leading zeros, as 1e-7 rather than 1e-07
+	formatted := strconv.FormatFloat(value, 'e', -1, 64)
+	index := strings.IndexByte(formatted, 'e')
+	mantissa, sign, exponent := formatted[:index], formatted[index+1], strings.TrimLeft(formatted[index+2:], "0")
+	return []byte(fmt.Sprintf("%se%c%s", mantissa, sign, exponent)), nil
+}
+
+// lessUTF16 reports whether a name is ordered before another by their UTF-16 code units
+func lessUTF16(a string, b string) bool {
+	unitsA, unitsB := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(unitsA) && i < len(unitsB); i++ {
+		if unitsA[i] != unitsB[i] {
+			return unitsA[i] < unitsB[i]
+		}
+	}
+	return len(unitsA) < len(unitsB)
+}
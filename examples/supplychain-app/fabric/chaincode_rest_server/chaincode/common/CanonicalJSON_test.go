@@ -0,0 +1,60 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// canonicalize returns the canonical form of a document as a string
+	canonicalize := func(document string) string {
+		canonical, err := CanonicalizeJSON([]byte(document))
+		Expect(err).NotTo(HaveOccurred())
+		return string(canonical)
+	}
+
+	g.Describe("Canonical JSON", func() {
+		g.It("should write the example of RFC 8785", func() {
+			input := `{
+				"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+				"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+				"literals": [null, true, false]
+			}`
+			Expect(canonicalize(input)).To(Equal(`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`))
+		})
+
+		g.It("should order names by their UTF-16 code units", func() {
+			input := `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`
+			Expect(canonicalize(input)).To(Equal("{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"))
+		})
+
+		g.It("should write numbers as ECMAScript does", func() {
+			Expect(canonicalize(`[0, -0, 1e21, 1e20, 1e-7, 0.000001, 123e-8, 9007199254740992, -1.5e300]`)).To(
+				Equal(`[0,0,1e+21,100000000000000000000,1e-7,0.000001,0.00000123,9007199254740992,-1.5e+300]`))
+		})
+
+		g.It("should not escape HTML characters, unlike json.Marshal", func() {
+			canonical, err := MarshalCanonical(map[string]string{"b": "<a&b>", "a": "\u2028"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(canonical)).To(Equal("{\"a\":\"\u2028\",\"b\":\"<a&b>\"}"))
+		})
+
+		g.It("should reject documents that have no canonical form", func() {
+			for document, message := range map[string]string{
+				`{"a":1,"a":2}`:       `The JSON document has duplicate name "a"`,
+				`[1e400]`:             "The JSON document has the invalid number 1e400",
+				`[-9007199254740993]`: "The JSON document has the integer -9007199254740993 beyond 2^53",
+				"[\"\xff\"]":          "The JSON document is not valid UTF-8",
+				`{"a":1} {"b":2}`:     "The JSON document has data after its value",
+			} {
+				_, err := CanonicalizeJSON([]byte(document))
+				Expect(err).To(MatchError(message))
+			}
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package common
+
+// Schema versions of the stored records
+const (
+	// LegacySchemaVersion is the schema version of the records written before they carried one
+	LegacySchemaVersion = 1
+	// CurrentSchemaVersion is the schema version of the records written by this version of the contract
+	CurrentSchemaVersion = 2
+)
+
+// Strategies to generate the ID of a record created without one
+const (
+	// IDStrategyTxID derives the ID from the transaction ID
+	IDStrategyTxID = "txID"
+	// IDStrategyContent derives the ID from the content of the request, so identical requests get the same ID
+	IDStrategyContent = "content"
+)
+
+// ConfigRoles names the roles, as found in the role attribute of an identity, that the contract checks
+type ConfigRoles struct {
+	// Admin is the role allowed to onboard tenants, access every tenant and update the configuration
+	Admin string `json:"admin"`
+	// AdminMSPs are the MSP IDs of the orgs whose identities with the admin role are admins. Any
+	// org can issue the admin role, so an identity of another org is never an admin.
+	AdminMSPs []string `json:"adminMSPs"`
+}
+
+// ConfigLimits stores the limits used to validate requests. A zero limit is not enforced.
+type ConfigLimits struct {
+	MaxContentLength int   `json:"maxContentLength"`
+	DefaultPageSize  int32 `json:"defaultPageSize"`
+	MaxPageSize      int32 `json:"maxPageSize"`
+}
+
+// ConfigFeatures stores the feature toggles of the contract
+type ConfigFeatures struct {
+	// SequentialVersions requires every new legal agreement version to follow the latest one by exactly 1
+	SequentialVersions bool `json:"sequentialVersions"`
+	// OrgEndorsementPolicy requires the owning org to endorse any change to its legal agreements
+	OrgEndorsementPolicy bool `json:"orgEndorsementPolicy"`
+	// IDStrategy selects how the ID of a legal agreement or signing created without one is generated
+	IDStrategy string `json:"idStrategy"`
+}
+
+// Config stores the configuration of the contract
+type Config struct {
+	// SchemaVersion is the schema version every stored record has been migrated to
+	SchemaVersion int            `json:"schemaVersion"`
+	Roles         ConfigRoles    `json:"roles"`
+	Limits        ConfigLimits   `json:"limits"`
+	Features      ConfigFeatures `json:"features"`
+}
+
+// DefaultConfig returns the configuration used until one is written to the ledger
+func DefaultConfig() Config {
+	return Config{
+		SchemaVersion: CurrentSchemaVersion,
+		Roles: ConfigRoles{
+			Admin:     "admin",
+			AdminMSPs: []string{},
+		},
+		Limits: ConfigLimits{
+			DefaultPageSize: 100,
+			MaxPageSize:     1000,
+		},
+		Features: ConfigFeatures{
+			OrgEndorsementPolicy: true,
+			IDStrategy:           IDStrategyTxID,
+		},
+	}
+}
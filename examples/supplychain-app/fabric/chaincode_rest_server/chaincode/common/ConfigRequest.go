@@ -0,0 +1,10 @@
+package common
+
+// UpdateConfigRequest models the request to update the configuration. It is also the optional
+// argument of Init. Only the fields present in the request are changed.
+type UpdateConfigRequest struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Roles         ConfigRoles    `json:"roles"`
+	Limits        ConfigLimits   `json:"limits"`
+	Features      ConfigFeatures `json:"features"`
+}
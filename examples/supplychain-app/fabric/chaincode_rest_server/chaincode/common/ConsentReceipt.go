@@ -0,0 +1,229 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ConsentReceiptVersion is the version of the format of consent receipts. It changes with any
+// change to the fields of a receipt or to how its commitment is computed.
+const ConsentReceiptVersion = 1
+
+// ConsentReceipt is a portable proof that a legal agreement signing was recorded. It is written
+// with the signing, so it can be checked against the block of its transaction or a state export,
+// or signed with the endorsements of that block by the receipt package. The commitment is the root of a Merkle tree whose leaves are the other fields, in order, so a
+// changed field no longer matches it, and a single field can be disclosed with a proof.
+type ConsentReceipt struct {
+	ReceiptVersion   int    `json:"receiptVersion"`
+	TenantID         string `json:"tenantID"`
+	SigningID        string `json:"signingID"`
+	UserID           string `json:"userID"`
+	LegalAgreementID string `json:"legalAgreementID"`
+	ContentHash      string `json:"contentHash"`
+	Accepted         bool   `json:"accepted"`
+	// Timestamp is the timestamp of the transaction that recorded the signing
+	Timestamp     int64  `json:"timestamp"`
+	TxID          string `json:"txID"`
+	HashAlgorithm string `json:"hashAlgorithm"`
+	Commitment    string `json:"commitment"`
+}
+
+// ReceiptFieldProof proves the value of a single field of a consent receipt against its
+// commitment. The path holds the hex encoded hashes of the siblings of the leaf of the field,
+// from the leaf up to the root.
+type ReceiptFieldProof struct {
+	Field       string          `json:"field"`
+	Value       json.RawMessage `json:"value"`
+	Index       int             `json:"index"`
+	LeavesCount int             `json:"leavesCount"`
+	Path        []string        `json:"path"`
+}
+
+// receiptFieldNames are the names of the leaves of the Merkle tree of a consent receipt, in order
+var receiptFieldNames = []string{
+	"receiptVersion", "tenantID", "signingID", "userID", "legalAgreementID", "contentHash", "accepted", "timestamp", "txID",
+}
+
+// NewConsentReceipt returns the receipt of a legal agreement signing recorded by the given
+// transaction, with its commitment
+func NewConsentReceipt(legalAgreementSigning LegalAgreementSigning, txID string, timestamp int64) ConsentReceipt {
+	receipt := ConsentReceipt{
+		ReceiptVersion:   ConsentReceiptVersion,
+		TenantID:         legalAgreementSigning.TenantID,
+		SigningID:        legalAgreementSigning.ID,
+		UserID:           legalAgreementSigning.UserID,
+		LegalAgreementID: legalAgreementSigning.LegalAgreementID,
+		ContentHash:      legalAgreementSigning.LegalAgreementContentHash,
+		Accepted:         legalAgreementSigning.Accepted,
+		Timestamp:        timestamp,
+		TxID:             txID,
+		HashAlgorithm:    HashAlgorithmSHA256,
+	}
+	receipt.Commitment = hex.EncodeToString(merkleRoot(receipt.leaves()))
+	return receipt
+}
+
+// Verify checks that the commitment of the receipt matches its fields
+func (receipt ConsentReceipt) Verify() error {
+	if receipt.ReceiptVersion != ConsentReceiptVersion {
+		return fmt.Errorf("Unsupported receipt version %d", receipt.ReceiptVersion)
+	}
+	if receipt.HashAlgorithm != HashAlgorithmSHA256 {
+		return fmt.Errorf("Unsupported hash algorithm: %s", receipt.HashAlgorithm)
+	}
+	if hex.EncodeToString(merkleRoot(receipt.leaves())) != receipt.Commitment {
+		return errors.New("The fields of the receipt do not match its commitment")
+	}
+	return nil
+}
+
+// Prove returns the proof of a field of the receipt, named as in JSON
+func (receipt ConsentReceipt) Prove(field string) (*ReceiptFieldProof, error) {
+	index := receiptFieldIndex(field)
+	if index < 0 {
+		return nil, fmt.Errorf("Receipts have no field %s", field)
+	}
+
+	values := receipt.values()
+	proof := &ReceiptFieldProof{Field: field, Value: values[index], Index: index, LeavesCount: len(values), Path: []string{}}
+	for _, sibling := range merklePath(receipt.leaves(), index) {
+		proof.Path = append(proof.Path, hex.EncodeToString(sibling))
+	}
+	return proof, nil
+}
+
+// Verify checks that the field of the proof has its value in the receipt of the given commitment
+func (proof ReceiptFieldProof) Verify(commitment string) error {
+	if index := receiptFieldIndex(proof.Field); index < 0 || index != proof.Index || proof.LeavesCount != len(receiptFieldNames) {
+		return fmt.Errorf("The proof of field %s is not at its position", proof.Field)
+	}
+
+	path := make([][]byte, len(proof.Path))
+	for i, sibling := range proof.Path {
+		hash, err := hex.DecodeString(sibling)
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("Invalid hash %s in the proof of field %s", sibling, proof.Field)
+		}
+		path[i] = hash
+	}
+
+	root, err := merkleRootFromPath(receiptLeaf(proof.Field, proof.Value), proof.Index, proof.LeavesCount, path)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(root) != commitment {
+		return fmt.Errorf("The proof of field %s does not match the commitment", proof.Field)
+	}
+	return nil
+}
+
+// values returns the canonical JSON of the values of the fields of the receipt, in the order of
+// its leaves
+func (receipt ConsentReceipt) values() []json.RawMessage {
+	values := []interface{}{
+		receipt.ReceiptVersion, receipt.TenantID, receipt.SigningID, receipt.UserID, receipt.LegalAgreementID,
+		receipt.ContentHash, receipt.Accepted, receipt.Timestamp, receipt.TxID,
+	}
+	encoded := make([]json.RawMessage, len(values))
+	for i, value := range values {
+		encoded[i], _ = MarshalCanonical(value)
+	}
+	return encoded
+}
+
+// leaves returns the leaves of the Merkle tree of the receipt
+func (receipt ConsentReceipt) leaves() [][]byte {
+	values := receipt.values()
+	leaves := make([][]byte, len(values))
+	for i, value := range values {
+		leaves[i] = receiptLeaf(receiptFieldNames[i], value)
+	}
+	return leaves
+}
+
+// receiptFieldIndex returns the position of the leaf of a field, or -1 if receipts have no such field
+func receiptFieldIndex(field string) int {
+	for i, name := range receiptFieldNames {
+		if name == field {
+			return i
+		}
+	}
+	return -1
+}
+
+// receiptLeaf returns the hash of the leaf of a field. Leaves and nodes are hashed with a
+// different prefix, as in RFC 6962, so a node cannot be passed off as a leaf.
+func receiptLeaf(field string, value []byte) []byte {
+	hash := sha256.Sum256(bytes.Join([][]byte{{0}, []byte(field), {0}, value}, nil))
+	return hash[:]
+}
+
+// merkleNode returns the hash of a node of a Merkle tree from the hashes of its children
+func merkleNode(left []byte, right []byte) []byte {
+	hash := sha256.Sum256(bytes.Join([][]byte{{1}, left, right}, nil))
+	return hash[:]
+}
+
+// merkleSplit returns the number of leaves of the left subtree of a tree of n leaves, which is the
+// largest power of two below n
+func merkleSplit(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
+// merkleRoot returns the root of the Merkle tree of the given leaves, built as in RFC 6962
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := merkleSplit(len(leaves))
+	return merkleNode(merkleRoot(leaves[:k]), merkleRoot(leaves[k:]))
+}
+
+// merklePath returns the hashes of the siblings of a leaf, from the leaf up to the root
+func merklePath(leaves [][]byte, index int) [][]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := merkleSplit(len(leaves))
+	if index < k {
+		return append(merklePath(leaves[:k], index), merkleRoot(leaves[k:]))
+	}
+	return append(merklePath(leaves[k:], index-k), merkleRoot(leaves[:k]))
+}
+
+// merkleRootFromPath returns the root of a Merkle tree of count leaves from a leaf at the given
+// index and the path of its siblings
+func merkleRootFromPath(leaf []byte, index int, count int, path [][]byte) ([]byte, error) {
+	if count == 1 {
+		if len(path) != 0 {
+			return nil, errors.New("The proof has more hashes than the tree has levels")
+		}
+		return leaf, nil
+	}
+	if len(path) == 0 {
+		return nil, errors.New("The proof has fewer hashes than the tree has levels")
+	}
+
+	k := merkleSplit(count)
+	sibling, rest := path[len(path)-1], path[:len(path)-1]
+	if index < k {
+		left, err := merkleRootFromPath(leaf, index, k, rest)
+		if err != nil {
+			return nil, err
+		}
+		return merkleNode(left, sibling), nil
+	}
+	right, err := merkleRootFromPath(leaf, index-k, count-k, rest)
+	if err != nil {
+		return nil, err
+	}
+	return merkleNode(sibling, right), nil
+}
@@ -0,0 +1,20 @@
+package common
+
+// ConsentScope declares a named consent scope of a legal agreement, such as marketing or analytics.
+// Required scopes are accepted with the legal agreement, optional ones are decided one by one.
+type ConsentScope struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// ScopeConsent reports whether a user consented to a scope of the legal agreement in force
+type ScopeConsent struct {
+	UserID                  string `json:"userID"`
+	Scope                   string `json:"scope"`
+	Consented               bool   `json:"consented"`
+	Status                  string `json:"status"`
+	LegalAgreementID        string `json:"legalAgreementID"`
+	Version                 int64  `json:"version"`
+	LegalAgreementSigningID string `json:"legalAgreementSigningID"`
+}
@@ -0,0 +1,31 @@
+package common
+
+// Consent statuses of a user against the legal agreement in force
+const (
+	ConsentStatusUpToDate = "up-to-date"
+	ConsentStatusOutdated = "outdated"
+	ConsentStatusDeclined = "declined"
+	ConsentStatusRevoked  = "revoked"
+)
+
+// ConsentStatus models the consent status of a user, resolved against the legal agreement in force
+type ConsentStatus struct {
+	UserID                      string                 `json:"userID"`
+	Status                      string                 `json:"status"`
+	IdentityStatus              string                 `json:"identityStatus"`
+	LegalAgreementSigningID     string                 `json:"legalAgreementSigningID"`
+	LatestLegalAgreementSigning *LegalAgreementSigning `json:"latestLegalAgreementSigning"`
+	Accepted                    bool                   `json:"accepted"`
+	SignedVersion               int64                  `json:"signedVersion"`
+	CurrentLegalAgreementID     string                 `json:"currentLegalAgreementID"`
+	CurrentVersion              int64                  `json:"currentVersion"`
+	MustReconsent               bool                   `json:"mustReconsent"`
+}
+
+// ListUsersRequiringReconsentResponse models the users who must consent again among a page of the
+// users who signed
+type ListUsersRequiringReconsentResponse struct {
+	Users               []ConsentStatus `json:"users"`
+	Bookmark            string          `json:"bookmark"`
+	FetchedRecordsCount int32           `json:"fetchedRecordsCount"`
+}
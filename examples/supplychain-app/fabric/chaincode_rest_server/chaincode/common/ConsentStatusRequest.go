@@ -0,0 +1,24 @@
+package common
+
+// ReadConsentStatusRequest models the request to read the consent status of a user
+type ReadConsentStatusRequest struct {
+	TenantID string `json:"tenantID"`
+	UserID   string `json:"userID"`
+	AsOf     int64  `json:"asOf"`
+}
+
+// ListUsersRequiringReconsentRequest models the request to list users who must consent again
+type ListUsersRequiringReconsentRequest struct {
+	TenantID string `json:"tenantID"`
+	PageSize int32  `json:"pageSize"`
+	Bookmark string `json:"bookmark"`
+	AsOf     int64  `json:"asOf"`
+}
+
+// ReadScopeConsentRequest models the request to read whether a user consented to a scope
+type ReadScopeConsentRequest struct {
+	TenantID string `json:"tenantID"`
+	UserID   string `json:"userID"`
+	Scope    string `json:"scope"`
+	AsOf     int64  `json:"asOf"`
+}
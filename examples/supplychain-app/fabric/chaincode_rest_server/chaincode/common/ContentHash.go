@@ -0,0 +1,72 @@
+package common
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Supported content hash algorithms
+const (
+	HashAlgorithmSHA256  = "SHA-256"
+	HashAlgorithmSHA384  = "SHA-384"
+	HashAlgorithmSHA3256 = "SHA3-256"
+
+	// DefaultHashAlgorithm is used when a request does not name an algorithm
+	DefaultHashAlgorithm = HashAlgorithmSHA256
+)
+
+// ComputeContentHashResponse models the hash the contract expects for a legal agreement content
+type ComputeContentHashResponse struct {
+	Hash          string `json:"hash"`
+	HashAlgorithm string `json:"hashAlgorithm"`
+}
+
+// CanonicalizeContent returns the canonical form of a legal agreement content.
+// The content hash is always computed over this form, so clients must apply
+// the same steps (or call computeContentHash) before hashing:
+//
+//  1. Unicode text is normalized to NFC.
+//  2. CRLF and lone CR line endings are converted to LF.
+//  3. Trailing spaces and tabs are removed from every line.
+//  4. Trailing empty lines at the end of the content are removed.
+func CanonicalizeContent(content string) string {
+	content = norm.NFC.String(content)
+	content = strings.Replace(content, "\r\n", "\n", -1)
+	content = strings.Replace(content, "\r", "\n", -1)
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// ComputeContentHash returns the hex encoded digest of the canonical content
+// using the given algorithm. An empty algorithm selects DefaultHashAlgorithm.
+func ComputeContentHash(content string, algorithm string) (string, error) {
+	if algorithm == "" {
+		algorithm = DefaultHashAlgorithm
+	}
+
+	var hasher hash.Hash
+	switch algorithm {
+	case HashAlgorithmSHA256:
+		hasher = sha256.New()
+	case HashAlgorithmSHA384:
+		hasher = sha512.New384()
+	case HashAlgorithmSHA3256:
+		hasher = sha3.New256()
+	default:
+		return "", fmt.Errorf("Unsupported hash algorithm: %s", algorithm)
+	}
+
+	hasher.Write([]byte(CanonicalizeContent(content)))
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
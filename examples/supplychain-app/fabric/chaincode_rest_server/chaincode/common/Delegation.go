@@ -0,0 +1,73 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Relationships between a delegate and the user they sign for
+const (
+	DelegationRelationshipGuardian  = "guardian"
+	DelegationRelationshipSignatory = "signatory"
+)
+
+// Statuses of a delegation
+const (
+	DelegationStatusActive  = "active"
+	DelegationStatusRevoked = "revoked"
+)
+
+// Delegation stores the permission of a user identity to sign legal agreements on behalf of another.
+// An effectiveUntil of 0 leaves the delegation open ended.
+type Delegation struct {
+	SchemaVersion  int    `json:"schemaVersion"`
+	TenantID       string `json:"tenantID"`
+	ID             string `json:"ID"`
+	UserID         string `json:"userID"`
+	DelegateID     string `json:"delegateID"`
+	Relationship   string `json:"relationship"`
+	Status         string `json:"status"`
+	EffectiveFrom  int64  `json:"effectiveFrom"`
+	EffectiveUntil int64  `json:"effectiveUntil"`
+	RevokedAt      int64  `json:"revokedAt"`
+}
+
+// UnmarshalJSON will override unmarshal
+func (delegation *Delegation) UnmarshalJSON(data []byte) error {
+	var input map[string]interface{}
+	err := json.Unmarshal(data, &input)
+	if err != nil {
+		return err
+	}
+
+	if input["delegateID"] == nil || input["relationship"] == nil {
+		return errors.New("Not a Delegation")
+	}
+
+	// Prevent circular reference
+	type Alias Delegation
+	var output Alias
+	err = json.Unmarshal(data, &output)
+	if err != nil {
+		return err
+	}
+
+	c := Delegation(output)
+	*delegation = c
+
+	return nil
+}
+
+// IsEffective reports whether the delegation allows signing at the given timestamp
+func (delegation *Delegation) IsEffective(timestamp int64) bool {
+	return delegation.Status == DelegationStatusActive &&
+		delegation.EffectiveFrom <= timestamp &&
+		(delegation.EffectiveUntil == 0 || timestamp < delegation.EffectiveUntil)
+}
+
+// OnBehalfOf records that a legal agreement signing was signed by a delegate of its user
+type OnBehalfOf struct {
+	SignerID     string `json:"signerID"`
+	Relationship string `json:"relationship"`
+	DelegationID string `json:"delegationID"`
+}
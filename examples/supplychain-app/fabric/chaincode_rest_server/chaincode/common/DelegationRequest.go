@@ -0,0 +1,25 @@
+package common
+
+// GrantDelegationRequest models the request to allow a user identity to sign on behalf of another
+type GrantDelegationRequest struct {
+	TenantID       string `json:"tenantID"`
+	ID             string `json:"ID"`
+	UserID         string `json:"userID"`
+	DelegateID     string `json:"delegateID"`
+	Relationship   string `json:"relationship"`
+	EffectiveFrom  int64  `json:"effectiveFrom"`
+	EffectiveUntil int64  `json:"effectiveUntil"`
+	IdempotencyKey string `json:"idempotencyKey"`
+}
+
+// RevokeDelegationRequest models the request to revoke a delegation
+type RevokeDelegationRequest struct {
+	TenantID string `json:"tenantID"`
+	ID       string `json:"ID"`
+}
+
+// ReadDelegationRequest models the request to read a delegation
+type ReadDelegationRequest struct {
+	TenantID string `json:"tenantID"`
+	ID       string `json:"ID"`
+}
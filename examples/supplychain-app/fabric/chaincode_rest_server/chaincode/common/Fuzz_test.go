@@ -0,0 +1,301 @@
+//go:build go1.18
+// +build go1.18
+
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// documents returns a new value of every document type stored in the ledger, keyed by the error
+// its UnmarshalJSON returns for another type of record
+func documents() map[string]interface{} {
+	return map[string]interface{}{
+		"Not a LegalAgreement":        new(LegalAgreement),
+		"Not a LegalAgreementSigning": new(LegalAgreementSigning),
+		"Not a UserIdentity":          new(UserIdentity),
+		"Not a Tenant":                new(Tenant),
+		"Not a Delegation":            new(Delegation),
+	}
+}
+
+// fuzzDecoder fuzzes the decoding of a type. Decoding must not panic, and a decoded value must
+// encode to JSON that decodes to the same value. The optional check is run on every decoded value.
+func fuzzDecoder(f *testing.F, newValue func() interface{}, check func(t *testing.T, encoded []byte), seeds ...string) {
+	for _, seed := range append(seeds, `{}`, `null`, `[]`, `{"ID":1}`, `{"tenantID":"acme","tenantID":null}`) {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value := newValue()
+		if err := json.Unmarshal(data, value); err != nil {
+			return
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("Error marshaling %T decoded from %q: %s", value, data, err)
+		}
+		decoded := newValue()
+		if err := json.Unmarshal(encoded, decoded); err != nil {
+			t.Fatalf("Error unmarshaling %s, encoded from %q: %s", encoded, data, err)
+		}
+		if !reflect.DeepEqual(value, decoded) {
+			t.Fatalf("%q decodes to %+v, but its encoding %s decodes to %+v", data, value, encoded, decoded)
+		}
+
+		if check != nil {
+			check(t, encoded)
+		}
+	})
+}
+
+// fuzzDocument fuzzes the decoding of a document type like fuzzDecoder, and also checks that an
+// encoded document is not mistaken for any other type of document sharing the keyspace
+func fuzzDocument(f *testing.F, notA string, seeds ...string) {
+	fuzzDecoder(f, func() interface{} { return documents()[notA] }, func(t *testing.T, encoded []byte) {
+		for otherNotA, other := range documents() {
+			if otherNotA == notA {
+				continue
+			}
+			if err := json.Unmarshal(encoded, other); err == nil || err.Error() != otherNotA {
+				t.Fatalf("%s is read as a %T: %v", encoded, other, err)
+			}
+		}
+	}, seeds...)
+}
+
+func FuzzLegalAgreement(f *testing.F) {
+	fuzzDocument(f, "Not a LegalAgreement",
+		`{"ID":"001","content":"first version","hash":"80d8f975e768eecac59d22a788bf8e811e51ca85e309ee47f1e821e3e58280f2","timestamp":1654027884,"version":1}`,
+		`{"version":null}`,
+		`{"version":2,"status":"superseded","scopes":[{"name":"marketing","required":false}],"parties":[{"userID":"u-001","role":"buyer"}]}`,
+	)
+}
+
+func FuzzLegalAgreementSigning(f *testing.F) {
+	fuzzDocument(f, "Not a LegalAgreementSigning",
+		`{"ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"80d8","accepted":true,"timestamp":1654027900}`,
+		`{"userID":"u-001","legalAgreementID":null}`,
+		`{"userID":"u-001","legalAgreementID":"001","scopeDecisions":{"marketing":false},"onBehalfOf":{"signerID":"u-002","relationship":"guardian","delegationID":"d-001"}}`,
+	)
+}
+
+func FuzzUserIdentity(f *testing.F) {
+	fuzzDocument(f, "Not a UserIdentity",
+		`{"userID":"u-001","legalAgreementSigningTxID":"tx1","status":"revoked"}`,
+	)
+}
+
+func FuzzTenant(f *testing.F) {
+	fuzzDocument(f, "Not a Tenant",
+		`{"tenantID":"acme","name":"Acme","allowedRoles":["user"],"requireSignature":true}`,
+		`{"allowedRoles":[]}`,
+		`{"allowedRoles":null}`,
+	)
+}
+
+func FuzzDelegation(f *testing.F) {
+	fuzzDocument(f, "Not a Delegation",
+		`{"ID":"d-001","userID":"u-001","delegateID":"u-002","relationship":"guardian","status":"active","effectiveUntil":1654027900}`,
+	)
+}
+
+func FuzzLegalAgreementRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(LegalAgreementRequest) }, nil,
+		`{"ID":"001","content":"first version","timestamp":1654027884,"version":1,"status":"draft","idempotencyKey":"k-001"}`,
+		`{"content":"a\r\nb","hashAlgorithm":"sha3-256","effectiveFrom":10,"effectiveUntil":5,"scopes":[{"name":"analytics","required":true}],"parties":[]}`,
+	)
+}
+
+func FuzzLegalAgreementSigningRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(LegalAgreementSigningRequest) }, nil,
+		`{"ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"80d8","accepted":true,"timestamp":1654027900}`,
+		`{"userID":"u-001","scopeDecisions":{"marketing":true,"analytics":false},"delegationID":"d-001","declineReason":"no"}`,
+	)
+}
+
+func FuzzReadLegalAgreementRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadLegalAgreementRequest) }, nil, `{"tenantID":"acme","ID":"001"}`)
+}
+
+func FuzzReadLatestVersionLegalAgreementRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadLatestVersionLegalAgreementRequest) }, nil, `{"tenantID":"acme"}`)
+}
+
+func FuzzReadEffectiveLegalAgreementRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadEffectiveLegalAgreementRequest) }, nil, `{"tenantID":"acme","asOf":1654027884}`)
+}
+
+func FuzzUpdateLegalAgreementStatusRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(UpdateLegalAgreementStatusRequest) }, nil, `{"tenantID":"acme","ID":"001","status":"retired"}`)
+}
+
+func FuzzComputeContentHashRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ComputeContentHashRequest) }, nil, `{"content":"first version","hashAlgorithm":"sha256"}`)
+}
+
+func FuzzReadLegalAgreementSigningRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadLegalAgreementSigningRequest) }, nil, `{"tenantID":"acme","ID":"0001"}`)
+}
+
+func FuzzReadLatestLegalAgreementSigningByUserIDRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadLatestLegalAgreementSigningByUserIDRequest) }, nil, `{"tenantID":"acme","userID":"u-001"}`)
+}
+
+func FuzzUserIdentityRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(UserIdentityRequest) }, nil, `{"userID":"u-001","legalAgreementSigningTxID":"tx1","verifiableCredential":"vc","status":"revoked","idempotencyKey":"k-001"}`)
+}
+
+func FuzzReadUserIdentityRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadUserIdentityRequest) }, nil, `{"tenantID":"acme","userID":"u-001"}`)
+}
+
+func FuzzOnboardTenantRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(OnboardTenantRequest) }, nil, `{"tenantID":"acme","name":"Acme","allowedRoles":["user","auditor"],"requireSignature":true,"timestamp":1654027884}`)
+}
+
+func FuzzReadTenantRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadTenantRequest) }, nil, `{"tenantID":"acme"}`)
+}
+
+func FuzzGrantDelegationRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(GrantDelegationRequest) }, nil, `{"ID":"d-001","userID":"u-001","delegateID":"u-002","relationship":"signatory","effectiveFrom":1,"effectiveUntil":2}`)
+}
+
+func FuzzRevokeDelegationRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(RevokeDelegationRequest) }, nil, `{"tenantID":"acme","ID":"d-001"}`)
+}
+
+func FuzzReadDelegationRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadDelegationRequest) }, nil, `{"tenantID":"acme","ID":"d-001"}`)
+}
+
+func FuzzReadConsentStatusRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadConsentStatusRequest) }, nil, `{"tenantID":"acme","userID":"u-001","asOf":1654027884}`)
+}
+
+func FuzzListUsersRequiringReconsentRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ListUsersRequiringReconsentRequest) }, nil, `{"tenantID":"acme","pageSize":2,"bookmark":"u-002","asOf":-1}`)
+}
+
+func FuzzReadScopeConsentRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadScopeConsentRequest) }, nil, `{"userID":"u-001","scope":"marketing"}`)
+}
+
+func FuzzReadAgreementExecutionStatusRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ReadAgreementExecutionStatusRequest) }, nil, `{"tenantID":"acme","legalAgreementID":"001"}`)
+}
+
+func FuzzUpdateConfigRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(UpdateConfigRequest) }, nil, `{"roles":{"admin":"root"},"limits":{"maxContentLength":10,"maxPageSize":0},"features":{"sequentialVersions":true,"idStrategy":"content"}}`)
+}
+
+func FuzzMigrateRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(MigrateRequest) }, nil, `{"pageSize":2147483648}`)
+}
+
+func FuzzExportStateRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ExportStateRequest) }, nil, `{"pageSize":2,"bookmark":"acme~001"}`)
+}
+
+func FuzzImportStateRequest(f *testing.F) {
+	fuzzDecoder(f, func() interface{} { return new(ImportStateRequest) }, nil, `{"chunk":"{\"format\":\"lglagrmt-state\"}\n"}`)
+}
+
+// FuzzDecodeStateExport checks that decoding a state export does not panic, and that the records
+// of a decoded export encode to a chunk that decodes to the same records
+func FuzzDecodeStateExport(f *testing.F) {
+	chunk, _ := EncodeStateExport(StateExportHeader{SchemaVersion: CurrentSchemaVersion, NextBookmark: "002"}, []StateExportRecord{
+		{Type: RecordTypeLegalAgreement, Key: "001", Value: json.RawMessage(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)},
+		{Type: RecordTypeTenant, Key: "~tenant~acme", Value: json.RawMessage(`{"tenantID":"acme","allowedRoles":["<user>"]}`)},
+	})
+	empty, _ := EncodeStateExport(StateExportHeader{}, nil)
+	for _, seed := range [][]byte{chunk, append(append([]byte{}, chunk...), empty...), empty, []byte(`{"format":1}`), []byte("\n\n")} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		headers, records, err := DecodeStateExport(data)
+		if err != nil {
+			return
+		}
+
+		// Only values that can be written in canonical JSON can be encoded
+		for _, record := range records {
+			if _, err := CanonicalizeJSON(record.Value); err != nil {
+				return
+			}
+		}
+
+		encoded, err := EncodeStateExport(headers[0], append([]StateExportRecord{}, records...))
+		if err != nil {
+			t.Fatalf("Error encoding the records decoded from %q: %s", data, err)
+		}
+		_, decoded, err := DecodeStateExport(encoded)
+		if err != nil {
+			t.Fatalf("Error decoding %q, encoded from %q: %s", encoded, data, err)
+		}
+		if len(decoded) != len(records) {
+			t.Fatalf("%q decodes to %d records, but its encoding %q to %d", data, len(records), encoded, len(decoded))
+		}
+		for i := range records {
+			if decoded[i].Key != records[i].Key || decoded[i].Type != records[i].Type {
+				t.Fatalf("%q decodes to %+v, but its encoding %q to %+v", data, records[i], encoded, decoded[i])
+			}
+		}
+	})
+}
+
+// FuzzReceiptFieldProof checks that verifying the proof of a field does not panic, and that only
+// the proof of the value of the field verifies against the commitment of a receipt
+func FuzzReceiptFieldProof(f *testing.F) {
+	receipt := NewConsentReceipt(LegalAgreementSigning{TenantID: "acme", ID: "0001", UserID: "u-001", LegalAgreementID: "001", Accepted: true}, "tx1", 1654027900)
+	proof, _ := receipt.Prove("userID")
+	seed, _ := json.Marshal(proof)
+	f.Add(seed)
+	f.Add([]byte(`{"field":"userID","value":"u-001","index":3,"leavesCount":9,"path":["00"]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var fuzzed ReceiptFieldProof
+		if err := json.Unmarshal(data, &fuzzed); err != nil {
+			return
+		}
+		if fuzzed.Verify(receipt.Commitment) != nil {
+			return
+		}
+		expected, _ := receipt.Prove(fuzzed.Field)
+		if string(fuzzed.Value) != string(expected.Value) {
+			t.Fatalf("The proof %q verifies another value of field %s than %s", data, fuzzed.Field, expected.Value)
+		}
+	})
+}
+
+// FuzzCanonicalizeJSON checks that canonicalizing a document does not panic, that the canonical
+// form is its own canonical form and that it holds the same value as the document
+func FuzzCanonicalizeJSON(f *testing.F) {
+	for _, seed := range []string{`{"b":[1,2.50,"<&>"],"a":{"€":null,"\r":true}}`, `1e-7`, `"\u0000😀"`, `{"a":1,"a":2}`} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		canonical, err := CanonicalizeJSON(data)
+		if err != nil {
+			return
+		}
+
+		again, err := CanonicalizeJSON(canonical)
+		if err != nil || string(again) != string(canonical) {
+			t.Fatalf("The canonical form %q of %q canonicalizes to %q: %v", canonical, data, again, err)
+		}
+
+		var value, canonicalValue interface{}
+		json.Unmarshal(data, &value)
+		json.Unmarshal(canonical, &canonicalValue)
+		if !reflect.DeepEqual(value, canonicalValue) {
+			t.Fatalf("The canonical form %q of %q holds another value", canonical, data)
+		}
+	})
+}
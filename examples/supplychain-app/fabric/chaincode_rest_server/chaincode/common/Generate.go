@@ -0,0 +1,5 @@
+package common
+
+// The JSON Schemas of the types of this package and the OpenAPI document of the transactions of
+// the contract are generated in the schema directory
+//go:generate go run ../schema/cmd -common . -contract ../lglagrmt -out ../schema
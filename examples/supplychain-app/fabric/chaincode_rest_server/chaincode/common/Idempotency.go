@@ -0,0 +1,17 @@
+package common
+
+import "encoding/json"
+
+// IdempotencyRecord stores the result of a create transaction submitted with an idempotency key,
+// so that a retry of the same request by the same identity returns it instead of failing
+type IdempotencyRecord struct {
+	SchemaVersion  int             `json:"schemaVersion"`
+	TenantID       string          `json:"tenantID"`
+	IdempotencyKey string          `json:"idempotencyKey"`
+	Function       string          `json:"function"`
+	RequestHash    string          `json:"requestHash"`
+	CreatorHash    string          `json:"creatorHash"`
+	CreatedID      string          `json:"createdID"`
+	TxID           string          `json:"txID"`
+	Response       json.RawMessage `json:"response"`
+}
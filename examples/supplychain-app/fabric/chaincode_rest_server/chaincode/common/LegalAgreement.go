@@ -5,13 +5,50 @@ import (
 	"errors"
 )
 
+// Legal agreement lifecycle states
+const (
+	LegalAgreementStatusDraft      = "draft"
+	LegalAgreementStatusReview     = "review"
+	LegalAgreementStatusPublished  = "published"
+	LegalAgreementStatusSuperseded = "superseded"
+	LegalAgreementStatusRetired    = "retired"
+)
+
 // LegalAgreement stores legal agreements
 type LegalAgreement struct {
-	ID          string `json:"ID"`
-	Content     string `json:"content"`
-	ContentHash string `json:"hash"`
-	Timestamp   int64  `json:"timestamp"`
-	Version     int64  `json:"version"`
+	SchemaVersion  int            `json:"schemaVersion"`
+	TenantID       string         `json:"tenantID"`
+	ID             string         `json:"ID"`
+	Content        string         `json:"content"`
+	ContentHash    string         `json:"hash"`
+	HashAlgorithm  string         `json:"hashAlgorithm"`
+	OwnerMSP       string         `json:"ownerMSP"`
+	Status         string         `json:"status"`
+	Timestamp      int64          `json:"timestamp"`
+	Version        int64          `json:"version"`
+	EffectiveFrom  int64          `json:"effectiveFrom"`
+	EffectiveUntil int64          `json:"effectiveUntil"`
+	Scopes         []ConsentScope `json:"scopes"`
+	Parties        []Party        `json:"parties"`
+}
+
+// CurrentStatus returns the lifecycle state of the legal agreement. Legal
+// agreements written before lifecycle states existed are live, so an empty
+// status is reported as published.
+func (legalAgreement *LegalAgreement) CurrentStatus() string {
+	if legalAgreement.Status == "" {
+		return LegalAgreementStatusPublished
+	}
+	return legalAgreement.Status
+}
+
+// IsEffectiveAt reports whether the given unix timestamp falls within the period the
+// legal agreement is in force. An EffectiveUntil of zero leaves the period open ended.
+func (legalAgreement *LegalAgreement) IsEffectiveAt(timestamp int64) bool {
+	if legalAgreement.EffectiveFrom > timestamp {
+		return false
+	}
+	return legalAgreement.EffectiveUntil == 0 || timestamp < legalAgreement.EffectiveUntil
 }
 
 // UnmarshalJSON will override Unmarshal
@@ -39,3 +76,23 @@ func (legalAgreement *LegalAgreement) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// Scope returns the consent scope of the legal agreement with the given name
+func (legalAgreement *LegalAgreement) Scope(name string) (ConsentScope, bool) {
+	for _, scope := range legalAgreement.Scopes {
+		if scope.Name == name {
+			return scope, true
+		}
+	}
+	return ConsentScope{}, false
+}
+
+// Party returns the party of the legal agreement that signs with the given user ID
+func (legalAgreement *LegalAgreement) Party(userID string) (Party, bool) {
+	for _, party := range legalAgreement.Parties {
+		if party.UserID == userID {
+			return party, true
+		}
+	}
+	return Party{}, false
+}
@@ -2,13 +2,46 @@ package common
 
 // LegalAgreementRequest models the request to create a legal agreement
 type LegalAgreementRequest struct {
-	ID        string `json:"ID"`
-	Content   string `json:"content"`
-	Timestamp int64  `json:"timestamp"`
-	Version   int64  `json:"version"`
+	TenantID       string         `json:"tenantID"`
+	ID             string         `json:"ID"`
+	Content        string         `json:"content"`
+	HashAlgorithm  string         `json:"hashAlgorithm"`
+	Status         string         `json:"status"`
+	Timestamp      int64          `json:"timestamp"`
+	Version        int64          `json:"version"`
+	EffectiveFrom  int64          `json:"effectiveFrom"`
+	EffectiveUntil int64          `json:"effectiveUntil"`
+	Scopes         []ConsentScope `json:"scopes"`
+	Parties        []Party        `json:"parties"`
+	IdempotencyKey string         `json:"idempotencyKey"`
 }
 
 // ReadLegalAgreementRequest models the request to read a legal agreement
 type ReadLegalAgreementRequest struct {
-	ID string `json:"ID"`
+	TenantID string `json:"tenantID"`
+	ID       string `json:"ID"`
+}
+
+// ReadLatestVersionLegalAgreementRequest models the request to read the latest published version of a legal agreement
+type ReadLatestVersionLegalAgreementRequest struct {
+	TenantID string `json:"tenantID"`
+}
+
+// ReadEffectiveLegalAgreementRequest models the request to read the legal agreement in force at a point in time
+type ReadEffectiveLegalAgreementRequest struct {
+	TenantID string `json:"tenantID"`
+	AsOf     int64  `json:"asOf"`
+}
+
+// UpdateLegalAgreementStatusRequest models the request to move a legal agreement to another lifecycle state
+type UpdateLegalAgreementStatusRequest struct {
+	TenantID string `json:"tenantID"`
+	ID       string `json:"ID"`
+	Status   string `json:"status"`
+}
+
+// ComputeContentHashRequest models the request to compute the hash of a legal agreement content
+type ComputeContentHashRequest struct {
+	Content       string `json:"content"`
+	HashAlgorithm string `json:"hashAlgorithm"`
 }
@@ -7,12 +7,19 @@ import (
 
 // LegalAgreementSigning stores signed legal agreements
 type LegalAgreementSigning struct {
-	ID                        string `json:"ID"`
-	UserID                    string `json:"userID"`
-	LegalAgreementID          string `json:"legalAgreementID"`
-	LegalAgreementContentHash string `json:"legalAgreementContentHash"`
-	Accepted                  bool   `json:"accepted"`
-	Timestamp                 int64  `json:"timestamp"`
+	SchemaVersion             int             `json:"schemaVersion"`
+	TenantID                  string          `json:"tenantID"`
+	ID                        string          `json:"ID"`
+	UserID                    string          `json:"userID"`
+	LegalAgreementID          string          `json:"legalAgreementID"`
+	LegalAgreementContentHash string          `json:"legalAgreementContentHash"`
+	Accepted                  bool            `json:"accepted"`
+	Timestamp                 int64           `json:"timestamp"`
+	Signature                 string          `json:"signature"`
+	ScopeDecisions            map[string]bool `json:"scopeDecisions"`
+	DeclineReason             string          `json:"declineReason"`
+	OnBehalfOf                *OnBehalfOf     `json:"onBehalfOf"`
+	Receipt                   *ConsentReceipt `json:"receipt"`
 }
 
 // UnmarshalJSON will override unmarshal
@@ -23,7 +30,7 @@ func (legalAgreementSigning *LegalAgreementSigning) UnmarshalJSON(data []byte) e
 		return err
 	}
 
-	if input["userID"] == nil {
+	if input["userID"] == nil || input["legalAgreementID"] == nil {
 		return errors.New("Not a LegalAgreementSigning")
 	}
 
@@ -40,3 +47,46 @@ func (legalAgreementSigning *LegalAgreementSigning) UnmarshalJSON(data []byte) e
 
 	return nil
 }
+
+// ConsentedTo reports whether the signing gives consent to the given scope. Required scopes are
+// accepted with the legal agreement, and optional scopes without a decision are not consented to.
+func (legalAgreementSigning *LegalAgreementSigning) ConsentedTo(scope ConsentScope) bool {
+	if !legalAgreementSigning.Accepted {
+		return false
+	}
+	if decision, ok := legalAgreementSigning.ScopeDecisions[scope.Name]; ok {
+		return decision
+	}
+	return scope.Required
+}
+
+// LegalAgreementSigningPayload models the fields of a legal agreement signing that a user signs
+// when a tenant requires signed legal agreement signings
+type LegalAgreementSigningPayload struct {
+	TenantID                  string          `json:"tenantID"`
+	UserID                    string          `json:"userID"`
+	LegalAgreementID          string          `json:"legalAgreementID"`
+	LegalAgreementContentHash string          `json:"legalAgreementContentHash"`
+	Accepted                  bool            `json:"accepted"`
+	Timestamp                 int64           `json:"timestamp"`
+	ScopeDecisions            map[string]bool `json:"scopeDecisions"`
+}
+
+// SigningPayload returns the bytes a user signs when a tenant requires signed legal agreement signings,
+// which are the canonical JSON of a LegalAgreementSigningPayload, so clients in any language can
+// rebuild them with an RFC 8785 implementation. No scope decisions are written as an empty object.
+func SigningPayload(tenantID string, userID string, legalAgreementID string, legalAgreementContentHash string, accepted bool, timestamp int64, scopeDecisions map[string]bool) ([]byte, error) {
+	if scopeDecisions == nil {
+		scopeDecisions = map[string]bool{}
+	}
+
+	return MarshalCanonical(LegalAgreementSigningPayload{
+		TenantID:                  tenantID,
+		UserID:                    userID,
+		LegalAgreementID:          legalAgreementID,
+		LegalAgreementContentHash: legalAgreementContentHash,
+		Accepted:                  accepted,
+		Timestamp:                 timestamp,
+		ScopeDecisions:            scopeDecisions,
+	})
+}
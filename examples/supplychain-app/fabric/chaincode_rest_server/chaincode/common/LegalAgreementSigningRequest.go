@@ -2,20 +2,28 @@ package common
 
 // LegalAgreementSigningRequest models the request to create a legal agreement signing
 type LegalAgreementSigningRequest struct {
-	ID                        string `json:"ID"`
-	UserID                    string `json:"userID"`
-	LegalAgreementID          string `json:"legalAgreementID"`
-	LegalAgreementContentHash string `json:"legalAgreementContentHash"`
-	Accepted                  bool   `json:"accepted"`
-	Timestamp                 int64  `json:"timestamp"`
+	TenantID                  string          `json:"tenantID"`
+	ID                        string          `json:"ID"`
+	UserID                    string          `json:"userID"`
+	LegalAgreementID          string          `json:"legalAgreementID"`
+	LegalAgreementContentHash string          `json:"legalAgreementContentHash"`
+	Accepted                  bool            `json:"accepted"`
+	Timestamp                 int64           `json:"timestamp"`
+	Signature                 string          `json:"signature"`
+	ScopeDecisions            map[string]bool `json:"scopeDecisions"`
+	DeclineReason             string          `json:"declineReason"`
+	DelegationID              string          `json:"delegationID"`
+	IdempotencyKey            string          `json:"idempotencyKey"`
 }
 
 // ReadLegalAgreementSigningRequest models the request to read a legal agreement signing
 type ReadLegalAgreementSigningRequest struct {
-	ID string `json:"ID"`
+	TenantID string `json:"tenantID"`
+	ID       string `json:"ID"`
 }
 
 // ReadLatestLegalAgreementSigningByUserIDRequest models the request to read latest legal agreement signing by user ID
 type ReadLatestLegalAgreementSigningByUserIDRequest struct {
-	UserID string `json:"userID"`
+	TenantID string `json:"tenantID"`
+	UserID   string `json:"userID"`
 }
@@ -0,0 +1,12 @@
+package common
+
+// MigrationStatus reports the progress of the migration of the stored records to the current schema version
+type MigrationStatus struct {
+	SchemaVersion        int    `json:"schemaVersion"`
+	TargetSchemaVersion  int    `json:"targetSchemaVersion"`
+	Migration            string `json:"migration"`
+	Bookmark             string `json:"bookmark"`
+	ScannedRecordsCount  int32  `json:"scannedRecordsCount"`
+	MigratedRecordsCount int32  `json:"migratedRecordsCount"`
+	Complete             bool   `json:"complete"`
+}
@@ -0,0 +1,6 @@
+package common
+
+// MigrateRequest models the request to migrate the next chunk of stored records
+type MigrateRequest struct {
+	PageSize int32 `json:"pageSize"`
+}
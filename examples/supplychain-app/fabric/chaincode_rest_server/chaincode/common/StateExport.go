@@ -0,0 +1,201 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Format of state exports. The format version changes with any incompatible change to the lines
+// of an export.
+const (
+	StateExportFormat        = "lglagrmt-state"
+	StateExportFormatVersion = 1
+)
+
+// Types of the records of a state export
+const (
+	RecordTypeLegalAgreement        = "LegalAgreement"
+	RecordTypeLegalAgreementSigning = "LegalAgreementSigning"
+	RecordTypeUserIdentity          = "UserIdentity"
+	RecordTypeTenant                = "Tenant"
+	RecordTypeDelegation            = "Delegation"
+)
+
+// StateExportHeader is the first line of a chunk of a state export. The hash covers the keys and
+// hashes of the records of the chunk, in order, so a chunk with a missing, added or reordered
+// record does not match its header.
+type StateExportHeader struct {
+	Format        string `json:"format"`
+	FormatVersion int    `json:"formatVersion"`
+	// SchemaVersion is the schema version of the exporting ledger, which an importing ledger must be at
+	SchemaVersion int   `json:"schemaVersion"`
+	Timestamp     int64 `json:"timestamp"`
+	// Bookmark is the bookmark the chunk was exported from, empty for the first chunk
+	Bookmark string `json:"bookmark"`
+	// NextBookmark is the bookmark of the next chunk, empty for the last chunk
+	NextBookmark  string `json:"nextBookmark"`
+	RecordsCount  int32  `json:"recordsCount"`
+	Hash          string `json:"hash"`
+	HashAlgorithm string `json:"hashAlgorithm"`
+}
+
+// StateExportRecord is a line of a chunk of a state export, holding a record of the ledger with
+// its key. The hash is the digest of the value, as written.
+type StateExportRecord struct {
+	Type  string          `json:"type"`
+	Key   string          `json:"key"`
+	Hash  string          `json:"hash"`
+	Value json.RawMessage `json:"value"`
+}
+
+// StateImportConflict describes a record of an import whose key already exists in the ledger.
+// Identical is true when the stored value is the imported one, as when a chunk is imported again.
+type StateImportConflict struct {
+	Type      string `json:"type"`
+	Key       string `json:"key"`
+	Identical bool   `json:"identical"`
+}
+
+// ImportStateResponse reports the records written by an import and the ones left as they were
+type ImportStateResponse struct {
+	ImportedRecordsCount int32                 `json:"importedRecordsCount"`
+	Conflicts            []StateImportConflict `json:"conflicts"`
+}
+
+// stateExportHash returns the hex encoded SHA-256 digest of data
+func stateExportHash(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// stateExportChunkHash returns the hash of the header of the given records
+func stateExportChunkHash(records []StateExportRecord) string {
+	var buffer bytes.Buffer
+	for _, record := range records {
+		buffer.WriteString(record.Key)
+		buffer.WriteByte('\n')
+		buffer.WriteString(record.Hash)
+		buffer.WriteByte('\n')
+	}
+	return stateExportHash(buffer.Bytes())
+}
+
+// EncodeStateExport returns a chunk of a state export, with the header on the first line and a
+// record on each of the next ones. It sets the format, count and hash of the header, and writes
+// the value of every record in canonical JSON and sets its hash.
+func EncodeStateExport(header StateExportHeader, records []StateExportRecord) ([]byte, error) {
+	for i := range records {
+		value, err := CanonicalizeJSON(records[i].Value)
+		if err != nil {
+			return nil, fmt.Errorf("Error encoding record %s: %s", records[i].Key, err)
+		}
+		records[i].Value = value
+		records[i].Hash = stateExportHash(records[i].Value)
+	}
+	header.Format = StateExportFormat
+	header.FormatVersion = StateExportFormatVersion
+	header.RecordsCount = int32(len(records))
+	header.Hash = stateExportChunkHash(records)
+	header.HashAlgorithm = HashAlgorithmSHA256
+
+	// Values are written as they were hashed, without escaping HTML characters
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(header); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeStateExport reads the chunks of a state export, which may be concatenated, and returns
+// the header of every chunk and all their records. It checks the format of every line and the
+// counts and hashes of every chunk, but not the values of the records.
+func DecodeStateExport(data []byte) ([]StateExportHeader, []StateExportRecord, error) {
+	var headers []StateExportHeader
+	var records []StateExportRecord
+	chunkStart := 0
+
+	// checkChunk checks the count and hash of the last chunk read
+	checkChunk := func() error {
+		if len(headers) == 0 {
+			return nil
+		}
+		header, chunkRecords := headers[len(headers)-1], records[chunkStart:]
+		if header.RecordsCount != int32(len(chunkRecords)) {
+			return fmt.Errorf("Chunk %d holds %d records instead of %d", len(headers), len(chunkRecords), header.RecordsCount)
+		}
+		if header.Hash != stateExportChunkHash(chunkRecords) {
+			return fmt.Errorf("Chunk %d does not match its hash", len(headers))
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(text, &fields); err != nil {
+			return nil, nil, fmt.Errorf("Line %d is not a JSON object: %s", line, err)
+		}
+
+		// A header starts a new chunk
+		if _, ok := fields["format"]; ok {
+			if err := checkChunk(); err != nil {
+				return nil, nil, err
+			}
+			var header StateExportHeader
+			if err := json.Unmarshal(text, &header); err != nil {
+				return nil, nil, fmt.Errorf("Line %d is not a header: %s", line, err)
+			}
+			if header.Format != StateExportFormat || header.FormatVersion != StateExportFormatVersion {
+				return nil, nil, fmt.Errorf("Unsupported format %s version %d", header.Format, header.FormatVersion)
+			}
+			if header.HashAlgorithm != HashAlgorithmSHA256 {
+				return nil, nil, fmt.Errorf("Unsupported hash algorithm: %s", header.HashAlgorithm)
+			}
+			headers = append(headers, header)
+			chunkStart = len(records)
+			continue
+		}
+
+		if len(headers) == 0 {
+			return nil, nil, errors.New("A state export must start with a header")
+		}
+		var record StateExportRecord
+		if err := json.Unmarshal(text, &record); err != nil {
+			return nil, nil, fmt.Errorf("Line %d is not a record: %s", line, err)
+		}
+		if record.Type == "" || record.Key == "" || len(record.Value) == 0 {
+			return nil, nil, fmt.Errorf("Line %d is not a record: the type, key and value are required", line)
+		}
+		if record.Hash != stateExportHash(record.Value) {
+			return nil, nil, fmt.Errorf("Record %s does not match its hash", record.Key)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(headers) == 0 {
+		return nil, nil, errors.New("A state export must start with a header")
+	}
+	if err := checkChunk(); err != nil {
+		return nil, nil, err
+	}
+
+	return headers, records, nil
+}
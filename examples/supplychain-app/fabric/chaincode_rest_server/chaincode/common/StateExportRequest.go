@@ -0,0 +1,13 @@
+package common
+
+// ExportStateRequest models the request to export the next chunk of the records of the ledger
+type ExportStateRequest struct {
+	PageSize int32  `json:"pageSize"`
+	Bookmark string `json:"bookmark"`
+}
+
+// ImportStateRequest models the request to import chunks of a state export. The chunk holds the
+// lines of one or more chunks returned by exportState.
+type ImportStateRequest struct {
+	Chunk string `json:"chunk"`
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Identity attributes read by the contract
+const (
+	// AttributeTenantID names the attribute holding the tenant an identity belongs to
+	AttributeTenantID = "tenantID"
+	// AttributeRole names the attribute holding the role of an identity
+	AttributeRole = "role"
+	// AttributeUserID names the attribute holding the user identity an identity acts as
+	AttributeUserID = "userID"
+)
+
+// Tenant stores the configuration of a tenant
+type Tenant struct {
+	SchemaVersion    int      `json:"schemaVersion"`
+	TenantID         string   `json:"tenantID"`
+	Name             string   `json:"name"`
+	AllowedRoles     []string `json:"allowedRoles"`
+	RequireSignature bool     `json:"requireSignature"`
+	Timestamp        int64    `json:"timestamp"`
+}
+
+// UnmarshalJSON will override unmarshal
+func (tenant *Tenant) UnmarshalJSON(data []byte) error {
+	var input map[string]interface{}
+	err := json.Unmarshal(data, &input)
+	if err != nil {
+		return err
+	}
+
+	if input["allowedRoles"] == nil {
+		return errors.New("Not a Tenant")
+	}
+
+	// Prevent circular reference
+	type Alias Tenant
+	var output Alias
+	err = json.Unmarshal(data, &output)
+	if err != nil {
+		return err
+	}
+
+	c := Tenant(output)
+	*tenant = c
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package common
+
+// OnboardTenantRequest models the request to onboard a tenant
+type OnboardTenantRequest struct {
+	TenantID         string   `json:"tenantID"`
+	Name             string   `json:"name"`
+	AllowedRoles     []string `json:"allowedRoles"`
+	RequireSignature bool     `json:"requireSignature"`
+	Timestamp        int64    `json:"timestamp"`
+	IdempotencyKey   string   `json:"idempotencyKey"`
+}
+
+// ReadTenantRequest models the request to read a tenant
+type ReadTenantRequest struct {
+	TenantID string `json:"tenantID"`
+}
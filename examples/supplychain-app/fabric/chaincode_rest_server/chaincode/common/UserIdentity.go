@@ -1,9 +1,47 @@
 package common
 
+import (
+	"encoding/json"
+	"errors"
+)
+
+// User identity statuses with a meaning to the contract
+const (
+	UserIdentityStatusRevoked = "revoked"
+)
+
 // UserIdentity stores user identities
 type UserIdentity struct {
+	SchemaVersion             int    `json:"schemaVersion"`
+	TenantID                  string `json:"tenantID"`
 	UserID                    string `json:"userID"`
 	LegalAgreementSigningTxID string `json:"legalAgreementSigningTxID"`
 	VerifiableCredential      string `json:"verifiableCredential"`
 	Status                    string `json:"status"`
 }
+
+// UnmarshalJSON will override unmarshal
+func (userIdentity *UserIdentity) UnmarshalJSON(data []byte) error {
+	var input map[string]interface{}
+	err := json.Unmarshal(data, &input)
+	if err != nil {
+		return err
+	}
+
+	if input["userID"] == nil || input["legalAgreementSigningTxID"] == nil {
+		return errors.New("Not a UserIdentity")
+	}
+
+	// Prevent circular reference
+	type Alias UserIdentity
+	var output Alias
+	err = json.Unmarshal(data, &output)
+	if err != nil {
+		return err
+	}
+
+	c := UserIdentity(output)
+	*userIdentity = c
+
+	return nil
+}
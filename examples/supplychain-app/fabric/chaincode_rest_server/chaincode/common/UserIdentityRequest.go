@@ -2,13 +2,16 @@ package common
 
 // UserIdentityRequest models the request to create an user identity
 type UserIdentityRequest struct {
+	TenantID                  string `json:"tenantID"`
 	UserID                    string `json:"userID"`
 	LegalAgreementSigningTxID string `json:"legalAgreementSigningTxID"`
 	VerifiableCredential      string `json:"verifiableCredential"`
 	Status                    string `json:"status"`
+	IdempotencyKey            string `json:"idempotencyKey"`
 }
 
 // ReadUserIdentityRequest models the request to read an user identity
 type ReadUserIdentityRequest struct {
-	UserID string `json:"userID"`
+	TenantID string `json:"tenantID"`
+	UserID   string `json:"userID"`
 }
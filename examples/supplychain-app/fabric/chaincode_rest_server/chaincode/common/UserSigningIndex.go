@@ -0,0 +1,11 @@
+package common
+
+// UserSigningIndex points at the latest legal agreement signing of a user, so that the users of a
+// tenant can be read a page at a time without reading every signing
+type UserSigningIndex struct {
+	SchemaVersion           int    `json:"schemaVersion"`
+	TenantID                string `json:"tenantID"`
+	UserID                  string `json:"userID"`
+	LegalAgreementSigningID string `json:"legalAgreementSigningID"`
+	Timestamp               int64  `json:"timestamp"`
+}
@@ -0,0 +1,32 @@
+package common
+
+// Names of the events emitted by write transactions
+const (
+	EventLegalAgreementCreated        = "LegalAgreementCreated"
+	EventLegalAgreementStatusUpdated  = "LegalAgreementStatusUpdated"
+	EventLegalAgreementSigningCreated = "LegalAgreementSigningCreated"
+	EventLegalAgreementExecuted       = "LegalAgreementExecuted"
+	EventUserIdentityCreated          = "UserIdentityCreated"
+	EventTenantOnboarded              = "TenantOnboarded"
+	EventDelegationGranted            = "DelegationGranted"
+	EventDelegationRevoked            = "DelegationRevoked"
+	EventConfigUpdated                = "ConfigUpdated"
+	EventSchemaMigrated               = "SchemaMigrated"
+	EventStateImported                = "StateImported"
+)
+
+// Event describes an event emitted by a write transaction
+type Event struct {
+	Name    string                 `json:"name"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// WriteResponse is the response envelope of every transaction that writes to the ledger
+type WriteResponse struct {
+	CreatedID string      `json:"createdID,omitempty"`
+	UpdatedID string      `json:"updatedID,omitempty"`
+	TxID      string      `json:"txID"`
+	Timestamp int64       `json:"timestamp"`
+	Document  interface{} `json:"document"`
+	Events    []Event     `json:"events"`
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/receipt"
+)
+
+const usage = `Usage: lglagrmt-receipt [flags] command [arguments]
+
+Verifies the consent receipts of legal agreement signings, without trusting the server that
+returned them. A receipt file holds a receipt, a signed receipt, or a legal agreement signing
+with its receipt.
+
+Commands:
+  verify receipt                   check a receipt against its commitment, the endorsements of a
+                                   signed receipt, and the block of -block or the state export
+                                   of -export
+  sign receipt                     print the signed receipt, with the endorsements of the block
+                                   of -block, so the receipt can be verified without the block
+  prove receipt field              print the proof of a field of a receipt, so the field can be
+                                   disclosed without the rest of the receipt
+  verify-proof commitment proof    check the proof of a field against the commitment of a receipt
+
+Flags:
+`
+
+// fileFlag collects the paths of repeated flags
+type fileFlag []string
+
+func (files *fileFlag) String() string {
+	return strings.Join(*files, ",")
+}
+
+func (files *fileFlag) Set(value string) error {
+	*files = append(*files, value)
+	return nil
+}
+
+// main function runs a command of the verifier
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs a command of the verifier and returns its exit code, which is 1 if a receipt or a proof
+// does not verify
+func run(arguments []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lglagrmt-receipt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	blockPath := flags.String("block", "", "file holding the block of the transaction of the receipt, as fetched with peer channel fetch")
+	exportPath := flags.String("export", "", "file holding the chunks of a state export of the ledger")
+	chaincode := flags.String("chaincode", receipt.DefaultChaincode, "name of the chaincode that wrote the signing")
+	var caFiles fileFlag
+	flags.Var(&caFiles, "cafile", "PEM file of CA certificates the certificates of the endorsers must chain to, repeated for every file")
+	if err := flags.Parse(arguments); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	fail := func(format string, a ...interface{}) int {
+		fmt.Fprintf(stderr, format+"\n", a...)
+		return 1
+	}
+
+	options := receipt.BlockOptions{Chaincode: *chaincode}
+	if len(caFiles) > 0 {
+		options.Roots = x509.NewCertPool()
+		for _, caFile := range caFiles {
+			certificates, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return fail("Error reading the CA certificates: %s", err)
+			}
+			if !options.Roots.AppendCertsFromPEM(certificates) {
+				return fail("No certificate in %s", caFile)
+			}
+		}
+	}
+
+	switch {
+	case command == "verify" && len(args) == 1:
+		consentReceipt, transaction, err := readReceipt(args[0])
+		if err != nil {
+			return fail("Error reading the receipt: %s", err)
+		}
+		if err := consentReceipt.Verify(); err != nil {
+			return fail("Invalid receipt: %s", err)
+		}
+
+		if transaction != nil {
+			verification, err := receipt.VerifySignedReceipt(receipt.SignedReceipt{Receipt: *consentReceipt, Transaction: *transaction}, options)
+			if err != nil {
+				return fail("Invalid receipt: %s", err)
+			}
+			fmt.Fprintf(stdout, "Transaction %s, in block %d, wrote signing %s\n", consentReceipt.TxID, verification.BlockNumber, consentReceipt.SigningID)
+			printEndorsers(stdout, verification.Endorsers)
+		}
+
+		if *blockPath != "" {
+			block, err := ioutil.ReadFile(*blockPath)
+			if err != nil {
+				return fail("Error reading the block: %s", err)
+			}
+			verification, err := receipt.VerifyBlock(*consentReceipt, block, options)
+			if err != nil {
+				return fail("Invalid receipt: %s", err)
+			}
+			fmt.Fprintf(stdout, "Transaction %s of block %d recorded signing %s\n", consentReceipt.TxID, verification.BlockNumber, consentReceipt.SigningID)
+			printEndorsers(stdout, verification.Endorsers)
+		}
+
+		if *exportPath != "" {
+			export, err := ioutil.ReadFile(*exportPath)
+			if err != nil {
+				return fail("Error reading the state export: %s", err)
+			}
+			if err := receipt.VerifyStateExport(*consentReceipt, export); err != nil {
+				return fail("Invalid receipt: %s", err)
+			}
+			fmt.Fprintf(stdout, "The state export holds signing %s\n", consentReceipt.SigningID)
+		}
+
+		if transaction == nil && *blockPath == "" && *exportPath == "" {
+			fmt.Fprintf(stdout, "Receipt %s matches its commitment\n", consentReceipt.Commitment)
+			break
+		}
+		fmt.Fprintf(stdout, "Receipt %s is valid\n", consentReceipt.Commitment)
+	case command == "sign" && len(args) == 1 && *blockPath != "":
+		consentReceipt, _, err := readReceipt(args[0])
+		if err != nil {
+			return fail("Error reading the receipt: %s", err)
+		}
+		block, err := ioutil.ReadFile(*blockPath)
+		if err != nil {
+			return fail("Error reading the block: %s", err)
+		}
+		signedReceipt, err := receipt.NewSignedReceipt(*consentReceipt, block, options)
+		if err != nil {
+			return fail("Invalid receipt: %s", err)
+		}
+		encoded, _ := json.MarshalIndent(signedReceipt, "", "  ")
+		fmt.Fprintf(stdout, "%s\n", encoded)
+	case command == "prove" && len(args) == 2:
+		consentReceipt, _, err := readReceipt(args[0])
+		if err != nil {
+			return fail("Error reading the receipt: %s", err)
+		}
+		proof, err := consentReceipt.Prove(args[1])
+		if err != nil {
+			return fail("%s", err)
+		}
+		encoded, _ := json.MarshalIndent(proof, "", "  ")
+		fmt.Fprintf(stdout, "%s\n", encoded)
+	case command == "verify-proof" && len(args) == 2:
+		data, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			return fail("Error reading the proof: %s", err)
+		}
+		var proof ReceiptFieldProof
+		if err := json.Unmarshal(data, &proof); err != nil {
+			return fail("Error reading the proof: %s", err)
+		}
+		if err := proof.Verify(args[0]); err != nil {
+			return fail("Invalid proof: %s", err)
+		}
+		fmt.Fprintf(stdout, "Field %s of receipt %s is %s\n", proof.Field, args[0], proof.Value)
+	default:
+		flags.Usage()
+		return 2
+	}
+	return 0
+}
+
+// printEndorsers prints the endorsers of the transaction of a receipt
+func printEndorsers(stdout io.Writer, endorsers []receipt.Endorser) {
+	for _, endorser := range endorsers {
+		fmt.Fprintf(stdout, "Endorsed by %s %s\n", endorser.MSPID, endorser.Subject)
+	}
+}
+
+// readReceipt reads a receipt from a file holding the receipt, a signed receipt, or a legal
+// agreement signing with its receipt. The reference to the transaction is only returned for a
+// signed receipt.
+func readReceipt(path string) (*ConsentReceipt, *receipt.TransactionReference, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var document struct {
+		Receipt     *ConsentReceipt               `json:"receipt"`
+		Transaction *receipt.TransactionReference `json:"transaction"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	if document.Receipt != nil {
+		return document.Receipt, document.Transaction, nil
+	}
+
+	var consentReceipt ConsentReceipt
+	if err := json.Unmarshal(data, &consentReceipt); err != nil {
+		return nil, nil, err
+	}
+	return &consentReceipt, nil, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/lglagrmt"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestVerifier(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var dir string
+	// verifier runs a command of the verifier and returns its exit code and output
+	verifier := func(arguments ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(arguments, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+	// write writes a file of the test directory and returns its path
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, data, 0644)).To(Succeed())
+		return path
+	}
+
+	g.Describe("Verifier", func() {
+		var signingPath, exportPath string
+		var signing []byte
+
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "lglagrmt-receipt")
+			Expect(err).NotTo(HaveOccurred())
+
+			admin, _ := stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			ledger := stubtest.New("legalagreement", new(lglagrmt.SmartContract))
+			ledger.SetCreator(admin)
+			Expect(ledger.Init("tx0", [][]byte{[]byte("init")}).Status).To(BeEquivalentTo(200))
+
+			hash, _ := ComputeContentHash("first version", "")
+			response := ledger.Invoke("tx1", [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			// The user signs for themselves
+			user, _ := stubtest.NewIdentity("Org1MSP", "user", map[string]string{"userID": "u-001"})
+			ledger.SetCreator(user)
+			response = ledger.Invoke("tx2", [][]byte{[]byte("createLegalAgreementSigning"), []byte(`{"ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"` + hash + `","accepted":true,"timestamp":1654027900}`)})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			signing, _ = ledger.GetState("0001")
+			signingPath = write("signing.json", signing)
+			ledger.SetCreator(admin)
+			response = ledger.Query("export", [][]byte{[]byte("exportState")})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			exportPath = write("export.ndjson", response.Payload)
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("should verify a receipt against a state export", func() {
+			code, output := verifier("-export", exportPath, "verify", signingPath)
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring("The state export holds signing 0001"))
+		})
+
+		g.It("should reject a tampered receipt", func() {
+			tampered := write("tampered.json", bytes.Replace(signing, []byte(`"userID":"u-001"`), []byte(`"userID":"u-002"`), -1))
+			code, output := verifier("-export", exportPath, "verify", tampered)
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("Invalid receipt: The fields of the receipt do not match its commitment"))
+		})
+
+		g.It("should verify the endorsements of a signed receipt", func() {
+			code, _ := verifier("sign", signingPath)
+			Expect(code).To(Equal(2))
+
+			// A signed receipt whose transaction does not write the signing of the receipt
+			var legalAgreementSigning LegalAgreementSigning
+			json.Unmarshal(signing, &legalAgreementSigning)
+			receiptAsBytes, _ := json.Marshal(legalAgreementSigning.Receipt)
+			signedReceipt := write("signed.json", []byte(`{"receipt":`+string(receiptAsBytes)+`,"transaction":{"blockNumber":7,"txIndex":0,"endorsements":[]}}`))
+			code, output := verifier("verify", signedReceipt)
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("Invalid receipt: Transaction tx2 did not write signing 0001"))
+		})
+
+		g.It("should prove a field of a receipt", func() {
+			code, output := verifier("prove", signingPath, "userID")
+			Expect(code).To(Equal(0), output)
+			proofPath := write("proof.json", []byte(output))
+
+			code, output = verifier("verify", signingPath)
+			Expect(code).To(Equal(0), output)
+			commitment := strings.TrimSuffix(strings.TrimPrefix(output, "Receipt "), " matches its commitment\n")
+
+			code, output = verifier("verify-proof", commitment, proofPath)
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring(`Field userID of receipt ` + commitment + ` is "u-001"`))
+		})
+	})
+}
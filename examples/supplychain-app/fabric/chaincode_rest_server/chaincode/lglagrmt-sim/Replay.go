@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// replay submits the transactions of a log in order, with their recorded IDs, times and
+// identities, and prints the status of each. The log holds a JSON transaction per line, as
+// recorded with -log. Replaying stops after the transaction with the ID until, if it is not empty,
+// so the state can be inspected as it was then. It returns the number of transactions whose status
+// differs from the recorded one.
+func (sim *simulator) replay(reader io.Reader, until string, writer io.Writer) (int, error) {
+	mismatches := 0
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var tx transaction
+		if err := json.Unmarshal([]byte(text), &tx); err != nil {
+			return mismatches, fmt.Errorf("line %d: %s", line, err)
+		}
+		if tx.TxID == "" || tx.Function == "" {
+			return mismatches, fmt.Errorf("line %d: a transaction needs a txID and a function", line)
+		}
+
+		response, err := sim.run(&tx, true)
+		if err != nil {
+			return mismatches, fmt.Errorf("line %d: %s", line, err)
+		}
+		fmt.Fprintf(writer, "%s %s %d", tx.TxID, tx.Function, response.Status)
+		if tx.Status != 0 && tx.Status != response.Status {
+			mismatches++
+			fmt.Fprintf(writer, " MISMATCH recorded %d", tx.Status)
+		}
+		fmt.Fprintln(writer)
+		if response.Status >= shim.ERRORTHRESHOLD && response.Message != "" {
+			fmt.Fprintf(writer, "  %s\n", response.Message)
+		}
+
+		if tx.TxID == until {
+			return mismatches, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return mismatches, err
+	}
+	if until != "" {
+		return mismatches, fmt.Errorf("Transaction %s is not in the log", until)
+	}
+	return mismatches, nil
+}
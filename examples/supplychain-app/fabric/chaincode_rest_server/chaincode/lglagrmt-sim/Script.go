@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// runScript runs the commands of a script, one per line, and prints the response of every
+// transaction. Blank lines and lines starting with # are ignored, and the commands are:
+//
+//	identity [mspID [name=value...]]  submits the next transactions as an identity with the given
+//	                                  attributes, or as no identity without an MSP ID
+//	time [seconds]                    runs the next transactions at a time since the epoch, or at
+//	                                  the current time without one
+//	invoke function [argument]        submits a transaction, committed if it succeeds
+//	query function [argument]         evaluates a transaction, which is never committed
+//
+// The transactions start with the identity and time of the defaults. The argument of a transaction
+// is the rest of the line, so it can be a JSON request with spaces. A failed transaction does not
+// stop the script, only an invalid command does.
+func (sim *simulator) runScript(reader io.Reader, defaults transaction, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		switch fields[0] {
+		case "identity":
+			defaults.MSPID, defaults.Attrs = "", nil
+			if len(fields) > 1 {
+				attrs, err := parseAttrs(fields[2:])
+				if err != nil {
+					return fmt.Errorf("line %d: %s", line, err)
+				}
+				defaults.MSPID, defaults.Attrs = fields[1], attrs
+			}
+		case "time":
+			defaults.Timestamp = 0
+			if len(fields) > 1 {
+				timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+				if err != nil {
+					return fmt.Errorf("line %d: invalid time %s", line, fields[1])
+				}
+				defaults.Timestamp = timestamp
+			}
+		case "invoke", "query":
+			if len(fields) < 2 {
+				return fmt.Errorf("line %d: missing function", line)
+			}
+			tx := defaults
+			tx.Function = fields[1]
+			rest := strings.TrimSpace(text[len(fields[0]):])
+			if arg := strings.TrimSpace(rest[len(fields[1]):]); arg != "" {
+				tx.Args = []string{arg}
+			}
+			fmt.Fprintf(writer, "> %s\n", text)
+			response, err := sim.run(&tx, fields[0] == "invoke")
+			if err != nil {
+				return fmt.Errorf("line %d: %s", line, err)
+			}
+			printResponse(writer, &tx, response)
+		default:
+			return fmt.Errorf("line %d: unknown command %s", line, fields[0])
+		}
+	}
+	return scanner.Err()
+}
+
+// parseAttrs parses identity attributes given as name=value
+func parseAttrs(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	attrs := map[string]string{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid attribute %s, expecting name=value", pair)
+		}
+		attrs[parts[0]] = parts[1]
+	}
+	return attrs, nil
+}
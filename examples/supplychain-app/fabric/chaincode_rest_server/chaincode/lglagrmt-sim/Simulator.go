@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chaincode/lglagrmt"
+	"github.com/chaincode/stubtest"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// transaction is a transaction run by the simulator, as recorded in a transaction log
+type transaction struct {
+	TxID string `json:"txID"`
+	// Timestamp is the time of the transaction in seconds since the epoch
+	Timestamp int64 `json:"timestamp"`
+	// MSPID is the MSP of the identity submitting the transaction, which has none if it is empty
+	MSPID    string            `json:"mspID,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Function string            `json:"function"`
+	Args     []string          `json:"args,omitempty"`
+	// Status is the status of the response, which replaying the transaction is expected to return
+	Status int32 `json:"status,omitempty"`
+}
+
+// simulator runs the transactions of the legal agreement contract in process, on a ledger that is
+// loaded from and saved to a store file
+type simulator struct {
+	ledger  *stubtest.Stub
+	txCount int
+	// mspID is the MSP of the identity that instantiates the contract, whose admins are admins
+	mspID string
+	// log records the submitted transactions, in the format of replay, when it is not nil
+	log io.Writer
+}
+
+// newSimulator returns a simulator of the contract on a ledger with the state of a store. The
+// contract is instantiated on every run by an identity of the given MSP, which only writes the
+// configuration of a ledger that has none.
+func newSimulator(s *store, mspID string) (*simulator, error) {
+	sim := &simulator{ledger: stubtest.New("legalagreement", new(lglagrmt.SmartContract)), txCount: s.TxCount, mspID: mspID}
+	if err := sim.load(s); err != nil {
+		return nil, err
+	}
+	return sim, nil
+}
+
+// store returns the store of the ledger of the simulator
+func (sim *simulator) store() *store {
+	return newStore(sim.ledger.State(), sim.txCount)
+}
+
+// load replaces the world state of the ledger with the state of a store, and instantiates the
+// contract again in case the state has no configuration
+func (sim *simulator) load(s *store) error {
+	state, err := s.worldState()
+	if err != nil {
+		return err
+	}
+	sim.ledger.Load(state)
+
+	identity, err := stubtest.NewIdentity(sim.mspID, "lglagrmt-sim", nil)
+	if err != nil {
+		return err
+	}
+	sim.ledger.SetCreator(identity)
+	response := sim.ledger.Init("init", [][]byte{[]byte("init")})
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return fmt.Errorf("Error instantiating the chaincode: %s", response.Message)
+	}
+	return nil
+}
+
+// run runs a transaction, which is committed if it is submitted and succeeds. A transaction
+// without an ID is given the next one, and one without a timestamp runs at the current time.
+func (sim *simulator) run(tx *transaction, submit bool) (peer.Response, error) {
+	if tx.TxID == "" {
+		sim.txCount++
+		tx.TxID = fmt.Sprintf("tx%d", sim.txCount)
+	}
+	if tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	timestamp := time.Unix(tx.Timestamp, 0)
+	sim.ledger.SetClock(func() time.Time { return timestamp })
+
+	if tx.MSPID == "" {
+		sim.ledger.SetCreator(nil)
+	} else {
+		identity, err := stubtest.NewIdentity(tx.MSPID, "lglagrmt-sim", tx.Attrs)
+		if err != nil {
+			return peer.Response{}, err
+		}
+		sim.ledger.SetCreator(identity)
+	}
+
+	args := [][]byte{[]byte(tx.Function)}
+	for _, arg := range tx.Args {
+		args = append(args, []byte(arg))
+	}
+	if !submit {
+		return sim.ledger.Query(tx.TxID, args), nil
+	}
+
+	response := sim.ledger.Invoke(tx.TxID, args)
+	if sim.log != nil {
+		logged := *tx
+		logged.Status = response.Status
+		if err := json.NewEncoder(sim.log).Encode(logged); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// printResponse prints the status of the response of a transaction, followed by its payload
+// indented if it is JSON, or by its error message
+func printResponse(writer io.Writer, tx *transaction, response peer.Response) {
+	fmt.Fprintf(writer, "%s %s %d\n", tx.TxID, tx.Function, response.Status)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		fmt.Fprintln(writer, response.Message)
+		return
+	}
+	if len(response.Payload) == 0 {
+		return
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, response.Payload, "", "  "); err != nil {
+		fmt.Fprintln(writer, string(response.Payload))
+		return
+	}
+	fmt.Fprintln(writer, indented.String())
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestSimulator(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var dir string
+	// sim runs a command of the simulator on the store of the test and returns its exit code and output
+	sim := func(stdin string, arguments ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		arguments = append([]string{"-store", filepath.Join(dir, "ledger.json"), "-time", "1654027884"}, arguments...)
+		code := run(arguments, strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+	createRequest := `{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`
+
+	g.Describe("Simulator", func() {
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "lglagrmt-sim")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		g.It("should keep committed transactions in the store between runs", func() {
+			code, output := sim("", "invoke", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring(`tx1 createLegalAgreement 200`))
+
+			code, output = sim("", "query", "readLegalAgreement", `{"ID":"001"}`)
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring(`"content": "first version"`))
+
+			code, output = sim("", "invoke", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("tx2 createLegalAgreement 409"))
+		})
+
+		g.It("should not commit queries", func() {
+			code, output := sim("", "query", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(0), output)
+
+			code, _ = sim("", "query", "readLegalAgreement", `{"ID":"001"}`)
+			Expect(code).To(Equal(1))
+		})
+
+		g.It("should run a script with identities and times", func() {
+			script := strings.Join([]string{
+				"# Read a legal agreement of another tenant",
+				"identity Org1MSP role=admin",
+				`invoke onboardTenant {"tenantID": "acme", "name": "Acme", "allowedRoles": ["user"]}`,
+				"identity Org1MSP role=user tenantID=acme",
+				"time 1654027900",
+				`invoke createLegalAgreement {"tenantID": "acme", "ID": "001", "content": "acme terms", "timestamp": 1654027884, "version": 1}`,
+				"identity Org1MSP role=user tenantID=globex",
+				`query readLegalAgreement {"tenantID": "acme", "ID": "001"}`,
+			}, "\n")
+
+			code, output := sim(script, "run", "-")
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring("tx1 onboardTenant 200"))
+			Expect(output).To(ContainSubstring("tx2 createLegalAgreement 200"))
+			Expect(output).To(ContainSubstring(`"timestamp": 1654027900`))
+			Expect(output).To(ContainSubstring("tx3 readLegalAgreement 403"))
+
+			code, output = sim("unknown command", "run", "-")
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("line 1: unknown command unknown"))
+		})
+
+		g.It("should replay a recorded log and report mismatches", func() {
+			logPath := filepath.Join(dir, "transactions.jsonl")
+			code, output := sim("", "-log", logPath, "invoke", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(0), output)
+			code, output = sim("", "-log", logPath, "invoke", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(1), output)
+			Expect(os.Remove(filepath.Join(dir, "ledger.json"))).To(Succeed())
+
+			code, output = sim("", "replay", logPath)
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring("tx1 createLegalAgreement 200\n"))
+			Expect(output).To(ContainSubstring("tx2 createLegalAgreement 409\n"))
+
+			code, output = sim("", "replay", logPath)
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("tx1 createLegalAgreement 409 MISMATCH recorded 200"))
+			Expect(output).To(ContainSubstring("1 transactions did not respond with their recorded status"))
+		})
+
+		g.It("should stop replaying after a transaction", func() {
+			log := `{"txID":"a1","timestamp":1654027884,"function":"createLegalAgreement","args":["{\"ID\":\"001\",\"content\":\"first version\",\"timestamp\":1654027884,\"version\":1}"],"status":200}
+{"txID":"a2","timestamp":1654027890,"function":"createLegalAgreement","args":["{\"ID\":\"002\",\"content\":\"second version\",\"timestamp\":1654027890,\"version\":2}"],"status":200}`
+
+			code, output := sim(log, "-until", "a1", "replay", "-")
+			Expect(code).To(Equal(0), output)
+			Expect(output).NotTo(ContainSubstring("a2"))
+
+			code, output = sim("", "query", "readLegalAgreement", `{"ID":"001"}`)
+			Expect(code).To(Equal(0), output)
+			code, output = sim("", "query", "readLegalAgreement", `{"ID":"002"}`)
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("readLegalAgreement 404"))
+
+			code, output = sim(log, "-until", "a3", "replay", "-")
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("Transaction a3 is not in the log"))
+		})
+
+		g.It("should import a dump of the world state", func() {
+			code, output := sim("", "invoke", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(0), output)
+			code, dump := sim("", "dump")
+			Expect(code).To(Equal(0), dump)
+			Expect(dump).To(ContainSubstring(`"key": "001"`))
+
+			// A value that is not JSON is kept in base64
+			dump = strings.Replace(dump, `"state": [`, `"state": [{"key": "raw", "valueBase64": "AAEC"},`, 1)
+			Expect(os.Remove(filepath.Join(dir, "ledger.json"))).To(Succeed())
+			code, output = sim(dump, "import", "-")
+			Expect(code).To(Equal(0), output)
+
+			code, output = sim("", "query", "readLegalAgreement", `{"ID":"001"}`)
+			Expect(code).To(Equal(0), output)
+			code, output = sim("", "dump")
+			Expect(code).To(Equal(0), output)
+			Expect(output).To(ContainSubstring(`"valueBase64": "AAEC"`))
+		})
+
+		g.It("should import a state export of the contract", func() {
+			code, output := sim("", "invoke", "createLegalAgreement", createRequest)
+			Expect(code).To(Equal(0), output)
+			code, output = sim("", "-attr", "role=admin", "query", "exportState", `{"pageSize":10}`)
+			Expect(code).To(Equal(0), output)
+			export := output[strings.Index(output, "\n")+1:]
+
+			Expect(os.Remove(filepath.Join(dir, "ledger.json"))).To(Succeed())
+			code, output = sim(export, "import", "-")
+			Expect(code).To(Equal(0), output)
+			code, output = sim("", "query", "readLegalAgreement", `{"ID":"001"}`)
+			Expect(code).To(Equal(0), output)
+
+			code, output = sim(strings.Replace(export, "first version", "altered version", 1), "import", "-")
+			Expect(code).To(Equal(1))
+			Expect(output).To(ContainSubstring("Record 001 does not match its hash"))
+		})
+	})
+}
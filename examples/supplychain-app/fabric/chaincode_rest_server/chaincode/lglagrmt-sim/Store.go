@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/chaincode/common"
+)
+
+// store is the file the simulator keeps its ledger in between runs. A dump of the world state has
+// the same format, so it can be imported into another store.
+type store struct {
+	TxCount int          `json:"txCount,omitempty"`
+	State   []storeEntry `json:"state"`
+}
+
+// storeEntry is a key of the world state. Values that are compact JSON, as the contract writes
+// them, are kept as is so the file can be read and edited, and other values are kept in base64.
+type storeEntry struct {
+	Key         string          `json:"key"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	ValueBase64 []byte          `json:"valueBase64,omitempty"`
+}
+
+// newStore returns the store of a world state, ordered by key
+func newStore(state map[string][]byte, txCount int) *store {
+	result := &store{TxCount: txCount, State: []storeEntry{}}
+	for key, value := range state {
+		entry := storeEntry{Key: key}
+		var compact bytes.Buffer
+		if json.Valid(value) && json.Compact(&compact, value) == nil && bytes.Equal(compact.Bytes(), value) {
+			entry.Value = value
+		} else {
+			entry.ValueBase64 = value
+		}
+		result.State = append(result.State, entry)
+	}
+	sort.Slice(result.State, func(i, j int) bool { return result.State[i].Key < result.State[j].Key })
+	return result
+}
+
+// worldState returns the world state of the store
+func (s *store) worldState() (map[string][]byte, error) {
+	state := make(map[string][]byte, len(s.State))
+	for _, entry := range s.State {
+		if entry.Value == nil {
+			state[entry.Key] = entry.ValueBase64
+			continue
+		}
+		// Values are indented in the file and restored to the compact JSON of the ledger
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, entry.Value); err != nil {
+			return nil, err
+		}
+		state[entry.Key] = compact.Bytes()
+	}
+	return state, nil
+}
+
+// decodeStore reads a store, or a dump of the world state
+func decodeStore(reader io.Reader) (*store, error) {
+	var result store
+	if err := json.NewDecoder(reader).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// decodeDump reads a dump of the world state, or the chunks of a state export of the contract,
+// which start with a header naming their format
+func decodeDump(data []byte) (*store, error) {
+	var first map[string]json.RawMessage
+	json.NewDecoder(bytes.NewReader(data)).Decode(&first)
+	if _, ok := first["format"]; !ok {
+		return decodeStore(bytes.NewReader(data))
+	}
+
+	_, records, err := common.DecodeStateExport(data)
+	if err != nil {
+		return nil, err
+	}
+	state := map[string][]byte{}
+	for _, record := range records {
+		state[record.Key] = record.Value
+	}
+	return newStore(state, 0), nil
+}
+
+// encodeStore writes a store as indented JSON, without escaping HTML characters so values are
+// kept byte for byte
+func encodeStore(writer io.Writer, s *store) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// readStore reads the store of a file, which is empty if the file does not exist yet
+func readStore(path string) (*store, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return decodeStore(file)
+}
+
+// writeStore replaces the store of a file. It is written to a temporary file first, so a failed
+// write does not lose the ledger.
+func writeStore(path string, s *store) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := encodeStore(file, s); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
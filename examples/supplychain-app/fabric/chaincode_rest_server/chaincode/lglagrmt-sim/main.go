@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const usage = `Usage: lglagrmt-sim [flags] command [arguments]
+
+Runs the legal agreement contract in process, on a ledger kept in a store file.
+
+Commands:
+  invoke function [argument...]  submit a transaction, committed if it succeeds
+  query function [argument...]   evaluate a transaction, which is never committed
+  run script                     run the commands of a script, - for the standard input
+  replay log                     submit the transactions of a transaction log
+  dump                           print the world state
+  import file                    replace the world state with a dump or a state export of the
+                                 contract, - for the standard input
+
+Flags:
+`
+
+// attrFlag collects the name=value attributes of repeated -attr flags
+type attrFlag []string
+
+func (attrs *attrFlag) String() string {
+	return strings.Join(*attrs, ",")
+}
+
+func (attrs *attrFlag) Set(value string) error {
+	*attrs = append(*attrs, value)
+	return nil
+}
+
+// main function runs a command of the simulator
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs a command of the simulator and returns its exit code, which is 1 if a transaction
+// given on the command line fails or a replayed one does not respond with its recorded status
+func run(arguments []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lglagrmt-sim", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	storePath := flags.String("store", "ledger.json", "file keeping the ledger between runs, created if it does not exist")
+	mspID := flags.String("msp", "Org1MSP", "MSP ID of the identity submitting the transactions")
+	var attrs attrFlag
+	flags.Var(&attrs, "attr", "name=value attribute of the identity submitting the transactions, repeated for every attribute. Transactions have no identity without attributes")
+	timestamp := flags.Int64("time", 0, "time of the transactions in seconds since the epoch, the current time if 0")
+	logPath := flags.String("log", "", "file the submitted transactions are appended to, in the format of replay")
+	until := flags.String("until", "", "ID of the transaction replay stops after")
+	if err := flags.Parse(arguments); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	fail := func(format string, a ...interface{}) int {
+		fmt.Fprintf(stderr, format+"\n", a...)
+		return 1
+	}
+
+	defaults := transaction{Timestamp: *timestamp}
+	if len(attrs) > 0 {
+		parsed, err := parseAttrs(attrs)
+		if err != nil {
+			return fail("Error in -attr: %s", err)
+		}
+		defaults.MSPID, defaults.Attrs = *mspID, parsed
+	}
+
+	s, err := readStore(*storePath)
+	if err != nil {
+		return fail("Error reading the store %s: %s", *storePath, err)
+	}
+	sim, err := newSimulator(s, *mspID)
+	if err != nil {
+		return fail("%s", err)
+	}
+	if *logPath != "" && command != "replay" {
+		logFile, err := os.OpenFile(*logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fail("Error opening the log %s: %s", *logPath, err)
+		}
+		defer logFile.Close()
+		sim.log = logFile
+	}
+
+	// open returns the file named by an argument, or the standard input for -
+	open := func(path string) (io.ReadCloser, error) {
+		if path == "-" {
+			return ioutil.NopCloser(stdin), nil
+		}
+		return os.Open(path)
+	}
+
+	code := 0
+	switch {
+	case (command == "invoke" || command == "query") && len(args) > 0:
+		tx := defaults
+		tx.Function, tx.Args = args[0], args[1:]
+		response, err := sim.run(&tx, command == "invoke")
+		if err != nil {
+			return fail("%s", err)
+		}
+		printResponse(stdout, &tx, response)
+		if response.Status >= shim.ERRORTHRESHOLD {
+			code = 1
+		}
+	case command == "run" && len(args) == 1:
+		script, err := open(args[0])
+		if err != nil {
+			return fail("Error opening the script: %s", err)
+		}
+		defer script.Close()
+		if err := sim.runScript(script, defaults, stdout); err != nil {
+			code = fail("Error running the script: %s", err)
+		}
+	case command == "replay" && len(args) == 1:
+		log, err := open(args[0])
+		if err != nil {
+			return fail("Error opening the log: %s", err)
+		}
+		defer log.Close()
+		mismatches, err := sim.replay(log, *until, stdout)
+		if mismatches > 0 {
+			code = fail("%d transactions did not respond with their recorded status", mismatches)
+		}
+		if err != nil {
+			code = fail("Error replaying the log: %s", err)
+		}
+	case command == "dump" && len(args) == 0:
+		if err := encodeStore(stdout, &store{State: sim.store().State}); err != nil {
+			return fail("Error writing the dump: %s", err)
+		}
+		return 0
+	case command == "import" && len(args) == 1:
+		file, err := open(args[0])
+		if err != nil {
+			return fail("Error opening the dump: %s", err)
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			return fail("Error reading the dump: %s", err)
+		}
+		dump, err := decodeDump(data)
+		if err != nil {
+			return fail("Error reading the dump: %s", err)
+		}
+		if err := sim.load(dump); err != nil {
+			return fail("Error importing the dump: %s", err)
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	// Queries are never committed, while the transactions committed before a failed script or
+	// replay are kept
+	if command != "query" {
+		if err := writeStore(*storePath, sim.store()); err != nil {
+			return fail("Error writing the store %s: %s", *storePath, err)
+		}
+	}
+	return code
+}
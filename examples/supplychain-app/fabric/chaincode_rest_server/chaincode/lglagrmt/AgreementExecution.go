@@ -0,0 +1,116 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// readAgreementExecutionStatus returns which parties of a multi-party legal agreement signed it
+func (s *SmartContract) readAgreementExecutionStatus(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ReadAgreementExecutionStatusRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ReadAgreementExecutionStatusRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadAgreementExecutionStatusRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Get the legal agreement state from the ledger
+	legalAgreementAsBytes, err := stub.GetState(tenantKey(request.TenantID, request.LegalAgreementID))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return 404 if legal agreement does not exist
+	notFound := peer.Response{
+		Status:  404,
+		Message: fmt.Sprintf("Legal Agreement %s does not exist", request.LegalAgreementID),
+	}
+	if len(legalAgreementAsBytes) == 0 || !isValidID(request.LegalAgreementID) {
+		return notFound
+	}
+
+	var legalAgreement LegalAgreement
+	err = json.Unmarshal(legalAgreementAsBytes, &legalAgreement)
+	if err != nil {
+		if err.Error() == "Not a LegalAgreement" {
+			return notFound
+		}
+		return shim.Error(err.Error())
+	}
+	if len(legalAgreement.Parties) == 0 {
+		return badRequest(fmt.Sprintf("Legal Agreement %s does not declare parties", legalAgreement.ID))
+	}
+
+	legalAgreementSignings, err := getLegalAgreementSignings(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	executionStatus := computeExecutionStatus(legalAgreement, legalAgreementSignings)
+	executionStatusAsBytes, _ := MarshalCanonical(executionStatus)
+
+	return shim.Success(executionStatusAsBytes)
+}
+
+// computeExecutionStatus reports which parties of a legal agreement accepted it in their latest
+// signing of it
+func computeExecutionStatus(legalAgreement LegalAgreement, legalAgreementSignings []LegalAgreementSigning) AgreementExecutionStatus {
+	var signingsOfAgreement []LegalAgreementSigning
+	for _, legalAgreementSigning := range legalAgreementSignings {
+		if legalAgreementSigning.LegalAgreementID == legalAgreement.ID {
+			signingsOfAgreement = append(signingsOfAgreement, legalAgreementSigning)
+		}
+	}
+	latestSignings := latestSigningsByUser(signingsOfAgreement)
+
+	executionStatus := AgreementExecutionStatus{
+		LegalAgreementID: legalAgreement.ID,
+		Version:          legalAgreement.Version,
+		Parties:          []PartySigningStatus{},
+	}
+	for _, party := range legalAgreement.Parties {
+		partyStatus := PartySigningStatus{UserID: party.UserID, Role: party.Role}
+		if latest, ok := latestSignings[party.UserID]; ok {
+			partyStatus.Signed = latest.Accepted
+			partyStatus.LegalAgreementSigningID = latest.ID
+			partyStatus.Timestamp = latest.Timestamp
+		}
+		if partyStatus.Signed {
+			executionStatus.SignedPartiesCount++
+		}
+		executionStatus.Parties = append(executionStatus.Parties, partyStatus)
+	}
+	executionStatus.Executed = len(legalAgreement.Parties) > 0 && executionStatus.SignedPartiesCount == len(legalAgreement.Parties)
+
+	return executionStatus
+}
+
+// validateParties checks that every party of a legal agreement is a distinct, valid user ID
+func validateParties(parties []Party) error {
+	userIDs := map[string]bool{}
+	for _, party := range parties {
+		if err := validateID("party user ID", party.UserID); err != nil {
+			return err
+		}
+		if userIDs[party.UserID] {
+			return fmt.Errorf("Duplicate party %s", party.UserID)
+		}
+		userIDs[party.UserID] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+func TestAgreementExecution(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	// createSigning runs the Create Legal Agreement Signing transaction for the given party
+	createSigning := func(id string, userID string, accepted bool) (peer.Response, WriteResponse) {
+		contentHash, _ := ComputeContentHash("supply contract", DefaultHashAlgorithm)
+		request := LegalAgreementSigningRequest{
+			ID:                        id,
+			UserID:                    userID,
+			LegalAgreementID:          "001",
+			LegalAgreementContentHash: contentHash,
+			Accepted:                  accepted,
+			Timestamp:                 1654027900,
+		}
+		requestAsBytes, _ := json.Marshal(request)
+
+		args := [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+		response := mockStub.MockInvoke("legalagreement", args)
+
+		var result WriteResponse
+		json.Unmarshal(response.Payload, &result)
+		return response, result
+	}
+
+	// readExecutionStatus runs the Read Agreement Execution Status query
+	readExecutionStatus := func() AgreementExecutionStatus {
+		args := [][]byte{[]byte("readAgreementExecutionStatus"), []byte(`{"legalAgreementID":"001"}`)}
+		response := mockStub.MockInvoke("legalagreement", args)
+		Expect(response.Status).To(BeEquivalentTo(200))
+
+		var result AgreementExecutionStatus
+		json.Unmarshal(response.Payload, &result)
+		return result
+	}
+
+	g.Describe("Agreement Execution", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"supply contract","timestamp":1654027884,"version":1,"parties":[{"userID":"buyer-corp","role":"buyer"},{"userID":"supplier-corp","role":"supplier"}]}`)}
+			response := mockStub.MockInvoke("legalagreement", args)
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should report the parties that signed", func() {
+				createSigning("0001", "buyer-corp", true)
+
+				result := readExecutionStatus()
+
+				Expect(result.Executed).To(BeFalse())
+				Expect(result.SignedPartiesCount).To(Equal(1))
+				Expect(result.Parties).To(Equal([]PartySigningStatus{
+					{UserID: "buyer-corp", Role: "buyer", Signed: true, LegalAgreementSigningID: "0001", Timestamp: 1654027900},
+					{UserID: "supplier-corp", Role: "supplier"},
+				}))
+			})
+
+			g.It("should emit the completion event with the last required signature", func() {
+				_, result := createSigning("0001", "buyer-corp", true)
+				Expect(result.Events).To(HaveLen(1))
+
+				_, result = createSigning("0002", "supplier-corp", true)
+				Expect(result.Events).To(HaveLen(2))
+				Expect(result.Events[0].Name).To(Equal(EventLegalAgreementExecuted))
+				Expect(result.Events[0].Payload["legalAgreementID"]).To(Equal("001"))
+				Expect(result.Events[1].Name).To(Equal(EventLegalAgreementSigningCreated))
+
+				Expect(readExecutionStatus().Executed).To(BeTrue())
+
+				// A later signing of an executed legal agreement does not complete it again
+				_, result = createSigning("0003", "buyer-corp", true)
+				Expect(result.Events).To(HaveLen(1))
+			})
+
+			g.It("should name the chaincode event of the completing transaction after the execution", func() {
+				// Skip the event of the creation of the legal agreement
+				<-mockStub.ChaincodeEventsChannel
+
+				createSigning("0001", "buyer-corp", true)
+				createSigning("0002", "supplier-corp", true)
+
+				// Each transaction sets a single chaincode event
+				Expect(mockStub.ChaincodeEventsChannel).To(HaveLen(2))
+				Expect((<-mockStub.ChaincodeEventsChannel).EventName).To(Equal(EventLegalAgreementSigningCreated))
+				Expect((<-mockStub.ChaincodeEventsChannel).EventName).To(Equal(EventLegalAgreementExecuted))
+			})
+
+			g.It("should not count a declined signing", func() {
+				createSigning("0001", "buyer-corp", false)
+				_, result := createSigning("0002", "supplier-corp", true)
+
+				Expect(result.Events).To(HaveLen(1))
+				Expect(readExecutionStatus().Executed).To(BeFalse())
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 403 if the user is not a party", func() {
+				response, _ := createSigning("0001", "u-001", true)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("User u-001 is not a party to Legal Agreement 001"))
+			})
+
+			g.It("should return an error if a party is declared twice", func() {
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"002","content":"supply contract v2","timestamp":1654028933,"version":2,"parties":[{"userID":"buyer-corp"},{"userID":"buyer-corp"}]}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Duplicate party buyer-corp"))
+			})
+
+			g.It("should return 404 if the Legal Agreement doesn't exist", func() {
+				args := [][]byte{[]byte("readAgreementExecutionStatus"), []byte(`{"legalAgreementID":"404"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+			})
+		})
+	})
+}
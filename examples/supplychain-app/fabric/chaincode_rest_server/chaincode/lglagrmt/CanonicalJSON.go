@@ -0,0 +1,36 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// storedDocumentResponse returns a stored document in canonical JSON. Documents are written in
+// canonical JSON, and the ones written before are returned in the same form.
+func storedDocumentResponse(documentAsBytes []byte, name string) peer.Response {
+	canonicalAsBytes, err := CanonicalizeJSON(documentAsBytes)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to canonicalize %s: %s", name, err))
+	}
+	return shim.Success(canonicalAsBytes)
+}
+
+// validateCanonicalArgs returns a 400 error if a JSON argument has no canonical form, such as one
+// with an integer beyond 2^53. Requests are hashed and written in canonical JSON, which would fail
+// for them. Arguments that are not JSON are left to the transactions.
+func validateCanonicalArgs(args []string) error {
+	for _, arg := range args {
+		if !json.Valid([]byte(arg)) {
+			continue
+		}
+		if _, err := CanonicalizeJSON([]byte(arg)); err != nil {
+			return &statusError{400, fmt.Sprintf("The request has no canonical form: %s", err)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var ledger *stubtest.Stub
+	var admin *stubtest.Identity
+	chaincode := new(SmartContract)
+
+	// expectCanonical checks that a document is in canonical JSON
+	expectCanonical := func(document []byte) {
+		canonical, err := CanonicalizeJSON(document)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(document)).To(Equal(string(canonical)))
+	}
+
+	g.Describe("Canonical JSON", func() {
+		g.BeforeEach(func() {
+			admin, _ = stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			ledger = stubtest.New("legalagreement", chaincode)
+			ledger.SetCreator(admin)
+			response := ledger.Init("tx0", [][]byte{[]byte("init")})
+			chaincode.logger.SetLevel(shim.LogError)
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+
+		g.It("should write records and responses in canonical JSON", func() {
+			response := ledger.Invoke("tx1", [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"<first> & version","timestamp":1654027884,"version":1,"idempotencyKey":"k-001"}`)})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			expectCanonical(response.Payload)
+
+			for _, key := range []string{"001", configKey, idempotencyKeyKey("", "k-001")} {
+				stored, _ := ledger.GetState(key)
+				expectCanonical(stored)
+			}
+			stored, _ := ledger.GetState("001")
+			Expect(string(stored)).To(ContainSubstring(`"content":"<first> & version"`))
+		})
+
+		g.It("should return records written before in canonical JSON", func() {
+			state := ledger.State()
+			state["001"] = []byte(`{"version":1, "timestamp":1654027884, "content":"first version", "ID":"001", "schemaVersion":2, "status":"published"}`)
+			ledger.Load(state)
+
+			response := ledger.Query("read", [][]byte{[]byte("readLegalAgreement"), []byte(`{"ID":"001"}`)})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			Expect(string(response.Payload)).To(Equal(`{"ID":"001","content":"first version","schemaVersion":2,"status":"published","timestamp":1654027884,"version":1}`))
+		})
+
+		g.It("should reject requests with an integer that canonical JSON cannot hold", func() {
+			response := ledger.Invoke("tx1", [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":9007199254740993,"version":1}`)})
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("The request has no canonical form: The JSON document has the integer 9007199254740993 beyond 2^53"))
+
+			stored, _ := ledger.GetState("001")
+			Expect(stored).To(BeEmpty())
+		})
+
+		g.It("should import records in canonical JSON", func() {
+			chunk, _ := EncodeStateExport(StateExportHeader{SchemaVersion: CurrentSchemaVersion}, []StateExportRecord{
+				{Type: RecordTypeLegalAgreement, Key: "001", Value: json.RawMessage(`{"version":1,"timestamp":1654027884,"content":"first version","ID":"001"}`)},
+			})
+			request, _ := json.Marshal(ImportStateRequest{Chunk: string(chunk)})
+			response := ledger.Invoke("import", [][]byte{[]byte("importState"), request})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			stored, _ := ledger.GetState("001")
+			Expect(string(stored)).To(Equal(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`))
+		})
+	})
+}
@@ -0,0 +1,227 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// configKey is the key of the configuration. It starts with the tenant key separator, which
+// tenant IDs cannot contain, so it never falls within the range of a tenant.
+const configKey = tenantKeySeparator + "config"
+
+// initConfig writes the configuration when the chaincode is instantiated or upgraded. The stored
+// configuration, or the default one, is kept unless the request changes it.
+func initConfig(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) > 1 {
+		return badRequest("Incorrect number of arguments. Expecting 0 or 1")
+	}
+
+	config, stored, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if stored && len(args) == 0 {
+		return shim.Success(nil)
+	}
+
+	// Admins belong to the org that instantiates the chaincode, unless the request names others.
+	// Once the configuration is stored, only admins change them.
+	if !stored {
+		creatorMSP, err := getCreatorMSPID(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if creatorMSP != "" {
+			config.Roles.AdminMSPs = []string{creatorMSP}
+		}
+	}
+
+	// Records written before the configuration existed are at the legacy schema version
+	if !stored {
+		legacy, err := hasRecords(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if legacy {
+			config.SchemaVersion = LegacySchemaVersion
+		}
+	}
+
+	if len(args) == 1 {
+		config, err = mergeConfig(config, []byte(args[0]))
+		if err != nil {
+			return badRequest(err.Error())
+		}
+	}
+
+	if err := putConfig(stub, config); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// readConfig returns the configuration of the contract
+func (s *SmartContract) readConfig(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return badRequest("Incorrect number of arguments. Expecting 0")
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	configAsBytes, _ := MarshalCanonical(config)
+
+	return shim.Success(configAsBytes)
+}
+
+// updateConfig changes the configuration of the contract. It must be submitted by the admin role.
+func (s *SmartContract) updateConfig(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Only admins can update the configuration
+	if err := authorizeAdmin(stub, "update the configuration"); err != nil {
+		return errorResponse(err)
+	}
+
+	config, err = mergeConfig(config, []byte(args[0]))
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	if err := putConfig(stub, config); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		Document: config,
+		Events: []Event{{
+			Name: EventConfigUpdated,
+			Payload: map[string]interface{}{
+				"schemaVersion": config.SchemaVersion,
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	s.logger.Infof("Updated configuration in transaction: %s\n", stub.GetTxID())
+	return shim.Success(bytes)
+}
+
+// getConfig returns the configuration stored in the ledger, or the default configuration if
+// none is stored, and whether it was stored
+func getConfig(stub shim.ChaincodeStubInterface) (Config, bool, error) {
+	configAsBytes, err := stub.GetState(configKey)
+	if err != nil {
+		return Config{}, false, err
+	}
+	if len(configAsBytes) == 0 {
+		return DefaultConfig(), false, nil
+	}
+
+	var config Config
+	err = json.Unmarshal(configAsBytes, &config)
+	if err != nil {
+		return Config{}, false, fmt.Errorf("Error unmarshaling Config: %s", err)
+	}
+
+	return config, true, nil
+}
+
+// mergeConfig applies the fields present in an UpdateConfigRequest to the configuration and
+// validates the result
+func mergeConfig(config Config, argBytes []byte) (Config, error) {
+	request := UpdateConfigRequest(config)
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return Config{}, fmt.Errorf("Error unmarshaling UpdateConfigRequest: %s", err)
+	}
+
+	if request.SchemaVersion != config.SchemaVersion {
+		return Config{}, fmt.Errorf("The schema version %d cannot be changed to %d", config.SchemaVersion, request.SchemaVersion)
+	}
+
+	merged := Config(request)
+	if err := validateConfig(merged); err != nil {
+		return Config{}, err
+	}
+
+	return merged, nil
+}
+
+// validateConfig checks that the configuration can be used by the contract
+func validateConfig(config Config) error {
+	if config.Roles.Admin == "" {
+		return fmt.Errorf("The admin role cannot be empty")
+	}
+	if len(config.Roles.AdminMSPs) == 0 {
+		return fmt.Errorf("The admin MSPs cannot be empty")
+	}
+	for _, mspID := range config.Roles.AdminMSPs {
+		if mspID == "" {
+			return fmt.Errorf("The admin MSPs cannot contain an empty MSP ID")
+		}
+	}
+	if config.Limits.MaxContentLength < 0 {
+		return fmt.Errorf("Invalid maximum content length %d", config.Limits.MaxContentLength)
+	}
+	if config.Limits.DefaultPageSize <= 0 {
+		return fmt.Errorf("Invalid default page size %d", config.Limits.DefaultPageSize)
+	}
+	if config.Limits.MaxPageSize < 0 {
+		return fmt.Errorf("Invalid maximum page size %d", config.Limits.MaxPageSize)
+	}
+	if config.Limits.MaxPageSize != 0 && config.Limits.DefaultPageSize > config.Limits.MaxPageSize {
+		return fmt.Errorf("The default page size %d exceeds the maximum page size %d", config.Limits.DefaultPageSize, config.Limits.MaxPageSize)
+	}
+	if config.Features.IDStrategy != "" && config.Features.IDStrategy != IDStrategyTxID && config.Features.IDStrategy != IDStrategyContent {
+		return fmt.Errorf("Invalid ID strategy %s. Expecting txID or content", config.Features.IDStrategy)
+	}
+	return nil
+}
+
+// resolvePageSize returns the page size to use for a paginated request, checked against the limits of the configuration
+func resolvePageSize(config Config, requested int32) (int32, error) {
+	if requested < 0 {
+		return 0, fmt.Errorf("Invalid page size %d", requested)
+	}
+	if requested == 0 {
+		return config.Limits.DefaultPageSize, nil
+	}
+	if config.Limits.MaxPageSize > 0 && requested > config.Limits.MaxPageSize {
+		return 0, fmt.Errorf("The page size %d exceeds the maximum page size %d", requested, config.Limits.MaxPageSize)
+	}
+	return requested, nil
+}
+
+// hasRecords reports whether any record is stored in the ledger
+func hasRecords(stub shim.ChaincodeStubInterface) (bool, error) {
+	iterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return false, fmt.Errorf("Error getting state iterator: %s", err)
+	}
+	defer iterator.Close()
+
+	return iterator.HasNext(), nil
+}
+
+// putConfig writes the configuration to the ledger
+func putConfig(stub shim.ChaincodeStubInterface, config Config) error {
+	configAsBytes, _ := MarshalCanonical(config)
+	return stub.PutState(configKey, configAsBytes)
+}
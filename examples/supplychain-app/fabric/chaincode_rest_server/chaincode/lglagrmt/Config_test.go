@@ -0,0 +1,216 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfig(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	// readConfig runs the Read Config transaction
+	readConfig := func() Config {
+		args := [][]byte{[]byte("readConfig")}
+		response := mockStub.MockInvoke("legalagreement", args)
+		Expect(response.Status).To(BeEquivalentTo(200))
+
+		var result Config
+		json.Unmarshal(response.Payload, &result)
+		return result
+	}
+
+	g.Describe("Init", func() {
+		g.It("should write the default configuration", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			response := mockStub.MockInit(txID, [][]byte{[]byte("init")})
+			chaincode.logger.SetLevel(shim.LogError)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+			Expect(readConfig()).To(Equal(DefaultConfig()))
+		})
+
+		g.It("should apply the configuration given at instantiation", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			response := mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"admin":"governance","adminMSPs":["Org1MSP"]},"limits":{"maxContentLength":1024}}`)})
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+
+			result := readConfig()
+			Expect(result.Roles.Admin).To(Equal("governance"))
+			Expect(result.Limits.MaxContentLength).To(Equal(1024))
+			Expect(result.Limits.DefaultPageSize).To(Equal(DefaultConfig().Limits.DefaultPageSize))
+		})
+
+		g.It("should make admins of the org that instantiates the chaincode", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			initAs(chaincode, mockStub, "Org1MSP")
+
+			Expect(readConfig().Roles.AdminMSPs).To(Equal([]string{"Org1MSP"}))
+		})
+
+		g.It("should keep the stored configuration on upgrade", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+			mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"admin":"governance","adminMSPs":["Org1MSP"]}}`)})
+
+			response := mockStub.MockInit(txID, [][]byte{[]byte("init")})
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+			Expect(readConfig().Roles.Admin).To(Equal("governance"))
+		})
+
+		g.It("should return an error if the configuration is invalid", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			response := mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"admin":""}}`)})
+
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("The admin role cannot be empty"))
+		})
+
+		g.It("should return an error if no org has admins", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			response := mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"admin":"governance"}}`)})
+
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("The admin MSPs cannot be empty"))
+		})
+	})
+
+	g.Describe("Update Config", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+			mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"admin":"governance","adminMSPs":["Org1MSP"]}}`)})
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should update the configuration as the configured admin role", func() {
+				governance, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "governance"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(governance, []string{`{"features":{"sequentialVersions":true,"orgEndorsementPolicy":true}}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(readConfig().Features.SequentialVersions).To(BeTrue())
+			})
+
+			g.It("should apply the validation limits", func() {
+				governance, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "governance"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(governance, []string{`{"limits":{"maxContentLength":5,"defaultPageSize":10,"maxPageSize":10}}`})
+				mockStub.MockTransactionEnd(txID)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"too long","timestamp":1654027884,"version":1}`)}
+				response = mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("The content exceeds the maximum length of 5 bytes"))
+
+				args = [][]byte{[]byte("listUsersRequiringReconsent"), []byte(`{"pageSize":11}`)}
+				response = mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("The page size 11 exceeds the maximum page size 10"))
+			})
+
+			g.It("should require sequential versions if the feature is enabled", func() {
+				governance, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "governance"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(governance, []string{`{"features":{"sequentialVersions":true}}`})
+				mockStub.MockTransactionEnd(txID)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":2}`)}
+				response = mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("The version 2 does not follow the latest version 0"))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 403 if the identity does not have the configured admin role", func() {
+				admin, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "admin"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(admin, []string{`{"roles":{"admin":"admin"}}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only admins can update the configuration"))
+			})
+
+			g.It("should return 403 if the admin role is issued by another org", func() {
+				governance, _ := newIdentityStub(mockStub, "Org2MSP", map[string]string{"role": "governance"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(governance, []string{`{"roles":{"adminMSPs":["Org1MSP","Org2MSP"]}}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only admins can update the configuration"))
+			})
+
+			g.It("should return 403 if the configuration is changed through init", func() {
+				args := [][]byte{[]byte("init"), []byte(`{"roles":{"admin":"admin"}}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(readConfig().Roles.Admin).To(Equal("governance"))
+			})
+
+			g.It("should not let another org claim the admins by running init again", func() {
+				mockStub = NewMockStub("mockstub", chaincode)
+				mockStub.MockInit(txID, [][]byte{[]byte("init")})
+				other, _ := newIdentityStub(mockStub, "Org2MSP", map[string]string{"role": "admin"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.Invoke(other)
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(readConfig().Roles.AdminMSPs).To(BeEmpty())
+			})
+
+			g.It("should return an error if the admin MSPs are emptied", func() {
+				governance, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "governance"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(governance, []string{`{"roles":{"adminMSPs":[]}}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("The admin MSPs cannot be empty"))
+			})
+
+			g.It("should return an error if the schema version is changed", func() {
+				governance, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "governance"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateConfig(governance, []string{`{"schemaVersion":3}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("The schema version 2 cannot be changed to 3"))
+			})
+		})
+	})
+}
@@ -0,0 +1,120 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// readScopeConsent returns whether a user consented to a scope of the legal agreement in force. The
+// user consents to a scope only while their latest signing is up to date and gives consent to it.
+func (s *SmartContract) readScopeConsent(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ReadScopeConsentRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ReadScopeConsentRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadScopeConsentRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Get the legal agreement in force
+	asOf, err := asOfOrTxTimestamp(stub, request.AsOf)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	effectiveLegalAgreement := effectiveVersion(legalAgreements, asOf)
+	if len(effectiveLegalAgreement.ID) == 0 {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("No Legal Agreement is effective at %d", asOf),
+		}
+	}
+
+	// Return 404 if the legal agreement in force does not declare the scope
+	scope, ok := effectiveLegalAgreement.Scope(request.Scope)
+	if !ok {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("Consent scope %s does not exist in Legal Agreement %s", request.Scope, effectiveLegalAgreement.ID),
+		}
+	}
+
+	// Get the latest signing and the identity of the user
+	legalAgreementSignings, err := getLegalAgreementSignings(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	latestLegalAgreementSigning, signed := latestSigningsByUser(legalAgreementSignings)[request.UserID]
+
+	userIdentity, err := getUserIdentity(stub, request.TenantID, request.UserID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	scopeConsent := ScopeConsent{
+		UserID:           request.UserID,
+		Scope:            scope.Name,
+		LegalAgreementID: effectiveLegalAgreement.ID,
+		Version:          effectiveLegalAgreement.Version,
+	}
+
+	var latest *LegalAgreementSigning
+	if signed {
+		latest = &latestLegalAgreementSigning
+		scopeConsent.LegalAgreementSigningID = latest.ID
+	}
+	scopeConsent.Status = computeConsentStatus(request.UserID, userIdentity, latest, legalAgreements, effectiveLegalAgreement).Status
+	scopeConsent.Consented = scopeConsent.Status == ConsentStatusUpToDate && latest.ConsentedTo(scope)
+
+	scopeConsentAsBytes, _ := MarshalCanonical(scopeConsent)
+
+	return shim.Success(scopeConsentAsBytes)
+}
+
+// validateScopes checks that every consent scope of a legal agreement has a unique name
+func validateScopes(scopes []ConsentScope) error {
+	names := map[string]bool{}
+	for _, scope := range scopes {
+		if scope.Name == "" {
+			return fmt.Errorf("Invalid consent scope name %q", scope.Name)
+		}
+		if names[scope.Name] {
+			return fmt.Errorf("Duplicate consent scope %s", scope.Name)
+		}
+		names[scope.Name] = true
+	}
+
+	return nil
+}
+
+// validateScopeDecisions checks the scope decisions of a signing against the scopes of the legal
+// agreement it signs. Accepting the legal agreement while declining a required scope is rejected.
+func validateScopeDecisions(legalAgreement LegalAgreement, accepted bool, scopeDecisions map[string]bool) error {
+	for name, decision := range scopeDecisions {
+		scope, ok := legalAgreement.Scope(name)
+		if !ok {
+			return fmt.Errorf("Unknown consent scope %s", name)
+		}
+		if accepted && scope.Required && !decision {
+			return fmt.Errorf("Required consent scope %s must be accepted", name)
+		}
+	}
+
+	return nil
+}
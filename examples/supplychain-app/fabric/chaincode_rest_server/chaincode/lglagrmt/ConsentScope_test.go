@@ -0,0 +1,172 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+func TestConsentScope(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	// createSigning runs the Create Legal Agreement Signing transaction with the given scope decisions
+	createSigning := func(id string, userID string, accepted bool, scopeDecisions map[string]bool) peer.Response {
+		contentHash, _ := ComputeContentHash("first version", DefaultHashAlgorithm)
+		request := LegalAgreementSigningRequest{
+			ID:                        id,
+			UserID:                    userID,
+			LegalAgreementID:          "001",
+			LegalAgreementContentHash: contentHash,
+			Accepted:                  accepted,
+			Timestamp:                 1654027900,
+			ScopeDecisions:            scopeDecisions,
+		}
+		requestAsBytes, _ := json.Marshal(request)
+
+		args := [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+		return mockStub.MockInvoke("legalagreement", args)
+	}
+
+	// readScopeConsent runs the Read Scope Consent query for the given user and scope
+	readScopeConsent := func(userID string, scope string) (peer.Response, ScopeConsent) {
+		request, _ := json.Marshal(ReadScopeConsentRequest{UserID: userID, Scope: scope})
+		args := [][]byte{[]byte("readScopeConsent"), request}
+		response := mockStub.MockInvoke("legalagreement", args)
+
+		var result ScopeConsent
+		json.Unmarshal(response.Payload, &result)
+		return response, result
+	}
+
+	g.Describe("Consent Scopes", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1,"scopes":[{"name":"terms","required":true},{"name":"marketing","description":"Product news by email"}]}`)}
+			response := mockStub.MockInvoke("legalagreement", args)
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should record the scope decisions and the decline reason", func() {
+				contentHash, _ := ComputeContentHash("first version", DefaultHashAlgorithm)
+				request := LegalAgreementSigningRequest{
+					ID:                        "0001",
+					UserID:                    "u-001",
+					LegalAgreementID:          "001",
+					LegalAgreementContentHash: contentHash,
+					Accepted:                  true,
+					Timestamp:                 1654027900,
+					ScopeDecisions:            map[string]bool{"marketing": false},
+					DeclineReason:             "no emails please",
+				}
+				requestAsBytes, _ := json.Marshal(request)
+
+				args := [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+				response := mockStub.MockInvoke("legalagreement", args)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				// Retrieve results from ledger
+				bytes, _ := mockStub.GetState("0001")
+				var result LegalAgreementSigning
+				json.Unmarshal(bytes, &result)
+
+				Expect(result.ScopeDecisions).To(Equal(map[string]bool{"marketing": false}))
+				Expect(result.DeclineReason).To(Equal("no emails please"))
+			})
+
+			g.It("should answer whether the user consented to a scope", func() {
+				createSigning("0001", "u-001", true, map[string]bool{"marketing": true})
+				createSigning("0002", "u-002", true, nil)
+				createSigning("0003", "u-003", false, map[string]bool{"marketing": true})
+
+				expected := map[string]map[string]bool{
+					"u-001": {"terms": true, "marketing": true},
+					"u-002": {"terms": true, "marketing": false},
+					"u-003": {"terms": false, "marketing": false},
+				}
+
+				for userID, scopes := range expected {
+					for scope, consented := range scopes {
+						response, result := readScopeConsent(userID, scope)
+
+						Expect(response.Status).To(BeEquivalentTo(200))
+						Expect(result.Consented).To(Equal(consented))
+						Expect(result.LegalAgreementID).To(Equal("001"))
+					}
+				}
+			})
+
+			g.It("should not report consent given under an outdated version", func() {
+				createSigning("0001", "u-001", true, map[string]bool{"marketing": true})
+
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"002","content":"second version","timestamp":1654028933,"version":2,"scopes":[{"name":"marketing"}]}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				response, result := readScopeConsent("u-001", "marketing")
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.Consented).To(BeFalse())
+				Expect(result.Status).To(Equal(ConsentStatusOutdated))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if a required scope is declined", func() {
+				response := createSigning("0001", "u-001", true, map[string]bool{"terms": false})
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Required consent scope terms must be accepted"))
+			})
+
+			g.It("should return an error if the scope is unknown", func() {
+				response := createSigning("0001", "u-001", true, map[string]bool{"analytics": true})
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Unknown consent scope analytics"))
+			})
+
+			g.It("should return an error if a scope is declared twice", func() {
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"002","content":"second version","timestamp":1654028933,"version":2,"scopes":[{"name":"marketing"},{"name":"marketing"}]}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Duplicate consent scope marketing"))
+			})
+
+			g.It("should return 404 if the effective version does not declare the scope", func() {
+				createSigning("0001", "u-001", true, nil)
+
+				response, _ := readScopeConsent("u-001", "analytics")
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+				Expect(response.Message).To(Equal("Consent scope analytics does not exist in Legal Agreement 001"))
+			})
+		})
+	})
+}
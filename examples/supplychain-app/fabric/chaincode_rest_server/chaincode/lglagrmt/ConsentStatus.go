@@ -0,0 +1,235 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// readConsentStatus returns the consent status of a user against the legal agreement in force, together
+// with the identity status and latest signing it was computed from, in a single read of the ledger
+func (s *SmartContract) readConsentStatus(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ReadConsentStatusRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ReadConsentStatusRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadConsentStatusRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Get the legal agreement in force
+	asOf, err := asOfOrTxTimestamp(stub, request.AsOf)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	effectiveLegalAgreement := effectiveVersion(legalAgreements, asOf)
+	if len(effectiveLegalAgreement.ID) == 0 {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("No Legal Agreement is effective at %d", asOf),
+		}
+	}
+
+	// Get the latest signing and the identity of the user
+	legalAgreementSignings, err := getLegalAgreementSignings(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	latestLegalAgreementSigning, signed := latestSigningsByUser(legalAgreementSignings)[request.UserID]
+
+	userIdentity, err := getUserIdentity(stub, request.TenantID, request.UserID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return 404 if the user never signed and was not revoked
+	if !signed && (userIdentity == nil || userIdentity.Status != UserIdentityStatusRevoked) {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("Legal Agreement Signing for user %s does not exist", request.UserID),
+		}
+	}
+
+	var latest *LegalAgreementSigning
+	if signed {
+		latest = &latestLegalAgreementSigning
+	}
+	consentStatus := computeConsentStatus(request.UserID, userIdentity, latest, legalAgreements, effectiveLegalAgreement)
+	consentStatusAsBytes, _ := MarshalCanonical(consentStatus)
+
+	return shim.Success(consentStatusAsBytes)
+}
+
+// listUsersRequiringReconsent returns the users who must consent again, as readConsentStatus reports
+// them, among a page of the users who signed. It reads the signing index of the users in user ID
+// order, so a page reads pageSize users whether they must consent again or not, and the bookmark is
+// the key of the index of the last user read.
+func (s *SmartContract) listUsersRequiringReconsent(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ListUsersRequiringReconsentRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ListUsersRequiringReconsentRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ListUsersRequiringReconsentRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	pageSize, err := resolvePageSize(config, request.PageSize)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	// Get the legal agreement in force
+	asOf, err := asOfOrTxTimestamp(stub, request.AsOf)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	effectiveLegalAgreement := effectiveVersion(legalAgreements, asOf)
+	if len(effectiveLegalAgreement.ID) == 0 {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("No Legal Agreement is effective at %d", asOf),
+		}
+	}
+
+	// Resume after the last user read by the previous page
+	startKey, endKey := userSigningIndexRange(request.TenantID)
+	if request.Bookmark != "" {
+		if !strings.HasPrefix(request.Bookmark, startKey) {
+			return peer.Response{
+				Status:  400,
+				Message: fmt.Sprintf("Invalid bookmark %s", request.Bookmark),
+			}
+		}
+		startKey = request.Bookmark + "\x00"
+	}
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Error getting state iterator: %s", err))
+	}
+	defer iterator.Close()
+
+	response := ListUsersRequiringReconsentResponse{Users: []ConsentStatus{}}
+	for iterator.HasNext() {
+		// Stop once the page is full, leaving the rest to the next call
+		if response.FetchedRecordsCount == pageSize {
+			break
+		}
+
+		// Get the next item
+		item, err := iterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error getting next item: %s", err))
+		}
+		response.FetchedRecordsCount++
+		response.Bookmark = item.Key
+
+		var index UserSigningIndex
+		if err := json.Unmarshal(item.Value, &index); err != nil {
+			return shim.Error(fmt.Sprintf("Error unmarshaling item: %s", err))
+		}
+
+		// Get the latest signing and the identity of the user
+		legalAgreementSigningAsBytes, err := stub.GetState(tenantKey(request.TenantID, index.LegalAgreementSigningID))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		var latestLegalAgreementSigning LegalAgreementSigning
+		if err := json.Unmarshal(legalAgreementSigningAsBytes, &latestLegalAgreementSigning); err != nil {
+			return shim.Error(fmt.Sprintf("Error unmarshaling Legal Agreement Signing %s: %s", index.LegalAgreementSigningID, err))
+		}
+
+		userIdentity, err := getUserIdentity(stub, request.TenantID, index.UserID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		consentStatus := computeConsentStatus(index.UserID, userIdentity, &latestLegalAgreementSigning, legalAgreements, effectiveLegalAgreement)
+		if consentStatus.MustReconsent {
+			response.Users = append(response.Users, consentStatus)
+		}
+	}
+
+	// The bookmark is empty on the last page
+	if !iterator.HasNext() {
+		response.Bookmark = ""
+	}
+
+	responseAsBytes, _ := MarshalCanonical(response)
+
+	return shim.Success(responseAsBytes)
+}
+
+// computeConsentStatus compares the latest signing of a user, if any, with the legal agreement in force.
+// A revoked identity takes precedence over the signing, and a declined signing over its version.
+func computeConsentStatus(userID string, userIdentity *UserIdentity, latestLegalAgreementSigning *LegalAgreementSigning, legalAgreements []LegalAgreement, effectiveLegalAgreement LegalAgreement) ConsentStatus {
+	consentStatus := ConsentStatus{
+		UserID:                      userID,
+		LatestLegalAgreementSigning: latestLegalAgreementSigning,
+		CurrentLegalAgreementID:     effectiveLegalAgreement.ID,
+		CurrentVersion:              effectiveLegalAgreement.Version,
+	}
+
+	if userIdentity != nil {
+		consentStatus.IdentityStatus = userIdentity.Status
+	}
+
+	if latestLegalAgreementSigning != nil {
+		consentStatus.LegalAgreementSigningID = latestLegalAgreementSigning.ID
+		consentStatus.Accepted = latestLegalAgreementSigning.Accepted
+		for _, legalAgreement := range legalAgreements {
+			if legalAgreement.ID == latestLegalAgreementSigning.LegalAgreementID {
+				consentStatus.SignedVersion = legalAgreement.Version
+				break
+			}
+		}
+	}
+
+	switch {
+	case consentStatus.IdentityStatus == UserIdentityStatusRevoked:
+		consentStatus.Status = ConsentStatusRevoked
+	case latestLegalAgreementSigning == nil || !latestLegalAgreementSigning.Accepted:
+		consentStatus.Status = ConsentStatusDeclined
+	case latestLegalAgreementSigning.LegalAgreementID != effectiveLegalAgreement.ID ||
+		latestLegalAgreementSigning.LegalAgreementContentHash != effectiveLegalAgreement.ContentHash:
+		consentStatus.Status = ConsentStatusOutdated
+	default:
+		consentStatus.Status = ConsentStatusUpToDate
+	}
+	consentStatus.MustReconsent = consentStatus.Status != ConsentStatusUpToDate
+
+	return consentStatus
+}
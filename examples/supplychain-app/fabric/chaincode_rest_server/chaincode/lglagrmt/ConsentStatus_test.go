@@ -0,0 +1,251 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestConsentStatus(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	// createSigning runs the Create Legal Agreement Signing transaction for the given user
+	createSigning := func(id string, userID string, legalAgreementID string, content string, accepted bool, timestamp int64) {
+		contentHash, _ := ComputeContentHash(content, DefaultHashAlgorithm)
+		request := LegalAgreementSigningRequest{
+			ID:                        id,
+			UserID:                    userID,
+			LegalAgreementID:          legalAgreementID,
+			LegalAgreementContentHash: contentHash,
+			Accepted:                  accepted,
+			Timestamp:                 timestamp,
+		}
+		requestAsBytes, _ := json.Marshal(request)
+
+		args := [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+		response := mockStub.MockInvoke("legalagreement", args)
+		Expect(response.Status).To(BeEquivalentTo(200))
+	}
+
+	// createLegalAgreement runs the Create Legal Agreement transaction for a published version
+	createLegalAgreement := func(id string, content string, version int64) {
+		args := [][]byte{[]byte("createLegalAgreement"), []byte(fmt.Sprintf(`{"ID":"%s","content":"%s","timestamp":1654027884,"version":%d}`, id, content, version))}
+		response := mockStub.MockInvoke("legalagreement", args)
+		Expect(response.Status).To(BeEquivalentTo(200))
+	}
+
+	g.Describe("Read Consent Status", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			createLegalAgreement("a-001", "first version", 1)
+			createSigning("s-0001", "u-001", "a-001", "first version", true, 1654027900)
+			createLegalAgreement("a-002", "second version", 2)
+			createSigning("s-0002", "u-002", "a-002", "second version", true, 1654028000)
+			createSigning("s-0003", "u-003", "a-002", "second version", false, 1654028000)
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should report each consent status", func() {
+				expected := map[string]string{
+					"u-001": ConsentStatusOutdated,
+					"u-002": ConsentStatusUpToDate,
+					"u-003": ConsentStatusDeclined,
+				}
+
+				for userID, status := range expected {
+					// Run Read Consent Status transaction
+					args := [][]byte{[]byte("readConsentStatus"), []byte(fmt.Sprintf(`{"userID":"%s"}`, userID))}
+					response := mockStub.MockInvoke("legalagreement", args)
+
+					// Retrieve results
+					var result ConsentStatus
+					json.Unmarshal(response.Payload, &result)
+
+					Expect(response.Status).To(BeEquivalentTo(200))
+					Expect(result.Status).To(Equal(status))
+				}
+			})
+
+			g.It("should summarize the latest signing and the current version", func() {
+				// Run Read Consent Status transaction
+				args := [][]byte{[]byte("readConsentStatus"), []byte(`{"userID":"u-001"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var result ConsentStatus
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.LatestLegalAgreementSigning.ID).To(Equal("s-0001"))
+				Expect(result.Accepted).To(BeTrue())
+				Expect(result.SignedVersion).To(BeEquivalentTo(1))
+				Expect(result.CurrentLegalAgreementID).To(Equal("a-002"))
+				Expect(result.CurrentVersion).To(BeEquivalentTo(2))
+				Expect(result.MustReconsent).To(BeTrue())
+			})
+
+			g.It("should report a revoked identity", func() {
+				// Run Create User Identity transaction
+				args := [][]byte{[]byte("createUserIdentity"), []byte(`{"userID":"u-002","legalAgreementSigningTxID":"mockTxID","verifiableCredential":"","status":"revoked"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				// Run Read Consent Status transaction
+				args = [][]byte{[]byte("readConsentStatus"), []byte(`{"userID":"u-002"}`)}
+				response = mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var result ConsentStatus
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.Status).To(Equal(ConsentStatusRevoked))
+				Expect(result.IdentityStatus).To(Equal(UserIdentityStatusRevoked))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if < 1 argument", func() {
+				// Run Read Consent Status transaction
+				args := [][]byte{[]byte("readConsentStatus")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
+			})
+
+			g.It("should return 404 if the user never signed", func() {
+				// Run Read Consent Status transaction
+				args := [][]byte{[]byte("readConsentStatus"), []byte(`{"userID":"None"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+				Expect(response.Message).To(Equal("Legal Agreement Signing for user None does not exist"))
+			})
+		})
+	})
+
+	g.Describe("List Users Requiring Reconsent", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			createLegalAgreement("a-001", "first version", 1)
+			createSigning("s-0001", "u-001", "a-001", "first version", true, 1654027900)
+			createSigning("s-0002", "u-002", "a-001", "first version", true, 1654027900)
+			createSigning("s-0003", "u-003", "a-001", "first version", true, 1654027900)
+			createLegalAgreement("a-002", "second version", 2)
+			createSigning("s-0004", "u-002", "a-002", "second version", true, 1654028000)
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should return the users who must consent again", func() {
+				createSigning("s-0005", "u-004", "a-002", "second version", false, 1654028000)
+
+				// Run List Users Requiring Reconsent transaction
+				args := [][]byte{[]byte("listUsersRequiringReconsent"), []byte(`{}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var result ListUsersRequiringReconsentResponse
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.FetchedRecordsCount).To(BeEquivalentTo(4))
+				Expect(result.Users).To(HaveLen(3))
+				Expect(result.Users[0].UserID).To(Equal("u-001"))
+				Expect(result.Users[0].Status).To(Equal(ConsentStatusOutdated))
+				Expect(result.Users[1].UserID).To(Equal("u-003"))
+				Expect(result.Users[2].UserID).To(Equal("u-004"))
+				Expect(result.Users[2].Status).To(Equal(ConsentStatusDeclined))
+				Expect(result.Bookmark).To(Equal(""))
+			})
+
+			g.It("should paginate with a bookmark", func() {
+				// Run List Users Requiring Reconsent transaction for the first page
+				args := [][]byte{[]byte("listUsersRequiringReconsent"), []byte(`{"pageSize":2}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				var page1 ListUsersRequiringReconsentResponse
+				json.Unmarshal(response.Payload, &page1)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(page1.FetchedRecordsCount).To(BeEquivalentTo(2))
+				Expect(page1.Users).To(HaveLen(1))
+				Expect(page1.Users[0].UserID).To(Equal("u-001"))
+				Expect(page1.Bookmark).To(Equal("~signer~~u-002"))
+
+				// Run List Users Requiring Reconsent transaction for the second page
+				args = [][]byte{[]byte("listUsersRequiringReconsent"), []byte(fmt.Sprintf(`{"pageSize":2,"bookmark":"%s"}`, page1.Bookmark))}
+				response = mockStub.MockInvoke("legalagreement", args)
+
+				var page2 ListUsersRequiringReconsentResponse
+				json.Unmarshal(response.Payload, &page2)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(page2.FetchedRecordsCount).To(BeEquivalentTo(1))
+				Expect(page2.Users[0].UserID).To(Equal("u-003"))
+				Expect(page2.Bookmark).To(Equal(""))
+			})
+
+			g.It("should only read the latest signing of each user", func() {
+				createSigning("s-0005", "u-001", "a-002", "second version", true, 1654028000)
+				createSigning("s-0006", "u-001", "a-002", "second version", false, 1654027950)
+
+				args := [][]byte{[]byte("listUsersRequiringReconsent"), []byte(`{}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				var result ListUsersRequiringReconsentResponse
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.FetchedRecordsCount).To(BeEquivalentTo(3))
+				Expect(result.Users).To(HaveLen(1))
+				Expect(result.Users[0].UserID).To(Equal("u-003"))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if the page size is negative", func() {
+				// Run List Users Requiring Reconsent transaction
+				args := [][]byte{[]byte("listUsersRequiringReconsent"), []byte(`{"pageSize":-1}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Invalid page size -1"))
+			})
+
+			g.It("should return 400 if the bookmark is not one of the tenant", func() {
+				args := [][]byte{[]byte("listUsersRequiringReconsent"), []byte(`{"bookmark":"~signer~acme~u-001"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Invalid bookmark ~signer~acme~u-001"))
+			})
+		})
+	})
+}
@@ -0,0 +1,378 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// grantDelegation allows a user identity to sign legal agreements on behalf of another
+func (s *SmartContract) grantDelegation(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create GrantDelegationRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request GrantDelegationRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling GrantDelegationRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Check that the identity can delegate for the user
+	if err := authorizeDelegator(stub, request.TenantID, request.UserID); err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return the original result if the request is a retry
+	replay, err := replayIdempotentRequest(stub, request.TenantID, request.IdempotencyKey, "grantDelegation", request)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if replay != nil {
+		return *replay
+	}
+
+	// Generate the ID if the request has none
+	id := request.ID
+	if id == "" {
+		contentRequest := request
+		contentRequest.IdempotencyKey = ""
+		id = generateID(stub, config.Features.IDStrategy, contentRequest)
+	}
+	if err := validateID("ID", id); err != nil {
+		return badRequest(err.Error())
+	}
+
+	// Check if delegation state using id as key exists
+	testDelegationAsBytes, err := stub.GetState(tenantKey(request.TenantID, id))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return 403 if item exists
+	if len(testDelegationAsBytes) != 0 {
+		return peer.Response{
+			Status:  409,
+			Message: fmt.Sprintf("Delegation %s already exists", id),
+		}
+	}
+
+	// Validate the relationship and the effective period
+	if request.Relationship != DelegationRelationshipGuardian && request.Relationship != DelegationRelationshipSignatory {
+		return badRequest(fmt.Sprintf("Invalid relationship %s. Expecting guardian or signatory", request.Relationship))
+	}
+	if request.EffectiveUntil != 0 && request.EffectiveUntil <= request.EffectiveFrom {
+		return badRequest(fmt.Sprintf("The effective until %d is not later than the effective from %d", request.EffectiveUntil, request.EffectiveFrom))
+	}
+
+	// Both ends of the delegation must be distinct user identities that are not revoked
+	if request.UserID == request.DelegateID {
+		return badRequest(fmt.Sprintf("User %s cannot delegate to themselves", request.UserID))
+	}
+	for _, userID := range []string{request.UserID, request.DelegateID} {
+		userIdentity, err := getUserIdentity(stub, request.TenantID, userID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if userIdentity == nil {
+			return badRequest(fmt.Sprintf("User Identity %s does not exist", userID))
+		}
+		if userIdentity.Status == UserIdentityStatusRevoked {
+			return badRequest(fmt.Sprintf("User Identity %s is revoked", userID))
+		}
+	}
+
+	// Create a new Delegation
+	newDelegation := Delegation{
+		SchemaVersion:  CurrentSchemaVersion,
+		TenantID:       request.TenantID,
+		ID:             id,
+		UserID:         request.UserID,
+		DelegateID:     request.DelegateID,
+		Relationship:   request.Relationship,
+		Status:         DelegationStatusActive,
+		EffectiveFrom:  request.EffectiveFrom,
+		EffectiveUntil: request.EffectiveUntil,
+	}
+
+	// Marshal delegation
+	delegationAsBytes, _ := MarshalCanonical(newDelegation)
+	err = stub.PutState(tenantKey(newDelegation.TenantID, newDelegation.ID), delegationAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Build the response, which is also returned to retries of the request
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		CreatedID: newDelegation.ID,
+		Document:  newDelegation,
+		Events: []Event{{
+			Name: EventDelegationGranted,
+			Payload: map[string]interface{}{
+				"tenantID":     newDelegation.TenantID,
+				"ID":           newDelegation.ID,
+				"userID":       newDelegation.UserID,
+				"delegateID":   newDelegation.DelegateID,
+				"relationship": newDelegation.Relationship,
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Record the response for retries of the request
+	err = putIdempotencyRecord(stub, request.TenantID, request.IdempotencyKey, "grantDelegation", request, newDelegation.ID, bytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	s.logger.Infof("Wrote Delegation: %s\n", newDelegation.ID)
+	return shim.Success(bytes)
+}
+
+// revokeDelegation revokes a delegation. Signings made under it before the revocation are kept.
+func (s *SmartContract) revokeDelegation(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create RevokeDelegationRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request RevokeDelegationRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling RevokeDelegationRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Return 404 if delegation does not exist
+	delegation, err := getDelegation(stub, request.TenantID, request.ID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if delegation == nil {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("Delegation %s does not exist", request.ID),
+		}
+	}
+
+	// Check that the identity can delegate for the user of the delegation
+	if err := authorizeDelegator(stub, request.TenantID, delegation.UserID); err != nil {
+		return errorResponse(err)
+	}
+
+	if delegation.Status == DelegationStatusRevoked {
+		return badRequest(fmt.Sprintf("Delegation %s is already revoked", delegation.ID))
+	}
+
+	timestamp, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	delegation.Status = DelegationStatusRevoked
+	delegation.RevokedAt = timestamp
+
+	// Marshal delegation
+	delegationAsBytes, _ := MarshalCanonical(delegation)
+	err = stub.PutState(tenantKey(delegation.TenantID, delegation.ID), delegationAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		UpdatedID: delegation.ID,
+		Document:  delegation,
+		Events: []Event{{
+			Name: EventDelegationRevoked,
+			Payload: map[string]interface{}{
+				"tenantID":   delegation.TenantID,
+				"ID":         delegation.ID,
+				"userID":     delegation.UserID,
+				"delegateID": delegation.DelegateID,
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	s.logger.Infof("Revoked Delegation: %s\n", delegation.ID)
+	return shim.Success(bytes)
+}
+
+// readDelegation returns the delegation with the given id
+func (s *SmartContract) readDelegation(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ReadDelegationRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ReadDelegationRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadDelegationRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Return 404 if delegation does not exist
+	delegation, err := getDelegation(stub, request.TenantID, request.ID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if delegation == nil {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("Delegation %s does not exist", request.ID),
+		}
+	}
+
+	delegationAsBytes, _ := MarshalCanonical(delegation)
+
+	return shim.Success(delegationAsBytes)
+}
+
+// getDelegation returns the delegation of the given tenant with the given id, or nil if it does not exist
+func getDelegation(stub shim.ChaincodeStubInterface, tenantID string, id string) (*Delegation, error) {
+	delegationAsBytes, err := stub.GetState(tenantKey(tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	if len(delegationAsBytes) == 0 || !isValidID(id) {
+		return nil, nil
+	}
+
+	var delegation Delegation
+	err = json.Unmarshal(delegationAsBytes, &delegation)
+	if err != nil {
+		if err.Error() == "Not a Delegation" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &delegation, nil
+}
+
+// getDelegations returns all delegations of the given tenant
+func getDelegations(stub shim.ChaincodeStubInterface, tenantID string) ([]Delegation, error) {
+	// Get iterator for all entries of the tenant
+	startKey, endKey := tenantRange(tenantID)
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting state iterator: %s", err)
+	}
+	defer iterator.Close()
+
+	var delegations []Delegation
+	for iterator.HasNext() {
+		// Get the next item
+		item, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Error getting next item: %s", err)
+		}
+
+		// Unmarshal item, skipping anything that is not a delegation
+		var delegation Delegation
+		err = json.Unmarshal(item.Value, &delegation)
+		if err != nil {
+			if err.Error() == "Not a Delegation" {
+				continue
+			}
+			return nil, fmt.Errorf("Error unmarshaling item: %s", err)
+		}
+
+		if delegation.TenantID == tenantID {
+			delegations = append(delegations, delegation)
+		}
+	}
+
+	return delegations, nil
+}
+
+// authorizeDelegator returns a 403 error unless the submitting identity acts as the given user or
+// as an effective guardian of them, or is an admin
+func authorizeDelegator(stub shim.ChaincodeStubInterface, tenantID string, userID string) error {
+	creatorUserID, err := getCreatorUserID(stub)
+	if err != nil {
+		return err
+	}
+	if creatorUserID != "" && creatorUserID == userID {
+		return nil
+	}
+
+	admin, err := isAdmin(stub)
+	if err != nil {
+		return err
+	}
+	if admin {
+		return nil
+	}
+
+	if creatorUserID != "" {
+		timestamp, err := getTxTimestamp(stub)
+		if err != nil {
+			return err
+		}
+		delegations, err := getDelegations(stub, tenantID)
+		if err != nil {
+			return err
+		}
+		for _, delegation := range delegations {
+			if delegation.UserID == userID && delegation.DelegateID == creatorUserID &&
+				delegation.Relationship == DelegationRelationshipGuardian && delegation.IsEffective(timestamp) {
+				return nil
+			}
+		}
+	}
+
+	return &statusError{403, fmt.Sprintf("Only user %s, their guardians and admins can change their delegations", userID)}
+}
+
+// authorizeDelegate checks that a signer may sign on behalf of a user at the given timestamp under
+// the referenced delegation, and returns the relationship to record on the signing
+func authorizeDelegate(stub shim.ChaincodeStubInterface, tenantID string, delegationID string, userID string, signerID string, timestamp int64) (*OnBehalfOf, error) {
+	if delegationID == "" {
+		return nil, &statusError{403, fmt.Sprintf("Signer %s needs a delegation to sign on behalf of user %s", signerID, userID)}
+	}
+
+	delegation, err := getDelegation(stub, tenantID, delegationID)
+	if err != nil {
+		return nil, err
+	}
+	if delegation == nil || delegation.UserID != userID || delegation.DelegateID != signerID {
+		return nil, &statusError{403, fmt.Sprintf("Delegation %s does not allow %s to sign on behalf of %s", delegationID, signerID, userID)}
+	}
+	if !delegation.IsEffective(timestamp) {
+		return nil, &statusError{403, fmt.Sprintf("Delegation %s is not effective at %d", delegationID, timestamp)}
+	}
+
+	return &OnBehalfOf{
+		SignerID:     signerID,
+		Relationship: delegation.Relationship,
+		DelegationID: delegation.ID,
+	}, nil
+}
@@ -0,0 +1,222 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+func TestDelegation(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	var admin, minor, parent, other *creatorStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	// invoke runs a transaction with the given JSON request
+	invoke := func(function string, request string) peer.Response {
+		args := [][]byte{[]byte(function), []byte(request)}
+		return mockStub.MockInvoke("legalagreement", args)
+	}
+
+	// grant runs the Grant Delegation transaction as the given identity
+	grant := func(identity *creatorStub, request string) peer.Response {
+		mockStub.MockTransactionStart(txID)
+		defer mockStub.MockTransactionEnd(txID)
+		return chaincode.grantDelegation(identity, []string{request})
+	}
+
+	// revoke runs the Revoke Delegation transaction as the given identity
+	revoke := func(identity *creatorStub, id string) peer.Response {
+		mockStub.MockTransactionStart(txID)
+		defer mockStub.MockTransactionEnd(txID)
+		return chaincode.revokeDelegation(identity, []string{fmt.Sprintf(`{"ID":"%s"}`, id)})
+	}
+
+	// sign runs the Create Legal Agreement Signing transaction for the minor as the given identity
+	sign := func(identity *creatorStub, delegationID string) peer.Response {
+		contentHash, _ := ComputeContentHash("first version", DefaultHashAlgorithm)
+		request := LegalAgreementSigningRequest{
+			ID:                        "0001",
+			UserID:                    "minor",
+			LegalAgreementID:          "001",
+			LegalAgreementContentHash: contentHash,
+			Accepted:                  true,
+			Timestamp:                 1654027900,
+			DelegationID:              delegationID,
+		}
+		requestAsBytes, _ := json.Marshal(request)
+
+		mockStub.MockTransactionStart(txID)
+		defer mockStub.MockTransactionEnd(txID)
+		return chaincode.createLegalAgreementSigning(identity, []string{string(requestAsBytes)})
+	}
+
+	g.Describe("Delegations", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+			initAs(chaincode, mockStub, "Org1MSP")
+			admin, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "admin"})
+			minor, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"userID": "minor"})
+			parent, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"userID": "parent"})
+			other, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"userID": "other"})
+
+			Expect(invoke("createLegalAgreement", `{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`).Status).To(BeEquivalentTo(200))
+			for _, userID := range []string{"minor", "parent", "other"} {
+				Expect(invoke("createUserIdentity", fmt.Sprintf(`{"userID":"%s"}`, userID)).Status).To(BeEquivalentTo(200))
+			}
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should record the delegate of a signing made on behalf of the user", func() {
+				response := grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"guardian"}`)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				response = sign(parent, "d-001")
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				// Retrieve results from ledger
+				bytes, _ := mockStub.GetState("0001")
+				var result LegalAgreementSigning
+				json.Unmarshal(bytes, &result)
+
+				Expect(result.UserID).To(Equal("minor"))
+				Expect(result.OnBehalfOf).To(Equal(&OnBehalfOf{SignerID: "parent", Relationship: DelegationRelationshipGuardian, DelegationID: "d-001"}))
+			})
+
+			g.It("should not record a delegate when the user signs", func() {
+				response := sign(minor, "")
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				bytes, _ := mockStub.GetState("0001")
+				var result LegalAgreementSigning
+				json.Unmarshal(bytes, &result)
+
+				Expect(result.OnBehalfOf).To(BeNil())
+			})
+
+			g.It("should not record a delegate when an identity without a user signs", func() {
+				service, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "admin"})
+
+				response := sign(service, "")
+				Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+				bytes, _ := mockStub.GetState("0001")
+				var result LegalAgreementSigning
+				json.Unmarshal(bytes, &result)
+
+				Expect(result.OnBehalfOf).To(BeNil())
+			})
+
+			g.It("should let a guardian and an admin grant delegations for the user", func() {
+				Expect(grant(admin, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"guardian"}`).Status).To(BeEquivalentTo(200))
+
+				response := grant(parent, `{"ID":"d-002","userID":"minor","delegateID":"other","relationship":"signatory"}`)
+				Expect(response.Status).To(BeEquivalentTo(200))
+			})
+
+			g.It("should revoke the delegation", func() {
+				grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"guardian"}`)
+
+				response := revoke(minor, "d-001")
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				response = invoke("readDelegation", `{"ID":"d-001"}`)
+				var result Delegation
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(result.Status).To(Equal(DelegationStatusRevoked))
+				Expect(result.RevokedAt).NotTo(BeZero())
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 403 if the signer has no delegation", func() {
+				response := sign(parent, "")
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Signer parent needs a delegation to sign on behalf of user minor"))
+			})
+
+			g.It("should return 403 if the delegation was granted to someone else", func() {
+				grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"guardian"}`)
+
+				response := sign(other, "d-001")
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Delegation d-001 does not allow other to sign on behalf of minor"))
+			})
+
+			g.It("should return 403 if the delegation is revoked or expired", func() {
+				grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"guardian"}`)
+				revoke(minor, "d-001")
+				grant(minor, `{"ID":"d-002","userID":"minor","delegateID":"parent","relationship":"guardian","effectiveFrom":1,"effectiveUntil":1000}`)
+
+				for _, delegationID := range []string{"d-001", "d-002"} {
+					response := sign(parent, delegationID)
+
+					Expect(response.Status).To(BeEquivalentTo(403))
+					Expect(response.Message).To(HavePrefix("Delegation " + delegationID + " is not effective at"))
+				}
+			})
+
+			g.It("should return 403 if a stranger grants a delegation for the user", func() {
+				response := grant(other, `{"ID":"d-001","userID":"minor","delegateID":"other","relationship":"guardian"}`)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only user minor, their guardians and admins can change their delegations"))
+			})
+
+			g.It("should return 403 if a stranger revokes a delegation of the user", func() {
+				grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"guardian"}`)
+
+				response := revoke(other, "d-001")
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only user minor, their guardians and admins can change their delegations"))
+			})
+
+			g.It("should return an error if the relationship is unknown", func() {
+				response := grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"parent","relationship":"friend"}`)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Invalid relationship friend. Expecting guardian or signatory"))
+			})
+
+			g.It("should return an error if a user identity does not exist", func() {
+				response := grant(minor, `{"ID":"d-001","userID":"minor","delegateID":"stranger","relationship":"guardian"}`)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("User Identity stranger does not exist"))
+			})
+
+			g.It("should return 404 if the delegation to revoke does not exist", func() {
+				response := revoke(admin, "d-404")
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+			})
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package lglagrmt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// generateID returns an ID for a record created without one. It is derived from the transaction ID,
+// or from the content of the request if the strategy says so, so that every endorsing peer
+// generates the same ID.
+func generateID(stub shim.ChaincodeStubInterface, strategy string, request interface{}) string {
+	seed := stub.GetTxID()
+	if strategy == IDStrategyContent {
+		seed = requestHash(request)
+	}
+
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:16])
+}
+
+// isValidID reports whether an ID can be used in the key of a record. IDs cannot contain the tenant
+// key separator, or a record of the default tenant could be read or written in the range of another tenant.
+func isValidID(id string) bool {
+	return id != "" && !strings.Contains(id, tenantKeySeparator)
+}
+
+// validateID returns an error naming the kind of ID if it cannot be used in the key of a record
+func validateID(kind string, id string) error {
+	if !isValidID(id) {
+		return fmt.Errorf("Invalid %s %s", kind, id)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestID(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	g.Describe("Generated IDs", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should derive the ID from the transaction ID", func() {
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"content":"first version","timestamp":1654027884,"version":1}`)}
+				response := mockStub.MockInvoke("tx1", args)
+
+				var results map[string]interface{}
+				json.Unmarshal(response.Payload, &results)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(results["createdID"]).To(HaveLen(32))
+				Expect(results["txID"]).To(Equal("tx1"))
+
+				// Retrieve results from ledger
+				bytes, _ := mockStub.GetState(results["createdID"].(string))
+				var result LegalAgreement
+				json.Unmarshal(bytes, &result)
+
+				Expect(result.ID).To(Equal(results["createdID"]))
+			})
+
+			g.It("should derive the ID from the content if configured", func() {
+				mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"adminMSPs":["Org1MSP"]},"features":{"idStrategy":"content"}}`)})
+
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"content":"first version","timestamp":1654027884,"version":1}`)}
+				response := mockStub.MockInvoke("tx1", args)
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				var results map[string]interface{}
+				json.Unmarshal(response.Payload, &results)
+
+				// The same content in another transaction gets the same ID
+				response = mockStub.MockInvoke("tx2", args)
+
+				Expect(response.Status).To(BeEquivalentTo(409))
+				Expect(response.Message).To(Equal("Legal Agreement " + results["createdID"].(string) + " already exists"))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if the ID contains the tenant key separator", func() {
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"acme~001","content":"first version","timestamp":1654027884,"version":1}`)}
+				response := mockStub.MockInvoke("tx1", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Invalid ID acme~001"))
+			})
+
+			g.It("should return 404 when reading the key of another tenant", func() {
+				mockStub.MockTransactionStart(txID)
+				mockStub.PutState("acme~001", []byte(`{"tenantID":"acme","ID":"001","content":"acme terms","version":1}`))
+				mockStub.MockTransactionEnd(txID)
+
+				args := [][]byte{[]byte("readLegalAgreement"), []byte(`{"ID":"acme~001"}`)}
+				response := mockStub.MockInvoke("tx1", args)
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+			})
+
+			g.It("should return an error if the ID strategy is unknown", func() {
+				response := mockStub.MockInit(txID, [][]byte{[]byte("init"), []byte(`{"roles":{"adminMSPs":["Org1MSP"]},"features":{"idStrategy":"random"}}`)})
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Invalid ID strategy random. Expecting txID or content"))
+			})
+		})
+	})
+}
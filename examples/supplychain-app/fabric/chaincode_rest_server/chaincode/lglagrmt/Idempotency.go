@@ -0,0 +1,101 @@
+package lglagrmt
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// replayIdempotentRequest returns the original result of a create request when it is a retry of a
+// request already submitted with the same idempotency key, or nil if the key was not used yet.
+// It fails if the key was used with a different request. The result is replayed before the checks
+// of the transaction, so it is only returned to the identity that submitted the request.
+func replayIdempotentRequest(stub shim.ChaincodeStubInterface, tenantID string, idempotencyKey string, function string, request interface{}) (*peer.Response, error) {
+	if idempotencyKey == "" {
+		return nil, nil
+	}
+
+	recordAsBytes, err := stub.GetState(idempotencyKeyKey(tenantID, idempotencyKey))
+	if err != nil {
+		return nil, err
+	}
+	if len(recordAsBytes) == 0 {
+		return nil, nil
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(recordAsBytes, &record); err != nil {
+		return nil, fmt.Errorf("Error unmarshaling IdempotencyRecord: %s", err)
+	}
+
+	if record.Function != function || record.RequestHash != requestHash(request) {
+		return nil, &statusError{409, fmt.Sprintf("Idempotency key %s was already used with a different request", idempotencyKey)}
+	}
+
+	creatorHash, err := getCreatorHash(stub)
+	if err != nil {
+		return nil, err
+	}
+	if record.CreatorHash != creatorHash {
+		return nil, &statusError{403, fmt.Sprintf("Idempotency key %s was used by another identity", idempotencyKey)}
+	}
+
+	success := shim.Success(record.Response)
+	return &success, nil
+}
+
+// putIdempotencyRecord records the response of a create request submitted with an idempotency key
+func putIdempotencyRecord(stub shim.ChaincodeStubInterface, tenantID string, idempotencyKey string, function string, request interface{}, createdID string, response []byte) error {
+	if idempotencyKey == "" {
+		return nil
+	}
+
+	creatorHash, err := getCreatorHash(stub)
+	if err != nil {
+		return err
+	}
+
+	record := IdempotencyRecord{
+		SchemaVersion:  CurrentSchemaVersion,
+		TenantID:       tenantID,
+		IdempotencyKey: idempotencyKey,
+		Function:       function,
+		RequestHash:    requestHash(request),
+		CreatorHash:    creatorHash,
+		CreatedID:      createdID,
+		TxID:           stub.GetTxID(),
+		Response:       response,
+	}
+
+	recordAsBytes, _ := MarshalCanonical(record)
+	return stub.PutState(idempotencyKeyKey(tenantID, idempotencyKey), recordAsBytes)
+}
+
+// requestHash returns the hex encoded SHA-256 digest of the canonical JSON of a request, so that
+// the formatting of the submitted JSON does not change the digest
+func requestHash(request interface{}) string {
+	requestAsBytes, _ := MarshalCanonical(request)
+	return fmt.Sprintf("%x", sha256.Sum256(requestAsBytes))
+}
+
+// getCreatorHash returns the hex encoded SHA-256 digest of the serialized identity that submitted
+// the transaction
+func getCreatorHash(stub shim.ChaincodeStubInterface) (string, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return "", fmt.Errorf("Error getting the creator: %s", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(creator)), nil
+}
+
+// idempotencyKeyKey returns the key of the record of an idempotency key of the given tenant. It
+// starts with the separator, which tenant IDs cannot contain, so it never falls within the range
+// of a tenant.
+func idempotencyKeyKey(tenantID string, idempotencyKey string) string {
+	return tenantKeySeparator + "idempotency" + tenantKeySeparator + tenantID + tenantKeySeparator + idempotencyKey
+}
@@ -0,0 +1,129 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestIdempotency(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	g.Describe("Create Legal Agreement Signing with an idempotency key", func() {
+		var request LegalAgreementSigningRequest
+
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)}
+			response := mockStub.MockInvoke("tx0", args)
+			Expect(response.Status).To(BeEquivalentTo(200))
+
+			contentHash, _ := ComputeContentHash("first version", DefaultHashAlgorithm)
+			request = LegalAgreementSigningRequest{
+				ID:                        "0001",
+				UserID:                    "u-001",
+				LegalAgreementID:          "001",
+				LegalAgreementContentHash: contentHash,
+				Accepted:                  true,
+				Timestamp:                 1654027900,
+				IdempotencyKey:            "retry-0001",
+			}
+			requestAsBytes, _ := json.Marshal(request)
+
+			args = [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+			response = mockStub.MockInvoke("tx1", args)
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should return the original result when retried", func() {
+				// Retry with the same payload, formatted differently
+				args := [][]byte{[]byte("createLegalAgreementSigning"), []byte(`{
+					"idempotencyKey": "retry-0001",
+					"ID": "0001",
+					"userID": "u-001",
+					"legalAgreementID": "001",
+					"legalAgreementContentHash": "` + request.LegalAgreementContentHash + `",
+					"accepted": true,
+					"timestamp": 1654027900
+				}`)}
+				response := mockStub.MockInvoke("tx2", args)
+
+				var results map[string]interface{}
+				json.Unmarshal(response.Payload, &results)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(results["createdID"]).To(Equal("0001"))
+				Expect(results["txID"]).To(Equal("tx1"))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 409 if the key is reused with a different payload", func() {
+				request.Accepted = false
+				requestAsBytes, _ := json.Marshal(request)
+
+				args := [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+				response := mockStub.MockInvoke("tx2", args)
+
+				Expect(response.Status).To(BeEquivalentTo(409))
+				Expect(response.Message).To(Equal("Idempotency key retry-0001 was already used with a different request"))
+			})
+
+			g.It("should return 409 if the key is reused by another transaction", func() {
+				args := [][]byte{[]byte("createUserIdentity"), []byte(`{"userID":"u-001","legalAgreementSigningTxID":"tx1","idempotencyKey":"retry-0001"}`)}
+				response := mockStub.MockInvoke("tx2", args)
+
+				Expect(response.Status).To(BeEquivalentTo(409))
+				Expect(response.Message).To(Equal("Idempotency key retry-0001 was already used with a different request"))
+			})
+
+			g.It("should return 403 if the key is reused by another identity", func() {
+				other, _ := newIdentityStub(mockStub, "Org1MSP", map[string]string{"userID": "u-001"})
+				requestAsBytes, _ := json.Marshal(request)
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.createLegalAgreementSigning(other, []string{string(requestAsBytes)})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Idempotency key retry-0001 was used by another identity"))
+			})
+
+			g.It("should return 409 on a retry without the key", func() {
+				request.IdempotencyKey = ""
+				requestAsBytes, _ := json.Marshal(request)
+
+				args := [][]byte{[]byte("createLegalAgreementSigning"), requestAsBytes}
+				response := mockStub.MockInvoke("tx2", args)
+
+				Expect(response.Status).To(BeEquivalentTo(409))
+				Expect(response.Message).To(Equal("Legal Agreement Signing 0001 already exists"))
+			})
+		})
+	})
+}
@@ -0,0 +1,180 @@
+package lglagrmt
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	. "github.com/chaincode/common"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+	"github.com/hyperledger/fabric/protos/msp"
+)
+
+// getCreatorMSPID returns the MSP ID of the identity that submitted the transaction.
+// It is empty when the transaction carries no creator, as with the mock stub.
+func getCreatorMSPID(stub shim.ChaincodeStubInterface) (string, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return "", fmt.Errorf("Error getting creator: %s", err)
+	}
+
+	var serializedIdentity msp.SerializedIdentity
+	if err := proto.Unmarshal(creator, &serializedIdentity); err != nil {
+		return "", fmt.Errorf("Error unmarshaling creator: %s", err)
+	}
+
+	return serializedIdentity.Mspid, nil
+}
+
+// getCreatorAttribute returns the value of an attribute of the identity that submitted the
+// transaction. Identities without an X.509 certificate, as with the mock stub, have no attributes.
+func getCreatorAttribute(stub shim.ChaincodeStubInterface, name string) (string, bool, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return "", false, fmt.Errorf("Error getting creator: %s", err)
+	}
+
+	var serializedIdentity msp.SerializedIdentity
+	if err := proto.Unmarshal(creator, &serializedIdentity); err != nil {
+		return "", false, fmt.Errorf("Error unmarshaling creator: %s", err)
+	}
+	if len(serializedIdentity.IdBytes) == 0 {
+		return "", false, nil
+	}
+
+	value, found, err := cid.GetAttributeValue(stub, name)
+	if err != nil {
+		return "", false, fmt.Errorf("Error getting attribute %s: %s", name, err)
+	}
+
+	return value, found, nil
+}
+
+// getCreatorUserID returns the userID attribute of the identity that submitted the transaction,
+// which is the user it acts as. It is empty for identities that do not act as a user, such as
+// service and org identities, and when the transaction carries no certificate, as with the mock stub.
+func getCreatorUserID(stub shim.ChaincodeStubInterface) (string, error) {
+	userID, _, err := getCreatorAttribute(stub, AttributeUserID)
+	return userID, err
+}
+
+// isAdmin reports whether the identity that submitted the transaction has the admin role of the
+// configuration and belongs to one of its admin MSPs
+func isAdmin(stub shim.ChaincodeStubInterface) (bool, error) {
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return false, err
+	}
+
+	role, _, err := getCreatorAttribute(stub, AttributeRole)
+	if err != nil {
+		return false, err
+	}
+	if role != config.Roles.Admin {
+		return false, nil
+	}
+
+	creatorMSP, err := getCreatorMSPID(stub)
+	if err != nil {
+		return false, err
+	}
+	return containsString(config.Roles.AdminMSPs, creatorMSP), nil
+}
+
+// authorizeAdmin returns a 403 error naming the action unless the submitting identity is an admin
+func authorizeAdmin(stub shim.ChaincodeStubInterface, action string) error {
+	admin, err := isAdmin(stub)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return &statusError{403, fmt.Sprintf("Only admins can %s", action)}
+	}
+	return nil
+}
+
+// isOwner reports whether the identity that submitted the transaction can change the legal
+// agreements owned by the given org, and returns the MSP ID of that identity. Legal agreements
+// recorded before they had an owner can only be changed by admins.
+func isOwner(stub shim.ChaincodeStubInterface, ownerMSP string) (string, bool, error) {
+	creatorMSP, err := getCreatorMSPID(stub)
+	if err != nil {
+		return "", false, err
+	}
+	if ownerMSP == creatorMSP {
+		return creatorMSP, true, nil
+	}
+	if ownerMSP != "" {
+		return creatorMSP, false, nil
+	}
+
+	admin, err := isAdmin(stub)
+	if err != nil {
+		return "", false, err
+	}
+	return creatorMSP, admin, nil
+}
+
+// verifyCreatorSignature checks a base64 encoded ASN.1 ECDSA signature over the SHA-256 digest of
+// the payload against the X.509 certificate of the identity that submitted the transaction
+func verifyCreatorSignature(stub shim.ChaincodeStubInterface, payload []byte, signature string) error {
+	if signature == "" {
+		return errors.New("signature is required")
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("signature is not base64 encoded: %s", err)
+	}
+
+	var ecdsaSignature struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(signatureBytes, &ecdsaSignature); err != nil {
+		return fmt.Errorf("signature is not an ASN.1 ECDSA signature: %s", err)
+	}
+
+	certificate, err := cid.GetX509Certificate(stub)
+	if err != nil {
+		return fmt.Errorf("identity has no certificate: %s", err)
+	}
+
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("identity does not have an ECDSA key")
+	}
+
+	digest := sha256.Sum256(payload)
+	if !ecdsa.Verify(publicKey, digest[:], ecdsaSignature.R, ecdsaSignature.S) {
+		return errors.New("signature does not match")
+	}
+
+	return nil
+}
+
+// setOrgEndorsementPolicy requires the endorsement of the given org for any later change to the key
+func setOrgEndorsementPolicy(stub shim.ChaincodeStubInterface, key string, mspID string) error {
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return err
+	}
+
+	if err := endorsementPolicy.AddOrgs(statebased.RoleTypeMember, mspID); err != nil {
+		return err
+	}
+
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return err
+	}
+
+	return stub.SetStateValidationParameter(key, policy)
+}
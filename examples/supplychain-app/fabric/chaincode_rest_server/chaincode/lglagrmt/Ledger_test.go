@@ -0,0 +1,111 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+// TestLedger runs the contract against the in-memory ledger of stubtest, which commits writes and
+// events like a peer does
+func TestLedger(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var ledger *stubtest.Stub
+	var admin, user *stubtest.Identity
+	chaincode := new(SmartContract)
+
+	// invoke runs a transaction as the given identity
+	invoke := func(identity *stubtest.Identity, txID string, function string, request string) peer.Response {
+		ledger.SetCreator(identity)
+		return ledger.Invoke(txID, [][]byte{[]byte(function), []byte(request)})
+	}
+
+	g.Describe("Ledger", func() {
+		g.BeforeEach(func() {
+			ledger = stubtest.New("legalagreement", chaincode)
+			admin, _ = stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			user, _ = stubtest.NewIdentity("Org1MSP", "user", map[string]string{"role": "user", "tenantID": "acme"})
+
+			ledger.SetCreator(admin)
+			response := ledger.Init("tx0", [][]byte{[]byte("init")})
+			chaincode.logger.SetLevel(shim.LogError)
+			Expect(response.Status).To(BeEquivalentTo(200))
+
+			response = invoke(admin, "tx1", "onboardTenant", `{"tenantID":"acme","name":"Acme","allowedRoles":["user"]}`)
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+
+		g.It("should record the history of a legal agreement", func() {
+			invoke(user, "tx2", "createLegalAgreement", `{"tenantID":"acme","ID":"001","content":"first version","timestamp":1654027884,"version":1,"status":"draft"}`)
+			response := invoke(user, "tx3", "updateLegalAgreementStatus", `{"tenantID":"acme","ID":"001","status":"published"}`)
+			Expect(response.Status).To(BeEquivalentTo(200))
+
+			iterator, _ := ledger.GetHistoryForKey("acme~001")
+			var statuses []string
+			for iterator.HasNext() {
+				modification, _ := iterator.Next()
+				var legalAgreement LegalAgreement
+				json.Unmarshal(modification.Value, &legalAgreement)
+				statuses = append(statuses, legalAgreement.Status)
+			}
+
+			Expect(statuses).To(Equal([]string{LegalAgreementStatusPublished, LegalAgreementStatusDraft}))
+		})
+
+		g.It("should supersede the previous version and emit one event per transaction", func() {
+			invoke(user, "tx2", "createLegalAgreement", `{"tenantID":"acme","ID":"001","content":"first version","timestamp":1654027884,"version":1}`)
+			response := invoke(user, "tx3", "createLegalAgreement", `{"tenantID":"acme","ID":"002","content":"second version","timestamp":1654028933,"version":2}`)
+			Expect(response.Status).To(BeEquivalentTo(200))
+
+			bytes, _ := ledger.GetState("acme~001")
+			var legalAgreement LegalAgreement
+			json.Unmarshal(bytes, &legalAgreement)
+			Expect(legalAgreement.Status).To(Equal(LegalAgreementStatusSuperseded))
+
+			events := ledger.Events()
+			Expect(events).To(HaveLen(3))
+			Expect(events[2].EventName).To(Equal(EventLegalAgreementCreated))
+			Expect(events[2].TxId).To(Equal("tx3"))
+		})
+
+		g.It("should not commit anything for a rejected transaction", func() {
+			response := invoke(user, "tx2", "createLegalAgreement", `{"tenantID":"other","ID":"001","content":"first version","timestamp":1654027884,"version":1}`)
+
+			Expect(response.Status).To(BeEquivalentTo(403))
+			bytes, _ := ledger.GetState("other~001")
+			Expect(bytes).To(BeNil())
+			Expect(ledger.Events()).To(HaveLen(1))
+		})
+
+		g.It("should leave legal agreements recorded without an owner to admins", func() {
+			state := ledger.State()
+			state["acme~001"] = []byte(`{"schemaVersion":2,"tenantID":"acme","ID":"001","content":"first version","timestamp":1654027884,"version":1,"status":"published"}`)
+			ledger.Load(state)
+
+			response := invoke(user, "tx2", "createLegalAgreement", `{"tenantID":"acme","ID":"002","content":"second version","timestamp":1654028933,"version":2}`)
+			Expect(response.Status).To(BeEquivalentTo(403))
+			Expect(response.Message).To(Equal("Legal Agreement versions have no owner and can only be created by admins"))
+
+			response = invoke(user, "tx3", "updateLegalAgreementStatus", `{"tenantID":"acme","ID":"001","status":"retired"}`)
+			Expect(response.Status).To(BeEquivalentTo(403))
+			Expect(response.Message).To(Equal("Legal Agreement 001 has no owner and can only be changed by admins"))
+
+			response = invoke(admin, "tx4", "createLegalAgreement", `{"tenantID":"acme","ID":"002","content":"second version","timestamp":1654028933,"version":2}`)
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			bytes, _ := ledger.GetState("acme~002")
+			var legalAgreement LegalAgreement
+			json.Unmarshal(bytes, &legalAgreement)
+			Expect(legalAgreement.OwnerMSP).To(Equal("Org1MSP"))
+		})
+	})
+}
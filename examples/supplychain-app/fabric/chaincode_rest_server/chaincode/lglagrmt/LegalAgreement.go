@@ -1,7 +1,6 @@
 package lglagrmt
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 
@@ -14,18 +13,48 @@ import (
 // createLegalAgreement creates an legal agreement in the ledger
 func (s *SmartContract) createLegalAgreement(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create LegalAgreementRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request LegalAgreementRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling LegalAgreementRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling LegalAgreementRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return the original result if the request is a retry
+	replay, err := replayIdempotentRequest(stub, request.TenantID, request.IdempotencyKey, "createLegalAgreement", request)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if replay != nil {
+		return *replay
+	}
+
+	// Generate the ID if the request has none
+	id := request.ID
+	if id == "" {
+		contentRequest := request
+		contentRequest.IdempotencyKey = ""
+		id = generateID(stub, config.Features.IDStrategy, contentRequest)
+	}
+	if err := validateID("ID", id); err != nil {
+		return badRequest(err.Error())
 	}
 
 	// Check if legal agreement state using id as key exists
-	testLegalAgreementAsBytes, err := stub.GetState(request.ID)
+	testLegalAgreementAsBytes, err := stub.GetState(tenantKey(request.TenantID, id))
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -33,50 +62,160 @@ func (s *SmartContract) createLegalAgreement(stub shim.ChaincodeStubInterface, a
 	// Return 403 if item exists
 	if len(testLegalAgreementAsBytes) != 0 {
 		return peer.Response{
-			Status:  403,
-			Message: fmt.Sprintf("Legal Agreement %s already exists", request.ID),
+			Status:  409,
+			Message: fmt.Sprintf("Legal Agreement %s already exists", id),
 		}
 	}
 
-	ContentHash := sha256.Sum256([]byte(request.Content))
+	// Hash the canonical form of the content so that line endings, trailing
+	// whitespace and Unicode normalization do not change the digest
+	hashAlgorithm := request.HashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = DefaultHashAlgorithm
+	}
+	contentHash, err := ComputeContentHash(request.Content, hashAlgorithm)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	// Validate the length of the canonical content
+	content := CanonicalizeContent(request.Content)
+	if config.Limits.MaxContentLength > 0 && len(content) > config.Limits.MaxContentLength {
+		return badRequest(fmt.Sprintf("The content exceeds the maximum length of %d bytes", config.Limits.MaxContentLength))
+	}
+
+	// New legal agreements are published unless they are staged as draft or review
+	status := request.Status
+	if status == "" {
+		status = LegalAgreementStatusPublished
+	}
+	if status != LegalAgreementStatusDraft && status != LegalAgreementStatusReview && status != LegalAgreementStatusPublished {
+		return badRequest(fmt.Sprintf("Invalid initial status %s. Expecting draft, review or published", status))
+	}
 
-	// Call readLatestVersionLegalAgreement to get the latest version
-	latestVersionLegalAgreementAsBytes := s.readLatestVersionLegalAgreement(stub, []string{})
-	if latestVersionLegalAgreementAsBytes.Status != 200 {
-		return shim.Error(fmt.Sprintf("Failed to read latest version legal agreement: %s", latestVersionLegalAgreementAsBytes.Message))
+	// Validate the effective period
+	if request.EffectiveUntil != 0 && request.EffectiveUntil <= request.EffectiveFrom {
+		return badRequest(fmt.Sprintf("The effective until %d is not later than the effective from %d", request.EffectiveUntil, request.EffectiveFrom))
 	}
 
-	var latestVersionLegalAgreement LegalAgreement
-	err = json.Unmarshal(latestVersionLegalAgreementAsBytes.Payload, &latestVersionLegalAgreement)
+	// Validate the consent scopes
+	if err := validateScopes(request.Scopes); err != nil {
+		return badRequest(err.Error())
+	}
+	scopes := request.Scopes
+	if scopes == nil {
+		scopes = []ConsentScope{}
+	}
+
+	// Validate the parties that must sign a multi-party legal agreement
+	if err := validateParties(request.Parties); err != nil {
+		return badRequest(err.Error())
+	}
+	parties := request.Parties
+	if parties == nil {
+		parties = []Party{}
+	}
+
+	// Get all legal agreements to find the latest version in any state
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
+	latestVersionLegalAgreement := latestVersion(legalAgreements, nil)
 
 	// Validate that the version is a greater than the previous version
 	if latestVersionLegalAgreement.Version >= request.Version {
-		return shim.Error(fmt.Sprintf("The version %d is not greater than the latest version %d", request.Version, latestVersionLegalAgreement.Version))
+		return badRequest(fmt.Sprintf("The version %d is not greater than the latest version %d", request.Version, latestVersionLegalAgreement.Version))
+	}
+	if config.Features.SequentialVersions && request.Version != latestVersionLegalAgreement.Version+1 {
+		return badRequest(fmt.Sprintf("The version %d does not follow the latest version %d", request.Version, latestVersionLegalAgreement.Version))
+	}
+
+	// Only the org that owns the legal agreement can publish new versions of it. The new version
+	// is owned by the org that submits it, so an admin gives an owner to versions that have none.
+	ownerMSP, owner, err := isOwner(stub, latestVersionLegalAgreement.OwnerMSP)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if latestVersionLegalAgreement.ID != "" && !owner && latestVersionLegalAgreement.OwnerMSP == "" {
+		return peer.Response{
+			Status:  403,
+			Message: "Legal Agreement versions have no owner and can only be created by admins",
+		}
+	}
+	if latestVersionLegalAgreement.ID != "" && !owner {
+		return peer.Response{
+			Status:  403,
+			Message: fmt.Sprintf("Legal Agreement versions are owned by %s", latestVersionLegalAgreement.OwnerMSP),
+		}
 	}
 
 	// Create a new LegalAgreement
 	newLegalAgreement := LegalAgreement{
-		ID:          request.ID,
-		Content:     request.Content,
-		ContentHash: fmt.Sprintf("%x", ContentHash),
-		Timestamp:   request.Timestamp,
-		Version:     request.Version,
+		SchemaVersion:  CurrentSchemaVersion,
+		TenantID:       request.TenantID,
+		ID:             id,
+		Content:        content,
+		ContentHash:    contentHash,
+		HashAlgorithm:  hashAlgorithm,
+		OwnerMSP:       ownerMSP,
+		Status:         status,
+		Timestamp:      request.Timestamp,
+		Version:        request.Version,
+		EffectiveFrom:  request.EffectiveFrom,
+		EffectiveUntil: request.EffectiveUntil,
+		Scopes:         scopes,
+		Parties:        parties,
+	}
+
+	// Publishing a new version supersedes the previously published one
+	supersededIDs := []string{}
+	if status == LegalAgreementStatusPublished {
+		supersededIDs, err = supersedeLegalAgreements(stub, legalAgreements, newLegalAgreement)
+		if err != nil {
+			return errorResponse(err)
+		}
 	}
 
 	// Marshal legal agreement
-	legalAgreementAsBytes, _ := json.Marshal(newLegalAgreement)
-	err = stub.PutState(newLegalAgreement.ID, legalAgreementAsBytes)
+	legalAgreementAsBytes, _ := MarshalCanonical(newLegalAgreement)
+	err = stub.PutState(tenantKey(newLegalAgreement.TenantID, newLegalAgreement.ID), legalAgreementAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Require the owning org to endorse any later change to the legal agreement
+	if ownerMSP != "" && config.Features.OrgEndorsementPolicy {
+		err = setOrgEndorsementPolicy(stub, tenantKey(newLegalAgreement.TenantID, newLegalAgreement.ID), ownerMSP)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// Build the response, which is also returned to retries of the request
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		CreatedID: newLegalAgreement.ID,
+		Document:  newLegalAgreement,
+		Events: []Event{{
+			Name: EventLegalAgreementCreated,
+			Payload: map[string]interface{}{
+				"tenantID":      newLegalAgreement.TenantID,
+				"ID":            newLegalAgreement.ID,
+				"version":       newLegalAgreement.Version,
+				"status":        newLegalAgreement.Status,
+				"supersededIDs": supersededIDs,
+			},
+		}},
+	})
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	response := map[string]interface{}{
-		"createdID": newLegalAgreement.ID,
+	// Record the response for retries of the request
+	err = putIdempotencyRecord(stub, request.TenantID, request.IdempotencyKey, "createLegalAgreement", request, newLegalAgreement.ID, bytes)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
-	bytes, _ := json.Marshal(response)
 
 	s.logger.Infof("Wrote Legal Agreement: %s\n", newLegalAgreement.ID)
 	return shim.Success(bytes)
@@ -85,24 +224,29 @@ func (s *SmartContract) createLegalAgreement(stub shim.ChaincodeStubInterface, a
 // readLegalAgreement returns the legal agreement with the given id
 func (s *SmartContract) readLegalAgreement(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create ReadLegalAgreementRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request ReadLegalAgreementRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling ReadLegalAgreementRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadLegalAgreementRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
 	}
 
 	// Get the legal agreement state from the ledger
-	legalAgreementAsBytes, err := stub.GetState(request.ID)
+	legalAgreementAsBytes, err := stub.GetState(tenantKey(request.TenantID, request.ID))
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// Return 404 if legal agreement does not exist
-	if len(legalAgreementAsBytes) == 0 {
+	if len(legalAgreementAsBytes) == 0 || !isValidID(request.ID) {
 		return peer.Response{
 			Status:  404,
 			Message: fmt.Sprintf("Legal Agreement %s does not exist", request.ID),
@@ -119,46 +263,205 @@ func (s *SmartContract) readLegalAgreement(stub shim.ChaincodeStubInterface, arg
 		}
 	}
 
-	return shim.Success(legalAgreementAsBytes)
+	return storedDocumentResponse(legalAgreementAsBytes, "Legal Agreement")
 }
 
-// readLatestVersionLegalAgreement returns the latest version of the legal agreement
+// readLatestVersionLegalAgreement returns the latest published version of the legal agreement
 func (s *SmartContract) readLatestVersionLegalAgreement(stub shim.ChaincodeStubInterface, args []string) peer.Response {
-	if len(args) != 0 {
-		return shim.Error("Incorrect number of arguments. Expecting 0")
+	if len(args) > 1 {
+		return badRequest("Incorrect number of arguments. Expecting 0 or 1")
 	}
 
-	// Get iterator for all entries
-	iterator, err := stub.GetStateByRange("", "")
+	// Create ReadLatestVersionLegalAgreementRequest struct from input JSON
+	var request ReadLatestVersionLegalAgreementRequest
+	if len(args) == 1 {
+		argBytes := []byte(args[0])
+		if err := json.Unmarshal(argBytes, &request); err != nil {
+			return badRequest(fmt.Sprintf("Error unmarshaling ReadLatestVersionLegalAgreementRequest: %s", err))
+		}
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Get all legal agreements
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
 	if err != nil {
-		shim.Error(fmt.Sprintf("Error getting state iterator: %s", err))
+		return shim.Error(err.Error())
+	}
+
+	// Get the latest published version
+	latestLegalAgreement := latestVersion(legalAgreements, func(legalAgreement LegalAgreement) bool {
+		return legalAgreement.CurrentStatus() == LegalAgreementStatusPublished
+	})
+
+	// Marshal latest version
+	legalAgreementAsBytes, _ := MarshalCanonical(latestLegalAgreement)
+
+	return shim.Success(legalAgreementAsBytes)
+}
+
+// readEffectiveLegalAgreement returns the legal agreement in force at the transaction timestamp,
+// or at the given as-of time
+func (s *SmartContract) readEffectiveLegalAgreement(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) > 1 {
+		return badRequest("Incorrect number of arguments. Expecting 0 or 1")
+	}
+
+	// Create ReadEffectiveLegalAgreementRequest struct from input JSON
+	var request ReadEffectiveLegalAgreementRequest
+	if len(args) == 1 {
+		argBytes := []byte(args[0])
+		if err := json.Unmarshal(argBytes, &request); err != nil {
+			return badRequest(fmt.Sprintf("Error unmarshaling ReadEffectiveLegalAgreementRequest: %s", err))
+		}
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Default to the transaction timestamp
+	asOf, err := asOfOrTxTimestamp(stub, request.AsOf)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Get all legal agreements
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return 404 if no legal agreement is in force
+	effectiveLegalAgreement := effectiveVersion(legalAgreements, asOf)
+	if len(effectiveLegalAgreement.ID) == 0 {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("No Legal Agreement is effective at %d", asOf),
+		}
+	}
+
+	// Marshal effective version
+	legalAgreementAsBytes, _ := MarshalCanonical(effectiveLegalAgreement)
+
+	return shim.Success(legalAgreementAsBytes)
+}
+
+// getLegalAgreements returns every legal agreement of the given tenant stored in the ledger
+func getLegalAgreements(stub shim.ChaincodeStubInterface, tenantID string) ([]LegalAgreement, error) {
+	// Get iterator for all entries of the tenant
+	startKey, endKey := tenantRange(tenantID)
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting state iterator: %s", err)
 	}
 	defer iterator.Close()
 
-	// Get the latest version
-	var latestLegalAgreement LegalAgreement
+	var legalAgreements []LegalAgreement
 	for iterator.HasNext() {
 		// Get the next item
 		item, err := iterator.Next()
 		if err != nil {
-			shim.Error(fmt.Sprintf("Error getting next item: %s", err))
+			return nil, fmt.Errorf("Error getting next item: %s", err)
 		}
 
-		// Unmarshal item
+		// Unmarshal item, skipping anything that is not a legal agreement
 		var legalAgreement LegalAgreement
 		err = json.Unmarshal(item.Value, &legalAgreement)
-		if err != nil && err.Error() != "Not a LegalAgreement" {
-			shim.Error(fmt.Sprintf("Error unmarshaling item: %s", err))
+		if err != nil {
+			if err.Error() == "Not a LegalAgreement" {
+				continue
+			}
+			return nil, fmt.Errorf("Error unmarshaling item: %s", err)
+		}
+
+		if legalAgreement.TenantID == tenantID {
+			legalAgreements = append(legalAgreements, legalAgreement)
+		}
+	}
+
+	return legalAgreements, nil
+}
+
+// latestVersion returns the legal agreement with the highest version accepted by the filter,
+// or an empty legal agreement if there is none. A nil filter accepts every legal agreement.
+func latestVersion(legalAgreements []LegalAgreement, filter func(LegalAgreement) bool) LegalAgreement {
+	var latestLegalAgreement LegalAgreement
+	for _, legalAgreement := range legalAgreements {
+		if filter != nil && !filter(legalAgreement) {
+			continue
 		}
 
-		// Update latest version
 		if latestLegalAgreement.Version < legalAgreement.Version {
 			latestLegalAgreement = legalAgreement
 		}
 	}
 
-	// Marshal latest version
-	legalAgreementAsBytes, _ := json.Marshal(latestLegalAgreement)
+	return latestLegalAgreement
+}
 
-	return shim.Success(legalAgreementAsBytes)
+// effectiveVersion returns the highest published or superseded version in force at the given
+// unix timestamp, or an empty legal agreement if there is none. A superseded version stays in
+// force until the version that superseded it becomes effective.
+func effectiveVersion(legalAgreements []LegalAgreement, timestamp int64) LegalAgreement {
+	return latestVersion(legalAgreements, func(legalAgreement LegalAgreement) bool {
+		status := legalAgreement.CurrentStatus()
+		if status != LegalAgreementStatusPublished && status != LegalAgreementStatusSuperseded {
+			return false
+		}
+		return legalAgreement.IsEffectiveAt(timestamp)
+	})
+}
+
+// getTxTimestamp returns the transaction timestamp as unix seconds
+func getTxTimestamp(stub shim.ChaincodeStubInterface) (int64, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("Error getting transaction timestamp: %s", err)
+	}
+	return timestamp.Seconds, nil
+}
+
+// asOfOrTxTimestamp returns the given as-of unix timestamp, or the transaction timestamp if it is zero
+func asOfOrTxTimestamp(stub shim.ChaincodeStubInterface, asOf int64) (int64, error) {
+	if asOf != 0 {
+		return asOf, nil
+	}
+	return getTxTimestamp(stub)
+}
+
+// computeContentHash returns the hash the contract expects for the given content
+func (s *SmartContract) computeContentHash(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ComputeContentHashRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ComputeContentHashRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ComputeContentHashRequest: %s", err))
+	}
+
+	hashAlgorithm := request.HashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = DefaultHashAlgorithm
+	}
+
+	contentHash, err := ComputeContentHash(request.Content, hashAlgorithm)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	response := ComputeContentHashResponse{
+		Hash:          contentHash,
+		HashAlgorithm: hashAlgorithm,
+	}
+	bytes, _ := MarshalCanonical(response)
+
+	return shim.Success(bytes)
 }
@@ -12,10 +12,13 @@ type SmartContract struct {
 	logger *shim.ChaincodeLogger
 }
 
-// Init is called during chaincode instantiation to initialize any data.
+// Init is called during chaincode instantiation and upgrade to initialize any data.
+// It accepts an optional UpdateConfigRequest to change the stored configuration.
 func (s *SmartContract) Init(stub shim.ChaincodeStubInterface) peer.Response {
 	s.logger = shim.NewLogger("legalagreement")
-	return shim.Success(nil)
+
+	_, args := stub.GetFunctionAndParameters()
+	return initConfig(stub, args)
 }
 
 // Invoke is called per transaction on the chaincode.
@@ -23,28 +26,70 @@ func (s *SmartContract) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
 	// Extract the function and args from the transaction proposal
 	function, args := stub.GetFunctionAndParameters()
 
+	// Requests are hashed and written in canonical JSON, so they must have a canonical form
+	if err := validateCanonicalArgs(args); err != nil {
+		return errorResponse(err)
+	}
+
 	// Call the internal function based on the arguments supplied
 	switch function {
 	case "init":
-		return s.Init(stub)
+		// Outside of instantiation and upgrade, the configuration is only changed by admins
+		return s.updateConfig(stub, args)
 	case "createLegalAgreement":
 		return s.createLegalAgreement(stub, args)
 	case "readLegalAgreement":
 		return s.readLegalAgreement(stub, args)
 	case "readLatestVersionLegalAgreement":
 		return s.readLatestVersionLegalAgreement(stub, args)
+	case "readEffectiveLegalAgreement":
+		return s.readEffectiveLegalAgreement(stub, args)
+	case "updateLegalAgreementStatus":
+		return s.updateLegalAgreementStatus(stub, args)
+	case "computeContentHash":
+		return s.computeContentHash(stub, args)
 	case "createLegalAgreementSigning":
 		return s.createLegalAgreementSigning(stub, args)
 	case "readLegalAgreementSigning":
 		return s.readLegalAgreementSigning(stub, args)
 	case "readLatestLegalAgreementSigningByUserID":
 		return s.readLatestLegalAgreementSigningByUserID(stub, args)
+	case "readAgreementExecutionStatus":
+		return s.readAgreementExecutionStatus(stub, args)
+	case "readConsentStatus":
+		return s.readConsentStatus(stub, args)
+	case "readScopeConsent":
+		return s.readScopeConsent(stub, args)
+	case "listUsersRequiringReconsent":
+		return s.listUsersRequiringReconsent(stub, args)
 	case "createUserIdentity":
 		return s.createUserIdentity(stub, args)
 	case "readUserIdentity":
 		return s.readUserIdentity(stub, args)
+	case "onboardTenant":
+		return s.onboardTenant(stub, args)
+	case "grantDelegation":
+		return s.grantDelegation(stub, args)
+	case "revokeDelegation":
+		return s.revokeDelegation(stub, args)
+	case "readDelegation":
+		return s.readDelegation(stub, args)
+	case "readTenant":
+		return s.readTenant(stub, args)
+	case "readConfig":
+		return s.readConfig(stub, args)
+	case "updateConfig":
+		return s.updateConfig(stub, args)
+	case "migrate":
+		return s.migrate(stub, args)
+	case "migrationStatus":
+		return s.migrationStatus(stub, args)
+	case "exportState":
+		return s.exportState(stub, args)
+	case "importState":
+		return s.importState(stub, args)
 	default:
 		fmt.Printf("Function for Invoke invalid or missing: %s, %s", function, args)
-		return shim.Error(fmt.Sprintf("Function for Invoke invalid or missing: %s, %s", function, args))
+		return badRequest(fmt.Sprintf("Function for Invoke invalid or missing: %s, %s", function, args))
 	}
 }
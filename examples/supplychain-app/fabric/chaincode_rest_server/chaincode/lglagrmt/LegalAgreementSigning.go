@@ -13,18 +13,49 @@ import (
 // createLegalAgreementSigning creates a legal agreement signing in the ledger
 func (s *SmartContract) createLegalAgreementSigning(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create LegalAgreementSigningRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request LegalAgreementSigningRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling LegalAgreementSigningRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling LegalAgreementSigningRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	tenant, err := authorizeTenant(stub, request.TenantID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return the original result if the request is a retry
+	replay, err := replayIdempotentRequest(stub, request.TenantID, request.IdempotencyKey, "createLegalAgreementSigning", request)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if replay != nil {
+		return *replay
+	}
+
+	// Generate the ID if the request has none
+	id := request.ID
+	if id == "" {
+		contentRequest := request
+		contentRequest.IdempotencyKey = ""
+		id = generateID(stub, config.Features.IDStrategy, contentRequest)
+	}
+	if err := validateID("ID", id); err != nil {
+		return badRequest(err.Error())
 	}
 
 	// Check if legal agreement signing state using id as key exists
-	testLegalAgreementSigningAsBytes, err := stub.GetState(request.ID)
+	testLegalAgreementSigningAsBytes, err := stub.GetState(tenantKey(request.TenantID, id))
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -32,20 +63,23 @@ func (s *SmartContract) createLegalAgreementSigning(stub shim.ChaincodeStubInter
 	// Return 404 if result's empty
 	if len(testLegalAgreementSigningAsBytes) != 0 {
 		return peer.Response{
-			Status:  403,
-			Message: fmt.Sprintf("Legal Agreement Signing %s already exists", request.ID),
+			Status:  409,
+			Message: fmt.Sprintf("Legal Agreement Signing %s already exists", id),
 		}
 	}
 
 	// Call readLegalAgreement to get the latest version
-	readLegalAgreementRequest := &ReadLegalAgreementRequest{ID: request.LegalAgreementID}
+	readLegalAgreementRequest := &ReadLegalAgreementRequest{TenantID: request.TenantID, ID: request.LegalAgreementID}
 	readLegalAgreementRequestAsBytes, err := json.Marshal(readLegalAgreementRequest)
 	if err != nil {
 		return shim.Error(fmt.Sprintf("Error marshaling ReadLegalAgreementRequest: %s", err))
 	}
 	legalAgreementAsBytes := s.readLegalAgreement(stub, []string{string(readLegalAgreementRequestAsBytes)})
 	if legalAgreementAsBytes.Status != 200 {
-		return shim.Error(fmt.Sprintf("Failed to read legal agreement: %s", legalAgreementAsBytes.Message))
+		return peer.Response{
+			Status:  legalAgreementAsBytes.Status,
+			Message: fmt.Sprintf("Failed to read legal agreement: %s", legalAgreementAsBytes.Message),
+		}
 	}
 
 	// Check if content hash is equal to the latest version
@@ -55,31 +89,152 @@ func (s *SmartContract) createLegalAgreementSigning(stub shim.ChaincodeStubInter
 		return shim.Error(err.Error())
 	}
 
+	// Only published legal agreements, or superseded ones still in force, can be signed
+	status := legalAgreement.CurrentStatus()
+	if status != LegalAgreementStatusPublished && status != LegalAgreementStatusSuperseded {
+		return badRequest(fmt.Sprintf("Legal Agreement %s is %s and cannot be signed", legalAgreement.ID, status))
+	}
+
+	// Check that the legal agreement is the version in force at the transaction timestamp
+	timestamp, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	legalAgreements, err := getLegalAgreements(stub, request.TenantID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if effectiveVersion(legalAgreements, timestamp).ID != legalAgreement.ID {
+		return badRequest(fmt.Sprintf("Legal Agreement %s is not the effective version", legalAgreement.ID))
+	}
+
 	if legalAgreement.ContentHash != request.LegalAgreementContentHash {
-		return shim.Error(fmt.Sprintf("Content hash does not match latest version of legal agreement"))
+		return badRequest(fmt.Sprintf("Content hash does not match latest version of legal agreement"))
+	}
+
+	// Only the parties of a multi-party legal agreement can sign it
+	if _, ok := legalAgreement.Party(request.UserID); len(legalAgreement.Parties) > 0 && !ok {
+		return peer.Response{
+			Status:  403,
+			Message: fmt.Sprintf("User %s is not a party to Legal Agreement %s", request.UserID, legalAgreement.ID),
+		}
+	}
+
+	// Validate the scope decisions against the scopes of the legal agreement
+	if err := validateScopeDecisions(legalAgreement, request.Accepted, request.ScopeDecisions); err != nil {
+		return badRequest(err.Error())
+	}
+	scopeDecisions := request.ScopeDecisions
+	if scopeDecisions == nil {
+		scopeDecisions = map[string]bool{}
+	}
+
+	// A submitting identity that acts as another user needs an effective delegation from the user
+	signerID, err := getCreatorUserID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	var onBehalfOf *OnBehalfOf
+	if signerID != "" && signerID != request.UserID {
+		onBehalfOf, err = authorizeDelegate(stub, request.TenantID, request.DelegationID, request.UserID, signerID, timestamp)
+		if err != nil {
+			return errorResponse(err)
+		}
+	} else if request.DelegationID != "" {
+		return badRequest(fmt.Sprintf("Delegation %s is only referenced when signing on behalf of another user", request.DelegationID))
+	}
+
+	// Check the signature of the user if the tenant requires one
+	if tenant.RequireSignature {
+		payload, err := SigningPayload(request.TenantID, request.UserID, request.LegalAgreementID, request.LegalAgreementContentHash, request.Accepted, request.Timestamp, scopeDecisions)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error marshaling the signing payload: %s", err))
+		}
+		if err := verifyCreatorSignature(stub, payload, request.Signature); err != nil {
+			return badRequest(fmt.Sprintf("Invalid signature: %s", err))
+		}
 	}
 
 	// Create a new LegalAgreementSigning
 	newLegalAgreementSigning := LegalAgreementSigning{
-		ID:                        request.ID,
+		SchemaVersion:             CurrentSchemaVersion,
+		TenantID:                  request.TenantID,
+		ID:                        id,
 		UserID:                    request.UserID,
 		LegalAgreementID:          request.LegalAgreementID,
 		LegalAgreementContentHash: request.LegalAgreementContentHash,
 		Accepted:                  request.Accepted,
 		Timestamp:                 request.Timestamp,
+		Signature:                 request.Signature,
+		ScopeDecisions:            scopeDecisions,
+		DeclineReason:             request.DeclineReason,
+		OnBehalfOf:                onBehalfOf,
+	}
+	receipt := NewConsentReceipt(newLegalAgreementSigning, stub.GetTxID(), timestamp)
+	newLegalAgreementSigning.Receipt = &receipt
+
+	// Check whether the signing executes a multi-party legal agreement, before it is written
+	events := []Event{{
+		Name: EventLegalAgreementSigningCreated,
+		Payload: map[string]interface{}{
+			"tenantID":          newLegalAgreementSigning.TenantID,
+			"ID":                newLegalAgreementSigning.ID,
+			"userID":            newLegalAgreementSigning.UserID,
+			"legalAgreementID":  newLegalAgreementSigning.LegalAgreementID,
+			"accepted":          newLegalAgreementSigning.Accepted,
+			"scopeDecisions":    newLegalAgreementSigning.ScopeDecisions,
+			"onBehalfOf":        newLegalAgreementSigning.OnBehalfOf,
+			"receiptCommitment": receipt.Commitment,
+		},
+	}}
+	if len(legalAgreement.Parties) > 0 {
+		legalAgreementSignings, err := getLegalAgreementSignings(stub, request.TenantID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		// The execution event comes first, so the chaincode event of the transaction is named after it
+		before := computeExecutionStatus(legalAgreement, legalAgreementSignings)
+		after := computeExecutionStatus(legalAgreement, append(legalAgreementSignings, newLegalAgreementSigning))
+		if !before.Executed && after.Executed {
+			events = append([]Event{{
+				Name: EventLegalAgreementExecuted,
+				Payload: map[string]interface{}{
+					"tenantID":         legalAgreement.TenantID,
+					"legalAgreementID": legalAgreement.ID,
+					"version":          legalAgreement.Version,
+					"parties":          after.Parties,
+				},
+			}}, events...)
+		}
 	}
 
 	// Marshal legal agreement signing
-	legalAgreementSigningAsBytes, _ := json.Marshal(newLegalAgreementSigning)
-	err = stub.PutState(newLegalAgreementSigning.ID, legalAgreementSigningAsBytes)
+	legalAgreementSigningAsBytes, _ := MarshalCanonical(newLegalAgreementSigning)
+	err = stub.PutState(tenantKey(newLegalAgreementSigning.TenantID, newLegalAgreementSigning.ID), legalAgreementSigningAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putUserSigningIndex(stub, newLegalAgreementSigning)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	response := map[string]interface{}{
-		"createdID": newLegalAgreementSigning.ID,
+	// Build the response, which is also returned to retries of the request
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		CreatedID: newLegalAgreementSigning.ID,
+		Document:  newLegalAgreementSigning,
+		Events:    events,
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Record the response for retries of the request
+	err = putIdempotencyRecord(stub, request.TenantID, request.IdempotencyKey, "createLegalAgreementSigning", request, newLegalAgreementSigning.ID, bytes)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
-	bytes, _ := json.Marshal(response)
 
 	s.logger.Infof("Wrote Legal Agreement Signing: %s\n", newLegalAgreementSigning.ID)
 	return shim.Success(bytes)
@@ -88,24 +243,29 @@ func (s *SmartContract) createLegalAgreementSigning(stub shim.ChaincodeStubInter
 // readLegalAgreementSigning returns the legal agreement signing with the given id
 func (s *SmartContract) readLegalAgreementSigning(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create ReadLegalAgreementSigningRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request ReadLegalAgreementSigningRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling ReadLegalAgreementSigningRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadLegalAgreementSigningRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
 	}
 
 	// Get the legal agreement signing state from the ledger
-	legalAgreementSigningAsBytes, err := stub.GetState(request.ID)
+	legalAgreementSigningAsBytes, err := stub.GetState(tenantKey(request.TenantID, request.ID))
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// Return 404 if result's empty
-	if len(legalAgreementSigningAsBytes) == 0 {
+	if len(legalAgreementSigningAsBytes) == 0 || !isValidID(request.ID) {
 		return peer.Response{
 			Status:  404,
 			Message: fmt.Sprintf("Legal Agreement Signing %s does not exist", request.ID),
@@ -122,62 +282,97 @@ func (s *SmartContract) readLegalAgreementSigning(stub shim.ChaincodeStubInterfa
 		}
 	}
 
-	return shim.Success(legalAgreementSigningAsBytes)
+	return storedDocumentResponse(legalAgreementSigningAsBytes, "Legal Agreement Signing")
 }
 
 // readLatestLegalAgreementSigningByUserID returns the latest legal agreement signing by user id
 func (s *SmartContract) readLatestLegalAgreementSigningByUserID(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create ReadLatestLegalAgreementSigningByUserIDRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request ReadLatestLegalAgreementSigningByUserIDRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling ReadLatestLegalAgreementSigningByUserIDRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadLatestLegalAgreementSigningByUserIDRequest: %s", err))
 	}
 
-	// Get iterator for all entries
-	iterator, err := stub.GetStateByRange("", "")
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Get all legal agreement signings
+	legalAgreementSignings, err := getLegalAgreementSignings(stub, request.TenantID)
 	if err != nil {
-		shim.Error(fmt.Sprintf("Error getting state iterator: %s", err))
+		return shim.Error(err.Error())
 	}
-	defer iterator.Close()
 
 	// Get the latest record
-	var latestLegalAgreementSigning LegalAgreementSigning
+	latestLegalAgreementSigning := latestSigningsByUser(legalAgreementSignings)[request.UserID]
+
+	// Return 404 if result's empty
+	if len(latestLegalAgreementSigning.UserID) == 0 {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("Legal Agreement Signing for user %s does not exist", request.UserID),
+		}
+	}
+
+	// Marshal latest record
+	legalAgreementSigningAsBytes, _ := MarshalCanonical(latestLegalAgreementSigning)
+
+	return shim.Success(legalAgreementSigningAsBytes)
+}
+
+// getLegalAgreementSignings returns every legal agreement signing of the given tenant stored in the ledger
+func getLegalAgreementSignings(stub shim.ChaincodeStubInterface, tenantID string) ([]LegalAgreementSigning, error) {
+	// Get iterator for all entries of the tenant
+	startKey, endKey := tenantRange(tenantID)
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting state iterator: %s", err)
+	}
+	defer iterator.Close()
+
+	var legalAgreementSignings []LegalAgreementSigning
 	for iterator.HasNext() {
 		// Get the next item
 		item, err := iterator.Next()
 		if err != nil {
-			shim.Error(fmt.Sprintf("Error getting next item: %s", err))
+			return nil, fmt.Errorf("Error getting next item: %s", err)
 		}
 
-		// Unmarshal item
+		// Unmarshal item, skipping anything that is not a legal agreement signing
 		var legalAgreementSigning LegalAgreementSigning
 		err = json.Unmarshal(item.Value, &legalAgreementSigning)
-		if err != nil && err.Error() != "Not a LegalAgreementSigning" {
-			shim.Error(fmt.Sprintf("Error unmarshaling item: %s", err))
+		if err != nil {
+			if err.Error() == "Not a LegalAgreementSigning" {
+				continue
+			}
+			return nil, fmt.Errorf("Error unmarshaling item: %s", err)
 		}
 
-		// Update latest record
-		if latestLegalAgreementSigning.Timestamp < legalAgreementSigning.Timestamp &&
-			legalAgreementSigning.UserID == request.UserID {
-			latestLegalAgreementSigning = legalAgreementSigning
+		if legalAgreementSigning.TenantID == tenantID {
+			legalAgreementSignings = append(legalAgreementSignings, legalAgreementSigning)
 		}
 	}
 
-	// Return 404 if result's empty
-	if len(latestLegalAgreementSigning.UserID) == 0 {
-		return peer.Response{
-			Status:  404,
-			Message: fmt.Sprintf("Legal Agreement Signing for user %s does not exist", request.UserID),
+	return legalAgreementSignings, nil
+}
+
+// latestSigningsByUser returns the latest legal agreement signing of every user, keyed by user ID.
+// Signings with the same timestamp are ordered by ID so that the result does not depend on key order.
+func latestSigningsByUser(legalAgreementSignings []LegalAgreementSigning) map[string]LegalAgreementSigning {
+	latestLegalAgreementSignings := map[string]LegalAgreementSigning{}
+	for _, legalAgreementSigning := range legalAgreementSignings {
+		latest, ok := latestLegalAgreementSignings[legalAgreementSigning.UserID]
+		if !ok || latest.Timestamp < legalAgreementSigning.Timestamp ||
+			(latest.Timestamp == legalAgreementSigning.Timestamp && latest.ID < legalAgreementSigning.ID) {
+			latestLegalAgreementSignings[legalAgreementSigning.UserID] = legalAgreementSigning
 		}
 	}
 
-	// Marshal latest record
-	legalAgreementSigningAsBytes, _ := json.Marshal(latestLegalAgreementSigning)
-
-	return shim.Success(legalAgreementSigningAsBytes)
+	return latestLegalAgreementSignings
 }
@@ -2,10 +2,7 @@ package lglagrmt
 
 import (
 	"encoding/json"
-	"io/ioutil"
-	"os"
 	"testing"
-	"time"
 
 	. "github.com/chaincode/common"
 
@@ -14,14 +11,6 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-// NewMockStub creates a MockStub. This currently requires using fabric builds from master branch
-// as it requires the changes below, that are yet to be released: https://jira.hyperledger.org/browse/FAB-5644
-func NewMockStub(name string, cc shim.Chaincode) *shim.MockStub {
-	// Create new mock
-	s := shim.NewMockStub(name, cc)
-	return s
-}
-
 func TestLegalAgreementSigning(t *testing.T) {
 	g := goblin.Goblin(t)
 	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
@@ -49,6 +38,14 @@ func TestLegalAgreementSigning(t *testing.T) {
 		})
 
 		g.Describe("with valid data", func() {
+			g.BeforeEach(func() {
+				// Create the legal agreement that is signed
+				args := [][]byte{[]byte("createLegalAgreement"), readJSON(g, "../testdata/legal-agreement-input-valid.json")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+			})
+
 			g.It("should return successfully", func() {
 				// Read input fixture
 				byteValue := readJSON(g, "../testdata/legal-agreement-signing-input-valid.json")
@@ -89,6 +86,15 @@ func TestLegalAgreementSigning(t *testing.T) {
 				var output map[string]interface{}
 				json.Unmarshal([]byte(byteValue), &output)
 
+				// The receipt holds the timestamp of the transaction, so it is checked on its own
+				var legalAgreementSigning LegalAgreementSigning
+				json.Unmarshal(bytes, &legalAgreementSigning)
+				Expect(legalAgreementSigning.Receipt).NotTo(BeNil())
+				Expect(legalAgreementSigning.Receipt.Verify()).To(Succeed())
+				Expect(legalAgreementSigning.Receipt.SigningID).To(Equal(input.ID))
+				Expect(legalAgreementSigning.Receipt.TxID).To(Equal("legalagreement"))
+				delete(results, "receipt")
+
 				Expect(results).To(Equal(output))
 			})
 
@@ -108,18 +114,42 @@ func TestLegalAgreementSigning(t *testing.T) {
 				var results map[string]interface{}
 				json.Unmarshal(response1.Payload, &results)
 
-				Expect(response2.Status).To(BeEquivalentTo(403))
-				Expect(response2.Message).To(BeEquivalentTo("Legal Agreement Signing 001 already exists"))
+				Expect(response2.Status).To(BeEquivalentTo(409))
+				Expect(response2.Message).To(BeEquivalentTo("Legal Agreement Signing 0001 already exists"))
 			})
 		})
 
 		g.Describe("with invalid data", func() {
+			g.It("should return an error if the Legal Agreement is retired", func() {
+				// Store a retired legal agreement
+				legalAgreement := LegalAgreement{
+					ID:          "001",
+					Content:     "some legal agreement content first version",
+					ContentHash: "5c23ff0895c77c61680680097fa64202e1f1864463e3f8e27a2bd3fc2c30592b",
+					Status:      LegalAgreementStatusRetired,
+					Timestamp:   1654027884,
+					Version:     1,
+				}
+				bytes, _ := json.Marshal(legalAgreement)
+				mockStub.MockTransactionStart(txID)
+				mockStub.PutState(legalAgreement.ID, bytes)
+				mockStub.MockTransactionEnd(txID)
+
+				// Run Create Legal Agreement Signing transaction
+				byteValue := readJSON(g, "../testdata/legal-agreement-signing-input-valid.json")
+				args := [][]byte{[]byte("createLegalAgreementSigning"), byteValue}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Legal Agreement 001 is retired and cannot be signed"))
+			})
+
 			g.It("should return an error if < 1 argument", func() {
 				// Run Create Product transaction
 				args := [][]byte{[]byte("createLegalAgreementSigning")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
@@ -128,7 +158,7 @@ func TestLegalAgreementSigning(t *testing.T) {
 				args := [][]byte{[]byte("createLegalAgreementSigning"), []byte(""), []byte("")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 		})
@@ -157,7 +187,7 @@ func TestLegalAgreementSigning(t *testing.T) {
 				mockStub.MockTransactionEnd(txID)
 
 				// Run Read Legal Agreement Signing transaction
-				args := [][]byte{[]byte("readLegalAgreementSigning"), []byte("0001")}
+				args := [][]byte{[]byte("readLegalAgreementSigning"), []byte(`{"ID":"0001"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
 				// Retrieve results
@@ -178,7 +208,7 @@ func TestLegalAgreementSigning(t *testing.T) {
 				args := [][]byte{[]byte("readLegalAgreementSigning")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
@@ -187,13 +217,13 @@ func TestLegalAgreementSigning(t *testing.T) {
 				args := [][]byte{[]byte("readLegalAgreementSigning"), []byte(""), []byte("")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
 			g.It("should return 404 if the Legal Agreement Signing doesn't exist", func() {
 				// Run Read Legal Agreement Signing transaction
-				args := [][]byte{[]byte("readLegalAgreementSigning"), []byte("None")}
+				args := [][]byte{[]byte("readLegalAgreementSigning"), []byte(`{"ID":"None"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
 				Expect(response.Status).To(BeEquivalentTo(404))
@@ -236,7 +266,7 @@ func TestLegalAgreementSigning(t *testing.T) {
 				mockStub.MockTransactionEnd(txID)
 
 				// Run Read Latest Legal Agreement Signing By User ID transaction
-				args := [][]byte{[]byte("readLatestLegalAgreementSigningByUserID"), []byte("001")}
+				args := [][]byte{[]byte("readLatestLegalAgreementSigningByUserID"), []byte(`{"userID":"001"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
 				// Retrieve results
@@ -257,7 +287,7 @@ func TestLegalAgreementSigning(t *testing.T) {
 				args := [][]byte{[]byte("readLatestLegalAgreementSigningByUserID")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
@@ -266,13 +296,13 @@ func TestLegalAgreementSigning(t *testing.T) {
 				args := [][]byte{[]byte("readLatestLegalAgreementSigningByUserID"), []byte(""), []byte("")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
 			g.It("should return 404 if the Latest Legal Agreement Signing By User ID doesn't exist", func() {
 				// Run Read Latest Legal Agreement Signing By User ID transaction
-				args := [][]byte{[]byte("readLatestLegalAgreementSigningByUserID"), []byte("None")}
+				args := [][]byte{[]byte("readLatestLegalAgreementSigningByUserID"), []byte(`{"userID":"None"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
 				Expect(response.Status).To(BeEquivalentTo(404))
@@ -0,0 +1,162 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// legalAgreementTransitions lists the states a legal agreement can move to from each state.
+// Superseded is only reached automatically, when a newer version is published.
+var legalAgreementTransitions = map[string][]string{
+	LegalAgreementStatusDraft:      {LegalAgreementStatusReview, LegalAgreementStatusPublished, LegalAgreementStatusRetired},
+	LegalAgreementStatusReview:     {LegalAgreementStatusDraft, LegalAgreementStatusPublished, LegalAgreementStatusRetired},
+	LegalAgreementStatusPublished:  {LegalAgreementStatusRetired},
+	LegalAgreementStatusSuperseded: {LegalAgreementStatusRetired},
+	LegalAgreementStatusRetired:    {},
+}
+
+// updateLegalAgreementStatus moves a legal agreement to another lifecycle state
+func (s *SmartContract) updateLegalAgreementStatus(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create UpdateLegalAgreementStatusRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request UpdateLegalAgreementStatusRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling UpdateLegalAgreementStatusRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Get the legal agreement state from the ledger
+	legalAgreementAsBytes, err := stub.GetState(tenantKey(request.TenantID, request.ID))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return 404 if legal agreement does not exist
+	if len(legalAgreementAsBytes) == 0 || !isValidID(request.ID) {
+		return peer.Response{
+			Status:  404,
+			Message: fmt.Sprintf("Legal Agreement %s does not exist", request.ID),
+		}
+	}
+
+	var legalAgreement LegalAgreement
+	err = json.Unmarshal(legalAgreementAsBytes, &legalAgreement)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Only the owning org can change the state of its legal agreements
+	_, owner, err := isOwner(stub, legalAgreement.OwnerMSP)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !owner && legalAgreement.OwnerMSP == "" {
+		return peer.Response{
+			Status:  403,
+			Message: fmt.Sprintf("Legal Agreement %s has no owner and can only be changed by admins", legalAgreement.ID),
+		}
+	}
+	if !owner {
+		return peer.Response{
+			Status:  403,
+			Message: fmt.Sprintf("Legal Agreement %s is owned by %s", legalAgreement.ID, legalAgreement.OwnerMSP),
+		}
+	}
+
+	// Validate the transition
+	currentStatus := legalAgreement.CurrentStatus()
+	if !canTransition(currentStatus, request.Status) {
+		return badRequest(fmt.Sprintf("Legal Agreement %s cannot move from %s to %s", request.ID, currentStatus, request.Status))
+	}
+	legalAgreement.Status = request.Status
+
+	// Publishing a version supersedes the previously published one
+	supersededIDs := []string{}
+	if legalAgreement.Status == LegalAgreementStatusPublished {
+		legalAgreements, err := getLegalAgreements(stub, legalAgreement.TenantID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		supersededIDs, err = supersedeLegalAgreements(stub, legalAgreements, legalAgreement)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	// Marshal legal agreement
+	legalAgreementAsBytes, _ = MarshalCanonical(legalAgreement)
+	err = stub.PutState(tenantKey(legalAgreement.TenantID, legalAgreement.ID), legalAgreementAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		UpdatedID: legalAgreement.ID,
+		Document:  legalAgreement,
+		Events: []Event{{
+			Name: EventLegalAgreementStatusUpdated,
+			Payload: map[string]interface{}{
+				"tenantID":       legalAgreement.TenantID,
+				"ID":             legalAgreement.ID,
+				"previousStatus": currentStatus,
+				"status":         legalAgreement.Status,
+				"supersededIDs":  supersededIDs,
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	s.logger.Infof("Moved Legal Agreement %s to %s\n", legalAgreement.ID, legalAgreement.Status)
+	return shim.Success(bytes)
+}
+
+// canTransition reports whether a legal agreement can move between the given states
+func canTransition(from string, to string) bool {
+	for _, allowed := range legalAgreementTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// supersedeLegalAgreements marks every other published legal agreement as superseded by the
+// given one and returns their IDs. It fails if a newer version is already published.
+func supersedeLegalAgreements(stub shim.ChaincodeStubInterface, legalAgreements []LegalAgreement, published LegalAgreement) ([]string, error) {
+	supersededIDs := []string{}
+	for _, legalAgreement := range legalAgreements {
+		if legalAgreement.ID == published.ID || legalAgreement.CurrentStatus() != LegalAgreementStatusPublished {
+			continue
+		}
+
+		if legalAgreement.Version > published.Version {
+			return nil, &statusError{400, fmt.Sprintf("The version %d is older than the published version %d", published.Version, legalAgreement.Version)}
+		}
+
+		legalAgreement.Status = LegalAgreementStatusSuperseded
+		legalAgreementAsBytes, _ := MarshalCanonical(legalAgreement)
+		if err := stub.PutState(tenantKey(legalAgreement.TenantID, legalAgreement.ID), legalAgreementAsBytes); err != nil {
+			return nil, err
+		}
+
+		supersededIDs = append(supersededIDs, legalAgreement.ID)
+	}
+
+	return supersededIDs, nil
+}
@@ -5,12 +5,13 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
-	"time"
 
 	. "github.com/chaincode/common"
 
 	"github.com/franela/goblin"
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/msp"
 	. "github.com/onsi/gomega"
 )
 
@@ -22,6 +23,35 @@ func NewMockStub(name string, cc shim.Chaincode) *shim.MockStub {
 	return s
 }
 
+// creatorStub is a MockStub that reports a creator from the given MSP, which MockStub does not support
+type creatorStub struct {
+	*shim.MockStub
+	creator []byte
+}
+
+func newCreatorStub(mockStub *shim.MockStub, mspID string) *creatorStub {
+	creator, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID})
+	return &creatorStub{MockStub: mockStub, creator: creator}
+}
+
+// GetCreator returns the serialized identity of the creator
+func (stub *creatorStub) GetCreator() ([]byte, error) {
+	return stub.creator, nil
+}
+
+// initAs instantiates the chaincode on the mock stub as an identity of the given org, whose
+// identities with the admin role are then admins
+func initAs(chaincode *SmartContract, mockStub *shim.MockStub, mspID string) {
+	txID := "mockTxID"
+
+	mockStub.MockTransactionStart(txID)
+	response := chaincode.Init(newCreatorStub(mockStub, mspID))
+	chaincode.logger.SetLevel(shim.LogError)
+	mockStub.MockTransactionEnd(txID)
+
+	Expect(response.Status).To(BeEquivalentTo(200))
+}
+
 func readJSON(g *goblin.G, path string) []byte {
 	jsonFile, err := os.Open(path)
 	if err != nil {
@@ -109,7 +139,7 @@ func TestLegalAgreement(t *testing.T) {
 				args := [][]byte{[]byte("createLegalAgreement")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
@@ -118,7 +148,7 @@ func TestLegalAgreement(t *testing.T) {
 				args := [][]byte{[]byte("createLegalAgreement"), []byte(""), []byte("")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
@@ -138,19 +168,19 @@ func TestLegalAgreement(t *testing.T) {
 
 				// Run Create Legal Agreement transaction with different ID
 				byteValue2, _ := json.Marshal(input2)
-				args2 := [][]byte{[]byte("createLegalAgreement"), string(byteValue2)}
+				args2 := [][]byte{[]byte("createLegalAgreement"), byteValue2}
 				response2 := mockStub.MockInvoke("legalagreement", args2)
 
 				// Retrieve results
 				var results1 map[string]interface{}
-				json.Unmarshal(response1.Payload, &results)
+				json.Unmarshal(response1.Payload, &results1)
 
 				var results2 map[string]interface{}
-				json.Unmarshal(response2.Payload, &results)
+				json.Unmarshal(response2.Payload, &results2)
 
 				Expect(response1.Status).To(BeEquivalentTo(200))
 				Expect(results1["createdID"]).To(Equal(input1.ID))
-				Expect(response2.Status).To(BeEquivalentTo(500))
+				Expect(response2.Status).To(BeEquivalentTo(400))
 				Expect(response2.Message).To(Equal("The version 1 is not greater than the latest version 1"))
 			})
 		})
@@ -178,7 +208,7 @@ func TestLegalAgreement(t *testing.T) {
 				mockStub.MockTransactionEnd(txID)
 
 				// Run Read Legal Agreement transaction
-				args := [][]byte{[]byte("readLegalAgreement"), []byte("001")}
+				args := [][]byte{[]byte("readLegalAgreement"), []byte(`{"ID":"001"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
 				// Retrieve results
@@ -199,7 +229,7 @@ func TestLegalAgreement(t *testing.T) {
 				args := [][]byte{[]byte("readLegalAgreement")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
@@ -208,13 +238,13 @@ func TestLegalAgreement(t *testing.T) {
 				args := [][]byte{[]byte("readLegalAgreement"), []byte(""), []byte("")}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
+				Expect(response.Status).To(BeEquivalentTo(400))
 				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
 			})
 
 			g.It("should return 404 if the Legal Agreement doesn't exist", func() {
 				// Run Read Legal Agreement transaction
-				args := [][]byte{[]byte("readLegalAgreement"), []byte("None")}
+				args := [][]byte{[]byte("readLegalAgreement"), []byte(`{"ID":"None"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
 				Expect(response.Status).To(BeEquivalentTo(404))
@@ -273,13 +303,281 @@ func TestLegalAgreement(t *testing.T) {
 		})
 
 		g.Describe("with invalid data", func() {
-			g.It("should return an error if > 0 argument", func() {
+			g.It("should return an error if > 1 argument", func() {
 				// Run Read Latest Version Legal Agreement transaction
-				args := [][]byte{[]byte("readLatestVersionLegalAgreement"), []byte("")}
+				args := [][]byte{[]byte("readLatestVersionLegalAgreement"), []byte(""), []byte("")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 0 or 1"))
+			})
+		})
+	})
+
+	g.Describe("Compute Content Hash", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should return the hash of the canonical content", func() {
+				// Run Compute Content Hash transaction
+				args := [][]byte{[]byte("computeContentHash"), []byte(`{"content":"some legal agreement content first version"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var results map[string]interface{}
+				json.Unmarshal(response.Payload, &results)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(results["hash"]).To(Equal("5c23ff0895c77c61680680097fa64202e1f1864463e3f8e27a2bd3fc2c30592b"))
+				Expect(results["hashAlgorithm"]).To(Equal(HashAlgorithmSHA256))
+			})
+
+			g.It("should ignore line endings and trailing whitespace", func() {
+				// Run Compute Content Hash transaction with LF line endings
+				args1 := [][]byte{[]byte("computeContentHash"), []byte(`{"content":"first line\nsecond line"}`)}
+				response1 := mockStub.MockInvoke("legalagreement", args1)
+
+				// Run Compute Content Hash transaction with CRLF line endings and trailing whitespace
+				args2 := [][]byte{[]byte("computeContentHash"), []byte(`{"content":"first line  \r\nsecond line\r\n"}`)}
+				response2 := mockStub.MockInvoke("legalagreement", args2)
+
+				// Retrieve results
+				var results1 map[string]interface{}
+				json.Unmarshal(response1.Payload, &results1)
+
+				var results2 map[string]interface{}
+				json.Unmarshal(response2.Payload, &results2)
+
+				Expect(response1.Status).To(BeEquivalentTo(200))
+				Expect(response2.Status).To(BeEquivalentTo(200))
+				Expect(results2["hash"]).To(Equal(results1["hash"]))
+			})
+
+			g.It("should use the requested hash algorithm", func() {
+				// Run Compute Content Hash transaction
+				args := [][]byte{[]byte("computeContentHash"), []byte(`{"content":"some legal agreement content first version","hashAlgorithm":"SHA3-256"}`)}
 				response := mockStub.MockInvoke("legalagreement", args)
 
-				Expect(response.Status).To(BeEquivalentTo(500))
-				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 0"))
+				// Retrieve results
+				var results map[string]interface{}
+				json.Unmarshal(response.Payload, &results)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(results["hashAlgorithm"]).To(Equal(HashAlgorithmSHA3256))
+				Expect(results["hash"]).To(HaveLen(64))
+				Expect(results["hash"]).NotTo(Equal("5c23ff0895c77c61680680097fa64202e1f1864463e3f8e27a2bd3fc2c30592b"))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if < 1 argument", func() {
+				// Run Compute Content Hash transaction
+				args := [][]byte{[]byte("computeContentHash")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 1"))
+			})
+
+			g.It("should return an error if the hash algorithm is not supported", func() {
+				// Run Compute Content Hash transaction
+				args := [][]byte{[]byte("computeContentHash"), []byte(`{"content":"some content","hashAlgorithm":"MD5"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Unsupported hash algorithm: MD5"))
+			})
+		})
+	})
+
+	g.Describe("Update Legal Agreement Status", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should publish a draft and supersede the previous version", func() {
+				// Run Create Legal Agreement transactions for a published and a draft version
+				args1 := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)}
+				response1 := mockStub.MockInvoke("legalagreement", args1)
+				args2 := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"002","content":"second version","status":"draft","timestamp":1654028933,"version":2}`)}
+				response2 := mockStub.MockInvoke("legalagreement", args2)
+
+				Expect(response1.Status).To(BeEquivalentTo(200))
+				Expect(response2.Status).To(BeEquivalentTo(200))
+
+				// The draft is not the latest published version yet
+				args := [][]byte{[]byte("readLatestVersionLegalAgreement")}
+				response := mockStub.MockInvoke("legalagreement", args)
+				var latest LegalAgreement
+				json.Unmarshal(response.Payload, &latest)
+
+				Expect(latest.ID).To(Equal("001"))
+
+				// Run Update Legal Agreement Status transaction
+				args = [][]byte{[]byte("updateLegalAgreementStatus"), []byte(`{"ID":"002","status":"published"}`)}
+				response = mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var results map[string]interface{}
+				json.Unmarshal(response.Payload, &results)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(results["updatedID"]).To(Equal("002"))
+				Expect(results["events"]).To(HaveLen(1))
+				Expect(results["events"].([]interface{})[0].(map[string]interface{})["payload"].(map[string]interface{})["supersededIDs"]).To(Equal([]interface{}{"001"}))
+
+				// Retrieve results from ledger
+				bytes, _ := mockStub.GetState("001")
+				var superseded LegalAgreement
+				json.Unmarshal(bytes, &superseded)
+
+				Expect(superseded.Status).To(Equal(LegalAgreementStatusSuperseded))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if the transition is not allowed", func() {
+				// Run Create Legal Agreement transaction
+				args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)}
+				mockStub.MockInvoke("legalagreement", args)
+
+				// Run Update Legal Agreement Status transaction
+				args = [][]byte{[]byte("updateLegalAgreementStatus"), []byte(`{"ID":"001","status":"draft"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Legal Agreement 001 cannot move from published to draft"))
+			})
+
+			g.It("should return 404 if the Legal Agreement doesn't exist", func() {
+				// Run Update Legal Agreement Status transaction
+				args := [][]byte{[]byte("updateLegalAgreementStatus"), []byte(`{"ID":"None","status":"retired"}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+				Expect(response.Message).To(Equal("Legal Agreement None does not exist"))
+			})
+		})
+	})
+
+	g.Describe("Read Effective Legal Agreement", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			// Run Create Legal Agreement transactions for a version in force and a scheduled version
+			args1 := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)}
+			mockStub.MockInvoke("legalagreement", args1)
+			args2 := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"002","content":"second version","timestamp":1654028933,"version":2,"effectiveFrom":4102444800}`)}
+			mockStub.MockInvoke("legalagreement", args2)
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should return the version in force at the transaction timestamp", func() {
+				// Run Read Effective Legal Agreement transaction
+				args := [][]byte{[]byte("readEffectiveLegalAgreement")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var result LegalAgreement
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.ID).To(Equal("001"))
+				Expect(result.Status).To(Equal(LegalAgreementStatusSuperseded))
+			})
+
+			g.It("should return the version in force at the given time", func() {
+				// Run Read Effective Legal Agreement transaction
+				args := [][]byte{[]byte("readEffectiveLegalAgreement"), []byte(`{"asOf":4102444800}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				// Retrieve results
+				var result LegalAgreement
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.ID).To(Equal("002"))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return an error if > 1 argument", func() {
+				// Run Read Effective Legal Agreement transaction
+				args := [][]byte{[]byte("readEffectiveLegalAgreement"), []byte(""), []byte("")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Incorrect number of arguments. Expecting 0 or 1"))
+			})
+
+			g.It("should return 404 if no Legal Agreement is in force", func() {
+				// Run Read Effective Legal Agreement transaction
+				args := [][]byte{[]byte("readEffectiveLegalAgreement"), []byte(`{"asOf":-1}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+				Expect(response.Message).To(Equal("No Legal Agreement is effective at -1"))
+			})
+
+			g.It("should not allow signing a version before it is in force", func() {
+				// Run Create Legal Agreement Signing transaction for the scheduled version
+				args := [][]byte{[]byte("createLegalAgreementSigning"), []byte(`{"ID":"0001","userID":"001","legalAgreementID":"002","legalAgreementContentHash":"f3b6b2d5bb2e0f0ffe8cc7ce7a2a8e6ad0b37bc5a3dd29e6ad4c7fcdc39f0e0d","accepted":true,"timestamp":1654029000}`)}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Legal Agreement 002 is not the effective version"))
+			})
+		})
+	})
+
+	g.Describe("Legal Agreement Ownership", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			// Create a legal agreement as Org1MSP
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.createLegalAgreement(newCreatorStub(mockStub, "Org1MSP"), []string{`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`})
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should record the owning org and its endorsement policy", func() {
+				// Retrieve results from ledger
+				bytes, _ := mockStub.GetState("001")
+				var result LegalAgreement
+				json.Unmarshal(bytes, &result)
+
+				policy, _ := mockStub.GetStateValidationParameter("001")
+
+				Expect(result.OwnerMSP).To(Equal("Org1MSP"))
+				Expect(policy).NotTo(BeEmpty())
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 403 if another org creates a version", func() {
+				// Create a legal agreement as Org2MSP
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.createLegalAgreement(newCreatorStub(mockStub, "Org2MSP"), []string{`{"ID":"002","content":"second version","timestamp":1654028933,"version":2}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Legal Agreement versions are owned by Org1MSP"))
+			})
+
+			g.It("should return 403 if another org changes the status", func() {
+				// Retire the legal agreement as Org2MSP
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.updateLegalAgreementStatus(newCreatorStub(mockStub, "Org2MSP"), []string{`{"ID":"001","status":"retired"}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Legal Agreement 001 is owned by Org1MSP"))
 			})
 		})
 	})
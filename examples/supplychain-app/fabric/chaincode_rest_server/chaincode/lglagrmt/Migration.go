@@ -0,0 +1,269 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// migrationKey is the key of the progress of the migration in progress
+const migrationKey = tenantKeySeparator + "migration"
+
+// migration upgrades a stored record to the next schema version. It returns false if the
+// record is not one it migrates.
+type migration struct {
+	description string
+	migrate     func(value []byte) ([]byte, bool, error)
+}
+
+// migrations lists the registered migrations in order: migrations[i] upgrades the records from
+// schema version LegacySchemaVersion+i to the next one. A record already at the target schema
+// version is skipped, so a chunk can safely be run again.
+var migrations = []migration{
+	{"Add the schema version to every record and backfill the status and hash algorithm of legal agreements", migrateToSchemaVersion2},
+}
+
+// migrate runs the current migration over the next chunk of records, ordered by key. Once all
+// records are migrated it moves the configuration to the next schema version, and the next call
+// starts the following migration. It must be submitted by an admin.
+func (s *SmartContract) migrate(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) > 1 {
+		return badRequest("Incorrect number of arguments. Expecting 0 or 1")
+	}
+
+	// Create MigrateRequest struct from input JSON
+	var request MigrateRequest
+	if len(args) == 1 {
+		argBytes := []byte(args[0])
+		if err := json.Unmarshal(argBytes, &request); err != nil {
+			return badRequest(fmt.Sprintf("Error unmarshaling MigrateRequest: %s", err))
+		}
+	}
+
+	// Only admins can migrate the ledger
+	if err := authorizeAdmin(stub, "migrate the ledger"); err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	pageSize, err := resolvePageSize(config, request.PageSize)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	status, err := getMigrationStatus(stub, config)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if status.Complete {
+		bytes, err := newWriteResponse(stub, WriteResponse{Document: status})
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(bytes)
+	}
+
+	// Resume after the last record examined by the previous chunk
+	startKey := ""
+	if status.Bookmark != "" {
+		startKey = status.Bookmark + "\x00"
+	}
+	iterator, err := stub.GetStateByRange(startKey, string(utf8.MaxRune))
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Error getting state iterator: %s", err))
+	}
+	defer iterator.Close()
+
+	current := migrations[config.SchemaVersion-LegacySchemaVersion]
+	targetVersion := config.SchemaVersion + 1
+	migrated := map[string][]byte{}
+	scanned := int32(0)
+	more := false
+	for iterator.HasNext() {
+		// Stop once the chunk is full, leaving the rest to the next call
+		if scanned == pageSize {
+			more = true
+			break
+		}
+		scanned++
+
+		// Get the next item
+		item, err := iterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error getting next item: %s", err))
+		}
+		status.Bookmark = item.Key
+		status.ScannedRecordsCount++
+
+		if item.Key == configKey || item.Key == migrationKey || recordSchemaVersion(item.Value) >= targetVersion {
+			continue
+		}
+
+		value, ok, err := current.migrate(item.Value)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error migrating %s: %s", item.Key, err))
+		}
+		if ok {
+			migrated[item.Key] = value
+		}
+	}
+
+	var migratedLegalAgreementSignings []LegalAgreementSigning
+	for key, value := range migrated {
+		if err := stub.PutState(key, value); err != nil {
+			return shim.Error(err.Error())
+		}
+		status.MigratedRecordsCount++
+
+		var legalAgreementSigning LegalAgreementSigning
+		if json.Unmarshal(value, &legalAgreementSigning) == nil {
+			migratedLegalAgreementSignings = append(migratedLegalAgreementSignings, legalAgreementSigning)
+		}
+	}
+
+	// Index the migrated signings, which were written before signings were indexed
+	if err := putUserSigningIndexes(stub, migratedLegalAgreementSignings); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Move to the next schema version once every record was examined
+	var events []Event
+	if !more {
+		config.SchemaVersion = targetVersion
+		if err := putConfig(stub, config); err != nil {
+			return shim.Error(err.Error())
+		}
+
+		s.logger.Infof("Migrated %d of %d records to schema version %d\n", status.MigratedRecordsCount, status.ScannedRecordsCount, targetVersion)
+		events = append(events, Event{
+			Name: EventSchemaMigrated,
+			Payload: map[string]interface{}{
+				"schemaVersion":        targetVersion,
+				"scannedRecordsCount":  status.ScannedRecordsCount,
+				"migratedRecordsCount": status.MigratedRecordsCount,
+			},
+		})
+		status = MigrationStatus{}
+	}
+
+	statusAsBytes, _ := MarshalCanonical(status)
+	if err := stub.PutState(migrationKey, statusAsBytes); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	status, err = getMigrationStatus(stub, config)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	bytes, err := newWriteResponse(stub, WriteResponse{Document: status, Events: events})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(bytes)
+}
+
+// migrationStatus returns the progress of the migration to the current schema version
+func (s *SmartContract) migrationStatus(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return badRequest("Incorrect number of arguments. Expecting 0")
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	status, err := getMigrationStatus(stub, config)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	statusAsBytes, _ := MarshalCanonical(status)
+
+	return shim.Success(statusAsBytes)
+}
+
+// getMigrationStatus returns the stored progress of the migration in progress, completed with
+// the schema versions of the configuration and of the contract
+func getMigrationStatus(stub shim.ChaincodeStubInterface, config Config) (MigrationStatus, error) {
+	statusAsBytes, err := stub.GetState(migrationKey)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	var status MigrationStatus
+	if len(statusAsBytes) != 0 {
+		if err := json.Unmarshal(statusAsBytes, &status); err != nil {
+			return MigrationStatus{}, fmt.Errorf("Error unmarshaling MigrationStatus: %s", err)
+		}
+	}
+
+	status.SchemaVersion = config.SchemaVersion
+	status.TargetSchemaVersion = CurrentSchemaVersion
+	status.Complete = config.SchemaVersion >= CurrentSchemaVersion
+	status.Migration = ""
+	if !status.Complete {
+		status.Migration = migrations[config.SchemaVersion-LegacySchemaVersion].description
+	}
+
+	return status, nil
+}
+
+// recordSchemaVersion returns the schema version of a stored record
+func recordSchemaVersion(value []byte) int {
+	var record struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(value, &record); err != nil || record.SchemaVersion == 0 {
+		return LegacySchemaVersion
+	}
+	return record.SchemaVersion
+}
+
+// migrateToSchemaVersion2 sets the schema version of every record, and the status and hash
+// algorithm that legal agreements written before lifecycle states and hash algorithms lack
+func migrateToSchemaVersion2(value []byte) ([]byte, bool, error) {
+	var legalAgreement LegalAgreement
+	if err := json.Unmarshal(value, &legalAgreement); err == nil {
+		legalAgreement.SchemaVersion = 2
+		legalAgreement.Status = legalAgreement.CurrentStatus()
+		if legalAgreement.HashAlgorithm == "" {
+			legalAgreement.HashAlgorithm = HashAlgorithmSHA256
+		}
+		migrated, err := MarshalCanonical(legalAgreement)
+		return migrated, true, err
+	}
+
+	var legalAgreementSigning LegalAgreementSigning
+	if err := json.Unmarshal(value, &legalAgreementSigning); err == nil {
+		legalAgreementSigning.SchemaVersion = 2
+		migrated, err := MarshalCanonical(legalAgreementSigning)
+		return migrated, true, err
+	}
+
+	var userIdentity UserIdentity
+	if err := json.Unmarshal(value, &userIdentity); err == nil {
+		userIdentity.SchemaVersion = 2
+		migrated, err := MarshalCanonical(userIdentity)
+		return migrated, true, err
+	}
+
+	var tenant Tenant
+	if err := json.Unmarshal(value, &tenant); err == nil {
+		tenant.SchemaVersion = 2
+		migrated, err := MarshalCanonical(tenant)
+		return migrated, true, err
+	}
+
+	return nil, false, nil
+}
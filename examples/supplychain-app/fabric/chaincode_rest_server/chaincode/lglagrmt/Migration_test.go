@@ -0,0 +1,129 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestMigration(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	var admin *creatorStub
+	chaincode := new(SmartContract)
+
+	// migrate runs the Migrate transaction as an admin
+	migrate := func(request string) MigrationStatus {
+		mockStub.MockTransactionStart(txID)
+		response := chaincode.migrate(admin, []string{request})
+		mockStub.MockTransactionEnd(txID)
+		Expect(response.Status).To(BeEquivalentTo(200))
+
+		var result MigrationStatus
+		json.Unmarshal(response.Payload, &WriteResponse{Document: &result})
+		return result
+	}
+
+	g.Describe("Migrate", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+			admin, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "admin"})
+
+			// Write records as the first version of the chaincode did, then upgrade it
+			mockStub.MockTransactionStart(txID)
+			mockStub.PutState("001", []byte(`{"ID":"001","content":"first version","hash":"abc","timestamp":1654027884,"version":1}`))
+			mockStub.PutState("002", []byte(`{"ID":"002","content":"second version","hash":"def","timestamp":1654028933,"version":2}`))
+			mockStub.PutState("0001", []byte(`{"ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"abc","accepted":true,"timestamp":1654027900}`))
+			mockStub.PutState("u-001", []byte(`{"userID":"u-001","legalAgreementSigningTxID":"tx","verifiableCredential":"vc"}`))
+			mockStub.MockTransactionEnd(txID)
+
+			initAs(chaincode, mockStub, "Org1MSP")
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should report the pending migration", func() {
+				args := [][]byte{[]byte("migrationStatus")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				var result MigrationStatus
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.SchemaVersion).To(Equal(LegacySchemaVersion))
+				Expect(result.TargetSchemaVersion).To(Equal(CurrentSchemaVersion))
+				Expect(result.Migration).NotTo(BeEmpty())
+				Expect(result.Complete).To(BeFalse())
+			})
+
+			g.It("should migrate the records in chunks", func() {
+				result := migrate(`{"pageSize":2}`)
+				Expect(result.Bookmark).To(Equal("001"))
+				Expect(result.ScannedRecordsCount).To(BeEquivalentTo(2))
+				Expect(result.MigratedRecordsCount).To(BeEquivalentTo(2))
+				Expect(result.Complete).To(BeFalse())
+
+				result = migrate(`{"pageSize":2}`)
+				Expect(result.Bookmark).To(Equal("u-001"))
+				Expect(result.MigratedRecordsCount).To(BeEquivalentTo(4))
+				Expect(result.Complete).To(BeFalse())
+
+				// The last chunk also scans the signing index written by the first one
+				result = migrate(`{"pageSize":3}`)
+				Expect(result.SchemaVersion).To(Equal(CurrentSchemaVersion))
+				Expect(result.Complete).To(BeTrue())
+
+				// Retrieve results from ledger
+				bytes, _ := mockStub.GetState("001")
+				var legalAgreement LegalAgreement
+				json.Unmarshal(bytes, &legalAgreement)
+
+				Expect(legalAgreement.SchemaVersion).To(Equal(2))
+				Expect(legalAgreement.Status).To(Equal(LegalAgreementStatusPublished))
+				Expect(legalAgreement.HashAlgorithm).To(Equal(HashAlgorithmSHA256))
+
+				bytes, _ = mockStub.GetState("u-001")
+				var userIdentity UserIdentity
+				json.Unmarshal(bytes, &userIdentity)
+
+				Expect(userIdentity.SchemaVersion).To(Equal(2))
+				Expect(userIdentity.VerifiableCredential).To(Equal("vc"))
+
+				// The migrated signing is indexed for its user
+				bytes, _ = mockStub.GetState(userSigningIndexKey("", "u-001"))
+				var index UserSigningIndex
+				json.Unmarshal(bytes, &index)
+
+				Expect(index.LegalAgreementSigningID).To(Equal("0001"))
+			})
+
+			g.It("should not change anything once complete", func() {
+				migrate("{}")
+
+				bytes, _ := mockStub.GetState("002")
+				result := migrate("{}")
+
+				after, _ := mockStub.GetState("002")
+				Expect(result.Complete).To(BeTrue())
+				Expect(after).To(Equal(bytes))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 403 if the identity is not an admin", func() {
+				args := [][]byte{[]byte("migrate"), []byte("{}")}
+				response := mockStub.MockInvoke("legalagreement", args)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only admins can migrate the ledger"))
+			})
+		})
+	})
+}
@@ -0,0 +1,250 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+// model is the reference model of the legal agreements and signings of a tenant
+type model struct {
+	legalAgreements        map[string]LegalAgreement
+	legalAgreementSignings map[string]LegalAgreementSigning
+	latestVersion          int64
+}
+
+// effectiveID returns the ID of the highest published or superseded version
+func (m *model) effectiveID() string {
+	effective := LegalAgreement{}
+	for _, legalAgreement := range m.legalAgreements {
+		if legalAgreement.Status != LegalAgreementStatusDraft && legalAgreement.Status != LegalAgreementStatusReview &&
+			legalAgreement.Version > effective.Version {
+			effective = legalAgreement
+		}
+	}
+	return effective.ID
+}
+
+// latestPublishedID returns the ID of the highest published version
+func (m *model) latestPublishedID() string {
+	latest := LegalAgreement{}
+	for _, legalAgreement := range m.legalAgreements {
+		if legalAgreement.Status == LegalAgreementStatusPublished && legalAgreement.Version > latest.Version {
+			latest = legalAgreement
+		}
+	}
+	return latest.ID
+}
+
+// latestSigningID returns the ID of the latest signing of a user, ordered by timestamp and then ID
+func (m *model) latestSigningID(userID string) string {
+	latest := LegalAgreementSigning{}
+	for _, legalAgreementSigning := range m.legalAgreementSignings {
+		if legalAgreementSigning.UserID != userID {
+			continue
+		}
+		if latest.ID == "" || latest.Timestamp < legalAgreementSigning.Timestamp ||
+			(latest.Timestamp == legalAgreementSigning.Timestamp && latest.ID < legalAgreementSigning.ID) {
+			latest = legalAgreementSigning
+		}
+	}
+	return latest.ID
+}
+
+// TestModel runs random sequences of create and read transactions against the contract and checks
+// the responses and the ledger against a reference model after every transaction
+func TestModel(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	const sequences = 40
+	const steps = 60
+	contents := []string{"first version", "second version", "third version"}
+	chaincode := new(SmartContract)
+
+	g.Describe("Model", func() {
+		g.It("should keep the invariants over random sequences of transactions", func() {
+			g.Timeout(time.Minute)
+
+			for seed := int64(1); seed <= sequences; seed++ {
+				random := rand.New(rand.NewSource(seed))
+				ledger := stubtest.New("legalagreement", chaincode)
+				ledger.SetClock(func() time.Time { return time.Unix(1654027884, 0) })
+				ledger.Init("tx0", [][]byte{[]byte("init")})
+				chaincode.logger.SetLevel(shim.LogError)
+
+				m := &model{legalAgreements: map[string]LegalAgreement{}, legalAgreementSignings: map[string]LegalAgreementSigning{}}
+				for step := 1; step <= steps; step++ {
+					txID := fmt.Sprintf("tx%d", step)
+					var function string
+					var request interface{}
+					var expectedStatus int32 = 200
+					var expectedID string
+
+					switch random.Intn(4) {
+					case 0:
+						status := []string{"", LegalAgreementStatusDraft, LegalAgreementStatusReview, LegalAgreementStatusPublished}[random.Intn(4)]
+						legalAgreementRequest := LegalAgreementRequest{
+							ID:      fmt.Sprintf("a%d", random.Intn(8)),
+							Content: contents[random.Intn(len(contents))],
+							Status:  status,
+							Version: random.Int63n(m.latestVersion + 3),
+						}
+						function, request = "createLegalAgreement", legalAgreementRequest
+
+						if _, ok := m.legalAgreements[legalAgreementRequest.ID]; ok {
+							expectedStatus = 409
+						} else if legalAgreementRequest.Version <= m.latestVersion {
+							expectedStatus = 400
+						} else {
+							if status == "" {
+								status = LegalAgreementStatusPublished
+							}
+							if status == LegalAgreementStatusPublished {
+								for id, legalAgreement := range m.legalAgreements {
+									if legalAgreement.Status == LegalAgreementStatusPublished {
+										legalAgreement.Status = LegalAgreementStatusSuperseded
+										m.legalAgreements[id] = legalAgreement
+									}
+								}
+							}
+							contentHash, _ := ComputeContentHash(legalAgreementRequest.Content, DefaultHashAlgorithm)
+							m.legalAgreements[legalAgreementRequest.ID] = LegalAgreement{
+								ID:          legalAgreementRequest.ID,
+								ContentHash: contentHash,
+								Status:      status,
+								Version:     legalAgreementRequest.Version,
+							}
+							m.latestVersion = legalAgreementRequest.Version
+						}
+					case 1:
+						legalAgreementSigningRequest := LegalAgreementSigningRequest{
+							ID:               fmt.Sprintf("s%d", random.Intn(12)),
+							UserID:           fmt.Sprintf("u%d", random.Intn(3)),
+							LegalAgreementID: fmt.Sprintf("a%d", random.Intn(8)),
+							Accepted:         random.Intn(2) == 0,
+							Timestamp:        100 + random.Int63n(3),
+						}
+						legalAgreement, ok := m.legalAgreements[legalAgreementSigningRequest.LegalAgreementID]
+						if ok && random.Intn(4) > 0 {
+							legalAgreementSigningRequest.LegalAgreementContentHash = legalAgreement.ContentHash
+						} else {
+							legalAgreementSigningRequest.LegalAgreementContentHash, _ = ComputeContentHash(contents[random.Intn(len(contents))], DefaultHashAlgorithm)
+						}
+						function, request = "createLegalAgreementSigning", legalAgreementSigningRequest
+
+						if _, exists := m.legalAgreementSignings[legalAgreementSigningRequest.ID]; exists {
+							expectedStatus = 409
+						} else if !ok {
+							expectedStatus = 404
+						} else if m.effectiveID() != legalAgreement.ID || legalAgreement.ContentHash != legalAgreementSigningRequest.LegalAgreementContentHash {
+							expectedStatus = 400
+						} else {
+							m.legalAgreementSignings[legalAgreementSigningRequest.ID] = LegalAgreementSigning{
+								ID:                        legalAgreementSigningRequest.ID,
+								UserID:                    legalAgreementSigningRequest.UserID,
+								LegalAgreementID:          legalAgreementSigningRequest.LegalAgreementID,
+								LegalAgreementContentHash: legalAgreementSigningRequest.LegalAgreementContentHash,
+								Timestamp:                 legalAgreementSigningRequest.Timestamp,
+							}
+						}
+					case 2:
+						userID := fmt.Sprintf("u%d", random.Intn(3))
+						function, request = "readLatestLegalAgreementSigningByUserID", ReadLatestLegalAgreementSigningByUserIDRequest{UserID: userID}
+						expectedID = m.latestSigningID(userID)
+						if expectedID == "" {
+							expectedStatus = 404
+						}
+					case 3:
+						function, request = "readLatestVersionLegalAgreement", ReadLatestVersionLegalAgreementRequest{}
+						expectedID = m.latestPublishedID()
+					}
+
+					requestAsBytes, _ := json.Marshal(request)
+					description := fmt.Sprintf("seed %d, step %d: %s %s", seed, step, function, requestAsBytes)
+					response := ledger.Invoke(txID, [][]byte{[]byte(function), requestAsBytes})
+					Expect(response.Status).To(Equal(expectedStatus), "%s: %s", description, response.Message)
+
+					// Reads return the record the model expects
+					if response.Status == 200 && (function == "readLatestLegalAgreementSigningByUserID" || function == "readLatestVersionLegalAgreement") {
+						var document struct {
+							ID string `json:"ID"`
+						}
+						json.Unmarshal(response.Payload, &document)
+						Expect(document.ID).To(Equal(expectedID), description)
+					}
+
+					expectInvariants(ledger, m, description)
+				}
+			}
+		})
+	})
+}
+
+// expectInvariants checks the legal agreements and signings in the ledger against the model
+func expectInvariants(ledger *stubtest.Stub, m *model, description string) {
+	legalAgreements := map[string]LegalAgreement{}
+	legalAgreementSignings := map[string]LegalAgreementSigning{}
+	iterator, err := ledger.GetStateByRange("", "")
+	Expect(err).NotTo(HaveOccurred())
+	for iterator.HasNext() {
+		item, _ := iterator.Next()
+		var legalAgreement LegalAgreement
+		var legalAgreementSigning LegalAgreementSigning
+		if json.Unmarshal(item.Value, &legalAgreement) == nil {
+			legalAgreements[legalAgreement.ID] = legalAgreement
+		} else if json.Unmarshal(item.Value, &legalAgreementSigning) == nil {
+			legalAgreementSignings[legalAgreementSigning.ID] = legalAgreementSigning
+		}
+	}
+	iterator.Close()
+
+	// The ledger holds the records of the model
+	Expect(legalAgreements).To(HaveLen(len(m.legalAgreements)), description)
+	Expect(legalAgreementSignings).To(HaveLen(len(m.legalAgreementSignings)), description)
+
+	// Versions are unique, and at most one version is published
+	versions := map[int64]string{}
+	published := 0
+	for id, legalAgreement := range legalAgreements {
+		Expect(m.legalAgreements).To(HaveKey(id), description)
+		Expect(legalAgreement.Status).To(Equal(m.legalAgreements[id].Status), "%s: status of %s", description, id)
+		Expect(versions).NotTo(HaveKey(legalAgreement.Version), "%s: version of %s", description, id)
+		versions[legalAgreement.Version] = id
+		if legalAgreement.Status == LegalAgreementStatusPublished {
+			published++
+		}
+	}
+	Expect(published).To(BeNumerically("<=", 1), description)
+
+	// Every signing references an existing legal agreement with its hash
+	for id, legalAgreementSigning := range legalAgreementSignings {
+		Expect(m.legalAgreementSignings).To(HaveKey(id), description)
+		Expect(legalAgreements).To(HaveKey(legalAgreementSigning.LegalAgreementID), "%s: legal agreement of %s", description, id)
+		Expect(legalAgreementSigning.LegalAgreementContentHash).To(Equal(legalAgreements[legalAgreementSigning.LegalAgreementID].ContentHash), "%s: hash of %s", description, id)
+	}
+
+	// The latest signing of every user is the one of the model
+	latestLegalAgreementSignings := latestSigningsByUser(valuesOf(legalAgreementSignings))
+	for userID, latest := range latestLegalAgreementSignings {
+		Expect(latest.ID).To(Equal(m.latestSigningID(userID)), "%s: latest signing of %s", description, userID)
+	}
+}
+
+// valuesOf returns the legal agreement signings of a map
+func valuesOf(legalAgreementSignings map[string]LegalAgreementSigning) []LegalAgreementSigning {
+	values := make([]LegalAgreementSigning, 0, len(legalAgreementSignings))
+	for _, legalAgreementSigning := range legalAgreementSignings {
+		values = append(values, legalAgreementSigning)
+	}
+	return values
+}
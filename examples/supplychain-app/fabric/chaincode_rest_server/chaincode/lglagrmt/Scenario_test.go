@@ -0,0 +1,16 @@
+package lglagrmt
+
+import (
+	"testing"
+
+	"github.com/chaincode/stubtest"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// TestScenarios runs every scenario of testdata/scenarios against a new ledger
+func TestScenarios(t *testing.T) {
+	stubtest.RunScenarios(t, "../testdata/scenarios", func() shim.Chaincode {
+		return new(SmartContract)
+	})
+}
@@ -0,0 +1,298 @@
+package lglagrmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// exportState returns the next chunk of the records of the ledger, ordered by key, as a chunk of
+// a state export. The configuration, the progress of migrations, the idempotency records and the
+// signing indexes belong to the ledger rather than to its data, so they are not exported. It can
+// only be run by an admin.
+func (s *SmartContract) exportState(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) > 1 {
+		return badRequest("Incorrect number of arguments. Expecting 0 or 1")
+	}
+
+	// Create ExportStateRequest struct from input JSON
+	var request ExportStateRequest
+	if len(args) == 1 {
+		argBytes := []byte(args[0])
+		if err := json.Unmarshal(argBytes, &request); err != nil {
+			return badRequest(fmt.Sprintf("Error unmarshaling ExportStateRequest: %s", err))
+		}
+	}
+
+	// Only admins can read the records of every tenant
+	if err := authorizeAdmin(stub, "export the state"); err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	pageSize, err := resolvePageSize(config, request.PageSize)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	timestamp, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Resume after the last record of the previous chunk
+	startKey := ""
+	if request.Bookmark != "" {
+		startKey = request.Bookmark + "\x00"
+	}
+	iterator, err := stub.GetStateByRange(startKey, string(utf8.MaxRune))
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Error getting state iterator: %s", err))
+	}
+	defer iterator.Close()
+
+	header := StateExportHeader{SchemaVersion: config.SchemaVersion, Timestamp: timestamp, Bookmark: request.Bookmark}
+	records := []StateExportRecord{}
+	for iterator.HasNext() {
+		// Get the next item
+		item, err := iterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error getting next item: %s", err))
+		}
+
+		if isLedgerKey(item.Key) {
+			continue
+		}
+
+		// Stop once the chunk is full, leaving the rest to the next call
+		if int32(len(records)) == pageSize {
+			header.NextBookmark = records[len(records)-1].Key
+			break
+		}
+
+		recordType := recordTypeOf(item.Value)
+		if recordType == "" {
+			return shim.Error(fmt.Sprintf("Record %s is not a known type of record", item.Key))
+		}
+		records = append(records, StateExportRecord{Type: recordType, Key: item.Key, Value: item.Value})
+	}
+
+	chunk, err := EncodeStateExport(header, records)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(chunk)
+}
+
+// importState writes the records of chunks of a state export that are not in the ledger yet, and
+// reports the ones whose key already exists as conflicts, leaving them as they are. Every chunk is
+// checked against its header and every record against its type and key before anything is
+// written, so an invalid import writes nothing. It can only be run by an admin.
+func (s *SmartContract) importState(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ImportStateRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ImportStateRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ImportStateRequest: %s", err))
+	}
+
+	// Only admins can write the records of every tenant
+	if err := authorizeAdmin(stub, "import the state"); err != nil {
+		return errorResponse(err)
+	}
+
+	config, _, err := getConfig(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	headers, records, err := DecodeStateExport([]byte(request.Chunk))
+	if err != nil {
+		return badRequest(fmt.Sprintf("Invalid state export: %s", err))
+	}
+	if config.Limits.MaxPageSize > 0 && int32(len(records)) > config.Limits.MaxPageSize {
+		return badRequest(fmt.Sprintf("The import holds %d records, more than the maximum page size %d", len(records), config.Limits.MaxPageSize))
+	}
+	for _, header := range headers {
+		if header.SchemaVersion != config.SchemaVersion {
+			return badRequest(fmt.Sprintf("The state export is at schema version %d, but the ledger is at schema version %d", header.SchemaVersion, config.SchemaVersion))
+		}
+	}
+
+	imported := map[string]bool{}
+	for i, record := range records {
+		if imported[record.Key] {
+			return badRequest(fmt.Sprintf("Record %s is imported more than once", record.Key))
+		}
+		imported[record.Key] = true
+		if err := validateStateExportRecord(record); err != nil {
+			return badRequest(err.Error())
+		}
+
+		// Records are written in canonical JSON, even when exported by an earlier version
+		records[i].Value, err = CanonicalizeJSON(record.Value)
+		if err != nil {
+			return badRequest(fmt.Sprintf("Record %s cannot be written in canonical JSON: %s", record.Key, err))
+		}
+	}
+
+	response := ImportStateResponse{Conflicts: []StateImportConflict{}}
+	var importedLegalAgreementSignings []LegalAgreementSigning
+	for _, record := range records {
+		storedAsBytes, err := stub.GetState(record.Key)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if len(storedAsBytes) != 0 {
+			response.Conflicts = append(response.Conflicts, StateImportConflict{
+				Type:      record.Type,
+				Key:       record.Key,
+				Identical: bytes.Equal(storedAsBytes, record.Value),
+			})
+			continue
+		}
+
+		if err := stub.PutState(record.Key, record.Value); err != nil {
+			return shim.Error(err.Error())
+		}
+		response.ImportedRecordsCount++
+
+		if record.Type == RecordTypeLegalAgreementSigning {
+			var legalAgreementSigning LegalAgreementSigning
+			json.Unmarshal(record.Value, &legalAgreementSigning)
+			importedLegalAgreementSignings = append(importedLegalAgreementSignings, legalAgreementSigning)
+		}
+
+		// Require the owning org to endorse any later change to an imported legal agreement
+		if record.Type == RecordTypeLegalAgreement && config.Features.OrgEndorsementPolicy {
+			var legalAgreement LegalAgreement
+			json.Unmarshal(record.Value, &legalAgreement)
+			if legalAgreement.OwnerMSP != "" {
+				if err := setOrgEndorsementPolicy(stub, record.Key, legalAgreement.OwnerMSP); err != nil {
+					return shim.Error(err.Error())
+				}
+			}
+		}
+	}
+
+	if err := putUserSigningIndexes(stub, importedLegalAgreementSignings); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	s.logger.Infof("Imported %d of %d records\n", response.ImportedRecordsCount, len(records))
+	responseAsBytes, err := newWriteResponse(stub, WriteResponse{
+		Document: response,
+		Events: []Event{{
+			Name: EventStateImported,
+			Payload: map[string]interface{}{
+				"importedRecordsCount": response.ImportedRecordsCount,
+				"conflictsCount":       len(response.Conflicts),
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(responseAsBytes)
+}
+
+// isLedgerKey reports whether a key holds data of the ledger itself rather than a record: the
+// configuration, the progress of migrations, an idempotency record or a signing index
+func isLedgerKey(key string) bool {
+	return key == configKey || key == migrationKey || strings.HasPrefix(key, tenantKeySeparator+"idempotency"+tenantKeySeparator) ||
+		strings.HasPrefix(key, tenantKeySeparator+"signer"+tenantKeySeparator)
+}
+
+// recordTypeOf returns the type of a stored record, or an empty string if it is of no known type
+func recordTypeOf(value []byte) string {
+	for _, recordType := range []struct {
+		name     string
+		document interface{}
+	}{
+		{RecordTypeLegalAgreement, new(LegalAgreement)},
+		{RecordTypeLegalAgreementSigning, new(LegalAgreementSigning)},
+		{RecordTypeUserIdentity, new(UserIdentity)},
+		{RecordTypeTenant, new(Tenant)},
+		{RecordTypeDelegation, new(Delegation)},
+	} {
+		if json.Unmarshal(value, recordType.document) == nil {
+			return recordType.name
+		}
+	}
+	return ""
+}
+
+// validateStateExportRecord checks that the value of a record of a state export is of its type
+// and that its key is the one the contract writes it at, so an import cannot write the
+// configuration or another key the contract does not manage
+func validateStateExportRecord(record StateExportRecord) error {
+	var tenantID, id, key string
+	switch record.Type {
+	case RecordTypeLegalAgreement:
+		var legalAgreement LegalAgreement
+		if err := json.Unmarshal(record.Value, &legalAgreement); err != nil {
+			return fmt.Errorf("Record %s is not a %s: %s", record.Key, record.Type, err)
+		}
+		tenantID, id = legalAgreement.TenantID, legalAgreement.ID
+	case RecordTypeLegalAgreementSigning:
+		var legalAgreementSigning LegalAgreementSigning
+		if err := json.Unmarshal(record.Value, &legalAgreementSigning); err != nil {
+			return fmt.Errorf("Record %s is not a %s: %s", record.Key, record.Type, err)
+		}
+		tenantID, id = legalAgreementSigning.TenantID, legalAgreementSigning.ID
+	case RecordTypeUserIdentity:
+		var userIdentity UserIdentity
+		if err := json.Unmarshal(record.Value, &userIdentity); err != nil {
+			return fmt.Errorf("Record %s is not a %s: %s", record.Key, record.Type, err)
+		}
+		tenantID, id = userIdentity.TenantID, userIdentity.UserID
+	case RecordTypeDelegation:
+		var delegation Delegation
+		if err := json.Unmarshal(record.Value, &delegation); err != nil {
+			return fmt.Errorf("Record %s is not a %s: %s", record.Key, record.Type, err)
+		}
+		tenantID, id = delegation.TenantID, delegation.ID
+	case RecordTypeTenant:
+		var tenant Tenant
+		if err := json.Unmarshal(record.Value, &tenant); err != nil {
+			return fmt.Errorf("Record %s is not a %s: %s", record.Key, record.Type, err)
+		}
+		if tenant.TenantID == "" || strings.Contains(tenant.TenantID, tenantKeySeparator) {
+			return fmt.Errorf("Invalid tenant ID %s", tenant.TenantID)
+		}
+		key = tenantConfigKey(tenant.TenantID)
+	default:
+		return fmt.Errorf("Record %s has the unknown type %s", record.Key, record.Type)
+	}
+
+	if record.Type != RecordTypeTenant {
+		if strings.Contains(tenantID, tenantKeySeparator) {
+			return fmt.Errorf("Invalid tenant ID %s", tenantID)
+		}
+		if err := validateID("ID", id); err != nil {
+			return err
+		}
+		key = tenantKey(tenantID, id)
+	}
+	if record.Key != key {
+		return fmt.Errorf("Record %s of type %s must have the key %s", record.Key, record.Type, key)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+func TestStateExport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var source, target *stubtest.Stub
+	var admin, user *stubtest.Identity
+	chaincode := new(SmartContract)
+
+	// invoke runs a transaction on a ledger as the given identity
+	invoke := func(ledger *stubtest.Stub, identity *stubtest.Identity, txID string, function string, request string) peer.Response {
+		ledger.SetCreator(identity)
+		return ledger.Invoke(txID, [][]byte{[]byte(function), []byte(request)})
+	}
+
+	// newLedger returns a ledger on which the chaincode is instantiated
+	newLedger := func() *stubtest.Stub {
+		ledger := stubtest.New("legalagreement", chaincode)
+		ledger.SetCreator(admin)
+		response := ledger.Init("tx0", [][]byte{[]byte("init")})
+		chaincode.logger.SetLevel(shim.LogError)
+		Expect(response.Status).To(BeEquivalentTo(200))
+		return ledger
+	}
+
+	// export returns all the chunks of the state export of the source ledger, in order
+	export := func(pageSize int32) []string {
+		var chunks []string
+		bookmark := ""
+		for {
+			request, _ := json.Marshal(ExportStateRequest{PageSize: pageSize, Bookmark: bookmark})
+			response := invoke(source, admin, "export", "exportState", string(request))
+			Expect(response.Status).To(BeEquivalentTo(200))
+			chunks = append(chunks, string(response.Payload))
+
+			headers, _, err := DecodeStateExport(response.Payload)
+			Expect(err).NotTo(HaveOccurred())
+			if headers[0].NextBookmark == "" {
+				return chunks
+			}
+			bookmark = headers[0].NextBookmark
+		}
+	}
+
+	// importState imports a chunk into the target ledger as an admin
+	importState := func(txID string, chunk string) (peer.Response, ImportStateResponse) {
+		request, _ := json.Marshal(ImportStateRequest{Chunk: chunk})
+		response := invoke(target, admin, txID, "importState", string(request))
+
+		var result ImportStateResponse
+		json.Unmarshal(response.Payload, &WriteResponse{Document: &result})
+		return response, result
+	}
+
+	g.Describe("State export", func() {
+		g.BeforeEach(func() {
+			admin, _ = stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			user, _ = stubtest.NewIdentity("Org1MSP", "user", map[string]string{"role": "user", "tenantID": "acme", "userID": "u-001"})
+			source, target = newLedger(), newLedger()
+
+			hash, _ := ComputeContentHash("first version", "")
+			for i, request := range []struct{ function, request string }{
+				{"onboardTenant", `{"tenantID":"acme","name":"Acme","allowedRoles":["user"]}`},
+				{"createLegalAgreement", `{"ID":"001","content":"first version","timestamp":1654027884,"version":1,"idempotencyKey":"k-001"}`},
+				{"createLegalAgreement", `{"ID":"002","content":"second version","timestamp":1654028933,"version":2}`},
+				{"createLegalAgreement", `{"tenantID":"acme","ID":"001","content":"first version","timestamp":1654027884,"version":1}`},
+			} {
+				response := invoke(source, admin, "tx"+string(rune('1'+i)), request.function, request.request)
+				Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			}
+
+			// The user signs for themselves
+			response := invoke(source, user, "tx6", "createLegalAgreementSigning", `{"tenantID":"acme","ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"`+hash+`","accepted":true,"timestamp":1654027900}`)
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+		})
+
+		g.It("should export every record in chunks, without the data of the ledger", func() {
+			chunks := export(2)
+			Expect(chunks).To(HaveLen(3))
+
+			headers, records, err := DecodeStateExport([]byte(strings.Join(chunks, "")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(headers[0].Bookmark).To(Equal(""))
+			Expect(headers[1].Bookmark).To(Equal(headers[0].NextBookmark))
+			Expect(headers[0].SchemaVersion).To(Equal(CurrentSchemaVersion))
+
+			var keys, types []string
+			for _, record := range records {
+				keys = append(keys, record.Key)
+				types = append(types, record.Type)
+				stored, _ := source.GetState(record.Key)
+				Expect(string(record.Value)).To(Equal(string(stored)))
+			}
+			Expect(keys).To(Equal([]string{"001", "002", "acme~0001", "acme~001", "~tenant~acme"}))
+			Expect(types).To(Equal([]string{
+				RecordTypeLegalAgreement, RecordTypeLegalAgreement, RecordTypeLegalAgreementSigning, RecordTypeLegalAgreement, RecordTypeTenant,
+			}))
+		})
+
+		g.It("should import the chunks of an export into another ledger", func() {
+			for i, chunk := range export(2) {
+				response, result := importState("import"+string(rune('1'+i)), chunk)
+				Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+				Expect(result.Conflicts).To(BeEmpty())
+			}
+
+			for _, key := range []string{"001", "002", "acme~0001", "acme~001", "~tenant~acme"} {
+				stored, _ := source.GetState(key)
+				imported, _ := target.GetState(key)
+				Expect(string(imported)).To(Equal(string(stored)))
+			}
+
+			response := invoke(target, user, "read", "readLatestLegalAgreementSigningByUserID", `{"tenantID":"acme","userID":"u-001"}`)
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			// The imported signings are indexed for their users
+			response = invoke(target, user, "read", "listUsersRequiringReconsent", `{"tenantID":"acme"}`)
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			var users ListUsersRequiringReconsentResponse
+			json.Unmarshal(response.Payload, &users)
+			Expect(users.FetchedRecordsCount).To(BeEquivalentTo(1))
+		})
+
+		g.It("should report the records that already exist as conflicts", func() {
+			response := invoke(target, admin, "tx1", "createLegalAgreement", `{"ID":"002","content":"another version","timestamp":1654028933,"version":2}`)
+			Expect(response.Status).To(BeEquivalentTo(200))
+			chunk := strings.Join(export(10), "")
+
+			response, result := importState("import1", chunk)
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			Expect(result.ImportedRecordsCount).To(BeEquivalentTo(4))
+			Expect(result.Conflicts).To(Equal([]StateImportConflict{{Type: RecordTypeLegalAgreement, Key: "002", Identical: false}}))
+
+			var legalAgreement LegalAgreement
+			stored, _ := target.GetState("002")
+			json.Unmarshal(stored, &legalAgreement)
+			Expect(legalAgreement.Content).To(Equal("another version"))
+
+			// Importing again only reports conflicts
+			response, result = importState("import2", chunk)
+			Expect(response.Status).To(BeEquivalentTo(200))
+			Expect(result.ImportedRecordsCount).To(BeEquivalentTo(0))
+			Expect(result.Conflicts).To(HaveLen(5))
+			Expect(result.Conflicts[0].Identical).To(BeTrue())
+		})
+
+		g.It("should reject a chunk that does not match its hashes", func() {
+			chunk := strings.Replace(export(10)[0], "second version", "altered version", 1)
+			response, _ := importState("import1", chunk)
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("Invalid state export: Record 002 does not match its hash"))
+
+			lines := strings.Split(export(10)[0], "\n")
+			chunk = strings.Join(append(lines[:1], lines[2:]...), "\n")
+			response, _ = importState("import2", chunk)
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("Invalid state export: Chunk 1 holds 4 records instead of 5"))
+
+			stored, _ := target.GetState("002")
+			Expect(stored).To(BeEmpty())
+		})
+
+		g.It("should reject a record written at another key than its own", func() {
+			chunk, err := EncodeStateExport(StateExportHeader{SchemaVersion: CurrentSchemaVersion}, []StateExportRecord{
+				{Type: RecordTypeLegalAgreement, Key: "001", Value: json.RawMessage(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)},
+				{Type: RecordTypeTenant, Key: "~config", Value: json.RawMessage(`{"tenantID":"acme","allowedRoles":[]}`)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			response, _ := importState("import1", string(chunk))
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("Record ~config of type Tenant must have the key ~tenant~acme"))
+
+			stored, _ := target.GetState("001")
+			Expect(stored).To(BeEmpty())
+		})
+
+		g.It("should reject a record that is not of its type", func() {
+			chunk, _ := EncodeStateExport(StateExportHeader{SchemaVersion: CurrentSchemaVersion}, []StateExportRecord{
+				{Type: RecordTypeLegalAgreementSigning, Key: "001", Value: json.RawMessage(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)},
+			})
+
+			response, _ := importState("import1", string(chunk))
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("Record 001 is not a LegalAgreementSigning: Not a LegalAgreementSigning"))
+		})
+
+		g.It("should reject an export at another schema version", func() {
+			chunk, _ := EncodeStateExport(StateExportHeader{SchemaVersion: LegacySchemaVersion}, nil)
+
+			response, _ := importState("import1", string(chunk))
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(ContainSubstring("The state export is at schema version 1"))
+		})
+
+		g.It("should leave a record of an unknown type after a full chunk to the next chunk", func() {
+			response := invoke(source, admin, "export", "exportState", `{"pageSize":1}`)
+			_, records, _ := DecodeStateExport(response.Payload)
+
+			state := source.State()
+			state[records[0].Key+" "] = []byte(`{"unknown":true}`)
+			source.Load(state)
+
+			response = invoke(source, admin, "export", "exportState", `{"pageSize":1}`)
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			headers, _, _ := DecodeStateExport(response.Payload)
+			Expect(headers[0].NextBookmark).To(Equal(records[0].Key))
+
+			request, _ := json.Marshal(ExportStateRequest{PageSize: 1, Bookmark: headers[0].NextBookmark})
+			response = invoke(source, admin, "export", "exportState", string(request))
+			Expect(response.Status).To(BeEquivalentTo(500))
+			Expect(response.Message).To(Equal("Record " + records[0].Key + "  is not a known type of record"))
+		})
+
+		g.It("should only let admins export and import", func() {
+			response := invoke(source, user, "export", "exportState", `{}`)
+			Expect(response.Status).To(BeEquivalentTo(403))
+			Expect(response.Message).To(Equal("Only admins can export the state"))
+
+			response = invoke(target, user, "import", "importState", `{"chunk":""}`)
+			Expect(response.Status).To(BeEquivalentTo(403))
+			Expect(response.Message).To(Equal("Only admins can import the state"))
+		})
+	})
+}
@@ -0,0 +1,36 @@
+package lglagrmt
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// statusError is an error returned by a helper that maps to a specific response status
+type statusError struct {
+	status  int32
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// errorResponse converts an error to a response, keeping the status of a statusError
+func errorResponse(err error) peer.Response {
+	if statusErr, ok := err.(*statusError); ok {
+		return peer.Response{
+			Status:  statusErr.status,
+			Message: statusErr.message,
+		}
+	}
+	return shim.Error(err.Error())
+}
+
+// badRequest returns the response to an invalid request, which the client has to change before
+// submitting it again
+func badRequest(message string) peer.Response {
+	return peer.Response{
+		Status:  400,
+		Message: message,
+	}
+}
@@ -0,0 +1,230 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// tenantKeySeparator separates the tenant ID from the record ID in the keys of a tenant
+const tenantKeySeparator = "~"
+
+// onboardTenant creates a tenant with its own configuration in the ledger
+func (s *SmartContract) onboardTenant(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create OnboardTenantRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request OnboardTenantRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling OnboardTenantRequest: %s", err))
+	}
+
+	// Only admins can onboard tenants
+	if err := authorizeAdmin(stub, "onboard tenants"); err != nil {
+		return errorResponse(err)
+	}
+
+	if request.TenantID == "" || strings.Contains(request.TenantID, tenantKeySeparator) {
+		return badRequest(fmt.Sprintf("Invalid tenant ID %s", request.TenantID))
+	}
+
+	// Return the original result if the request is a retry
+	replay, err := replayIdempotentRequest(stub, "", request.IdempotencyKey, "onboardTenant", request)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if replay != nil {
+		return *replay
+	}
+
+	// Check if tenant state using id as key exists
+	testTenantAsBytes, err := stub.GetState(tenantConfigKey(request.TenantID))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Return 403 if item exists
+	if len(testTenantAsBytes) != 0 {
+		return peer.Response{
+			Status:  409,
+			Message: fmt.Sprintf("Tenant %s already exists", request.TenantID),
+		}
+	}
+
+	// Create a new Tenant
+	newTenant := Tenant{
+		SchemaVersion:    CurrentSchemaVersion,
+		TenantID:         request.TenantID,
+		Name:             request.Name,
+		AllowedRoles:     request.AllowedRoles,
+		RequireSignature: request.RequireSignature,
+		Timestamp:        request.Timestamp,
+	}
+	if newTenant.AllowedRoles == nil {
+		newTenant.AllowedRoles = []string{}
+	}
+
+	// Marshal tenant
+	tenantAsBytes, _ := MarshalCanonical(newTenant)
+	err = stub.PutState(tenantConfigKey(newTenant.TenantID), tenantAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Build the response, which is also returned to retries of the request
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		CreatedID: newTenant.TenantID,
+		Document:  newTenant,
+		Events: []Event{{
+			Name: EventTenantOnboarded,
+			Payload: map[string]interface{}{
+				"tenantID": newTenant.TenantID,
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Record the response for retries of the request
+	err = putIdempotencyRecord(stub, "", request.IdempotencyKey, "onboardTenant", request, newTenant.TenantID, bytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	s.logger.Infof("Wrote Tenant: %s\n", newTenant.TenantID)
+	return shim.Success(bytes)
+}
+
+// readTenant returns the tenant with the given id
+func (s *SmartContract) readTenant(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return badRequest("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Create ReadTenantRequest struct from input JSON
+	argBytes := []byte(args[0])
+	var request ReadTenantRequest
+	if err := json.Unmarshal(argBytes, &request); err != nil {
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadTenantRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant, which also checks that it exists
+	tenant, err := authorizeTenant(stub, request.TenantID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	tenantAsBytes, _ := MarshalCanonical(tenant)
+
+	return shim.Success(tenantAsBytes)
+}
+
+// authorizeTenant checks that the submitting identity can access the given tenant and returns its
+// configuration. Admins can access every tenant. Other identities can only access the tenant named
+// by their tenantID attribute, or the default tenant if they have none, and only with a role the
+// tenant allows.
+func authorizeTenant(stub shim.ChaincodeStubInterface, tenantID string) (Tenant, error) {
+	admin, err := isAdmin(stub)
+	if err != nil {
+		return Tenant{}, err
+	}
+
+	creatorTenantID, _, err := getCreatorAttribute(stub, AttributeTenantID)
+	if err != nil {
+		return Tenant{}, err
+	}
+	if !admin && creatorTenantID != tenantID {
+		if tenantID == "" {
+			return Tenant{}, &statusError{403, "Identity cannot access the default tenant"}
+		}
+		return Tenant{}, &statusError{403, fmt.Sprintf("Identity cannot access tenant %s", tenantID)}
+	}
+
+	// The default tenant has no configuration
+	if tenantID == "" {
+		return Tenant{AllowedRoles: []string{}}, nil
+	}
+
+	tenant, err := getTenant(stub, tenantID)
+	if err != nil {
+		return Tenant{}, err
+	}
+	if tenant == nil {
+		return Tenant{}, &statusError{404, fmt.Sprintf("Tenant %s does not exist", tenantID)}
+	}
+
+	if !admin && len(tenant.AllowedRoles) > 0 {
+		role, _, err := getCreatorAttribute(stub, AttributeRole)
+		if err != nil {
+			return Tenant{}, err
+		}
+		if !containsString(tenant.AllowedRoles, role) {
+			return Tenant{}, &statusError{403, fmt.Sprintf("Role %s is not allowed in tenant %s", role, tenantID)}
+		}
+	}
+
+	return *tenant, nil
+}
+
+// getTenant returns the tenant with the given id, or nil if it does not exist
+func getTenant(stub shim.ChaincodeStubInterface, tenantID string) (*Tenant, error) {
+	tenantAsBytes, err := stub.GetState(tenantConfigKey(tenantID))
+	if err != nil {
+		return nil, err
+	}
+	if len(tenantAsBytes) == 0 {
+		return nil, nil
+	}
+
+	var tenant Tenant
+	err = json.Unmarshal(tenantAsBytes, &tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+// tenantKey returns the key of a record of the given tenant. Records of the default tenant keep
+// their plain ID as key.
+func tenantKey(tenantID string, id string) string {
+	if tenantID == "" {
+		return id
+	}
+	return tenantID + tenantKeySeparator + id
+}
+
+// tenantRange returns the key range holding the records of the given tenant. The range of the
+// default tenant is the whole ledger, so records must still be filtered by their tenant ID.
+func tenantRange(tenantID string) (string, string) {
+	if tenantID == "" {
+		return "", ""
+	}
+	return tenantID + tenantKeySeparator, tenantID + tenantKeySeparator + string(utf8.MaxRune)
+}
+
+// tenantConfigKey returns the key of the configuration of the given tenant. It starts with the
+// separator, which tenant IDs cannot contain, so it never falls within the range of a tenant.
+func tenantConfigKey(tenantID string) string {
+	return tenantKeySeparator + "tenant" + tenantKeySeparator + tenantID
+}
+
+// containsString reports whether the slice contains the given string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package lglagrmt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/msp"
+	. "github.com/onsi/gomega"
+)
+
+// newIdentityStub returns a creatorStub whose creator has an X.509 certificate carrying the given
+// Fabric CA attributes, together with the private key of the certificate
+func newIdentityStub(mockStub *shim.MockStub, mspID string, attrs map[string]string) (*creatorStub, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	attrsAsBytes, _ := json.Marshal(map[string]interface{}{"attrs": attrs})
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}, Value: attrsAsBytes},
+		},
+	}
+	certificate, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+
+	creator, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certificatePEM})
+	return &creatorStub{MockStub: mockStub, creator: creator}, key
+}
+
+// sign returns the base64 encoded ASN.1 ECDSA signature of the payload
+func sign(key *ecdsa.PrivateKey, payload []byte) string {
+	digest := sha256.Sum256(payload)
+	r, s, _ := ecdsa.Sign(rand.Reader, key, digest[:])
+	signature, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestTenant(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	var admin, user, outsider *creatorStub
+	var userKey *ecdsa.PrivateKey
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	g.Describe("Onboard Tenant", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+			initAs(chaincode, mockStub, "Org1MSP")
+			admin, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "admin"})
+			user, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "user", "tenantID": "acme"})
+		})
+
+		g.Describe("with valid data", func() {
+			g.It("should write the tenant to the ledger", func() {
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.onboardTenant(admin, []string{`{"tenantID":"acme","name":"Acme","allowedRoles":["user"],"timestamp":1654027884}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+
+				// Read the tenant as a member of the tenant
+				mockStub.MockTransactionStart(txID)
+				response = chaincode.readTenant(user, []string{`{"tenantID":"acme"}`})
+				mockStub.MockTransactionEnd(txID)
+
+				var result Tenant
+				json.Unmarshal(response.Payload, &result)
+
+				Expect(response.Status).To(BeEquivalentTo(200))
+				Expect(result.Name).To(Equal("Acme"))
+				Expect(result.AllowedRoles).To(Equal([]string{"user"}))
+			})
+		})
+
+		g.Describe("with invalid data", func() {
+			g.It("should return 403 if the admin belongs to another org", func() {
+				otherAdmin, _ := newIdentityStub(mockStub, "Org2MSP", map[string]string{"role": "admin"})
+
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.onboardTenant(otherAdmin, []string{`{"tenantID":"acme","name":"Acme"}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only admins can onboard tenants"))
+			})
+			g.It("should return 403 if the identity is not an admin", func() {
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.onboardTenant(user, []string{`{"tenantID":"acme","allowedRoles":["user"]}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(403))
+				Expect(response.Message).To(Equal("Only admins can onboard tenants"))
+			})
+
+			g.It("should return an error if the tenant ID contains the key separator", func() {
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.onboardTenant(admin, []string{`{"tenantID":"ac~me","allowedRoles":["user"]}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(400))
+				Expect(response.Message).To(Equal("Invalid tenant ID ac~me"))
+			})
+
+			g.It("should return 404 if the tenant doesn't exist", func() {
+				mockStub.MockTransactionStart(txID)
+				response := chaincode.readTenant(user, []string{`{"tenantID":"acme"}`})
+				mockStub.MockTransactionEnd(txID)
+
+				Expect(response.Status).To(BeEquivalentTo(404))
+				Expect(response.Message).To(Equal("Tenant acme does not exist"))
+			})
+		})
+	})
+
+	g.Describe("Tenant Isolation", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+			initAs(chaincode, mockStub, "Org1MSP")
+			admin, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "admin"})
+			user, userKey = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "user", "tenantID": "acme", "userID": "u-001"})
+			outsider, _ = newIdentityStub(mockStub, "Org1MSP", map[string]string{"role": "user", "tenantID": "globex"})
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.onboardTenant(admin, []string{`{"tenantID":"acme","allowedRoles":["user"],"requireSignature":true}`})
+			Expect(response.Status).To(BeEquivalentTo(200))
+			response = chaincode.createLegalAgreement(user, []string{`{"tenantID":"acme","ID":"001","content":"acme terms","timestamp":1654027884,"version":1}`})
+			Expect(response.Status).To(BeEquivalentTo(200))
+			mockStub.MockTransactionEnd(txID)
+		})
+
+		g.It("should store the records of a tenant under its own keys", func() {
+			bytes, _ := mockStub.GetState("acme~001")
+			var result LegalAgreement
+			json.Unmarshal(bytes, &result)
+
+			Expect(result.TenantID).To(Equal("acme"))
+			Expect(mockStub.State["001"]).To(BeNil())
+		})
+
+		g.It("should return 403 if the identity belongs to another tenant", func() {
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.readLegalAgreement(outsider, []string{`{"tenantID":"acme","ID":"001"}`})
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(403))
+			Expect(response.Message).To(Equal("Identity cannot access tenant acme"))
+		})
+
+		g.It("should return 403 if the identity has no tenant", func() {
+			args := [][]byte{[]byte("readLegalAgreement"), []byte(`{"tenantID":"acme","ID":"001"}`)}
+			response := mockStub.MockInvoke("legalagreement", args)
+
+			Expect(response.Status).To(BeEquivalentTo(403))
+			Expect(response.Message).To(Equal("Identity cannot access tenant acme"))
+		})
+
+		g.It("should not return the agreements of a tenant in the default tenant", func() {
+			args := [][]byte{[]byte("readLatestVersionLegalAgreement")}
+			response := mockStub.MockInvoke("legalagreement", args)
+
+			var result LegalAgreement
+			json.Unmarshal(response.Payload, &result)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+			Expect(result.ID).To(BeEmpty())
+		})
+
+		g.It("should require a valid signature if the tenant asks for one", func() {
+			contentHash, _ := ComputeContentHash("acme terms", DefaultHashAlgorithm)
+			request := LegalAgreementSigningRequest{
+				TenantID:                  "acme",
+				ID:                        "0001",
+				UserID:                    "u-001",
+				LegalAgreementID:          "001",
+				LegalAgreementContentHash: contentHash,
+				Accepted:                  true,
+				Timestamp:                 1654027900,
+			}
+			requestAsBytes, _ := json.Marshal(request)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.createLegalAgreementSigning(user, []string{string(requestAsBytes)})
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(400))
+			Expect(response.Message).To(Equal("Invalid signature: signature is required"))
+
+			payload, _ := SigningPayload(request.TenantID, request.UserID, request.LegalAgreementID, request.LegalAgreementContentHash, request.Accepted, request.Timestamp, request.ScopeDecisions)
+			Expect(string(payload)).To(Equal(`{"accepted":true,"legalAgreementContentHash":"` + contentHash + `","legalAgreementID":"001","scopeDecisions":{},"tenantID":"acme","timestamp":1654027900,"userID":"u-001"}`))
+			request.Signature = sign(userKey, payload)
+			requestAsBytes, _ = json.Marshal(request)
+
+			mockStub.MockTransactionStart(txID)
+			response = chaincode.createLegalAgreementSigning(user, []string{string(requestAsBytes)})
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+}
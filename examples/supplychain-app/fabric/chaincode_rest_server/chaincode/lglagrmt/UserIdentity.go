@@ -13,18 +13,36 @@ import (
 // createUserIdentity creates an user identity in the ledger
 func (s *SmartContract) createUserIdentity(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create UserIdentityRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request UserIdentityRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling UserIdentityRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling UserIdentityRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
+	}
+
+	// Return the original result if the request is a retry
+	replay, err := replayIdempotentRequest(stub, request.TenantID, request.IdempotencyKey, "createUserIdentity", request)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if replay != nil {
+		return *replay
+	}
+
+	if err := validateID("user ID", request.UserID); err != nil {
+		return badRequest(err.Error())
 	}
 
 	// Check if user identity state using id as key exists
-	testUserIdentityAsBytes, err := stub.GetState(request.UserID)
+	testUserIdentityAsBytes, err := stub.GetState(tenantKey(request.TenantID, request.UserID))
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -32,13 +50,15 @@ func (s *SmartContract) createUserIdentity(stub shim.ChaincodeStubInterface, arg
 	// Return 403 if item exists
 	if len(testUserIdentityAsBytes) != 0 {
 		return peer.Response{
-			Status:  403,
+			Status:  409,
 			Message: fmt.Sprintf("User Identity %s already exists", request.UserID),
 		}
 	}
 
 	// Create a new UserIdentity
 	newUserIdentity := UserIdentity{
+		SchemaVersion:             CurrentSchemaVersion,
+		TenantID:                  request.TenantID,
 		UserID:                    request.UserID,
 		LegalAgreementSigningTxID: request.LegalAgreementSigningTxID,
 		VerifiableCredential:      request.VerifiableCredential,
@@ -46,17 +66,34 @@ func (s *SmartContract) createUserIdentity(stub shim.ChaincodeStubInterface, arg
 	}
 
 	// Marshal user identity
-	userIdentityAsBytes, _ := json.Marshal(newUserIdentity)
-	err = stub.PutState(newUserIdentity.UserID, userIdentityAsBytes)
+	userIdentityAsBytes, _ := MarshalCanonical(newUserIdentity)
+	err = stub.PutState(tenantKey(newUserIdentity.TenantID, newUserIdentity.UserID), userIdentityAsBytes)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	response := map[string]interface{}{
-		"createdID": newUserIdentity.UserID,
-		"txID":      stub.GetTxID(),
+	// Build the response, which is also returned to retries of the request
+	bytes, err := newWriteResponse(stub, WriteResponse{
+		CreatedID: newUserIdentity.UserID,
+		Document:  newUserIdentity,
+		Events: []Event{{
+			Name: EventUserIdentityCreated,
+			Payload: map[string]interface{}{
+				"tenantID": newUserIdentity.TenantID,
+				"userID":   newUserIdentity.UserID,
+				"status":   newUserIdentity.Status,
+			},
+		}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Record the response for retries of the request
+	err = putIdempotencyRecord(stub, request.TenantID, request.IdempotencyKey, "createUserIdentity", request, newUserIdentity.UserID, bytes)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
-	bytes, _ := json.Marshal(response)
 
 	s.logger.Infof("Wrote User Identity: %s\n", newUserIdentity.UserID)
 	return shim.Success(bytes)
@@ -65,24 +102,29 @@ func (s *SmartContract) createUserIdentity(stub shim.ChaincodeStubInterface, arg
 // readUserIdentity returns the user identity with the given id
 func (s *SmartContract) readUserIdentity(stub shim.ChaincodeStubInterface, args []string) peer.Response {
 	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+		return badRequest("Incorrect number of arguments. Expecting 1")
 	}
 
 	// Create ReadUserIdentityRequest struct from input JSON
 	argBytes := []byte(args[0])
 	var request ReadUserIdentityRequest
 	if err := json.Unmarshal(argBytes, &request); err != nil {
-		return shim.Error(fmt.Sprintf("Error unmarshaling ReadUserIdentityRequest: %s", err))
+		return badRequest(fmt.Sprintf("Error unmarshaling ReadUserIdentityRequest: %s", err))
+	}
+
+	// Check that the identity can access the tenant
+	if _, err := authorizeTenant(stub, request.TenantID); err != nil {
+		return errorResponse(err)
 	}
 
 	// Get the user identity state from the ledger
-	userIdentityAsBytes, err := stub.GetState(request.UserID)
+	userIdentityAsBytes, err := stub.GetState(tenantKey(request.TenantID, request.UserID))
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// Return 404 if user identity does not exist
-	if len(userIdentityAsBytes) == 0 {
+	if len(userIdentityAsBytes) == 0 || !isValidID(request.UserID) {
 		return peer.Response{
 			Status:  404,
 			Message: fmt.Sprintf("User Identity %s does not exist", request.UserID),
@@ -99,5 +141,27 @@ func (s *SmartContract) readUserIdentity(stub shim.ChaincodeStubInterface, args
 		}
 	}
 
-	return shim.Success(userIdentityAsBytes)
+	return storedDocumentResponse(userIdentityAsBytes, "User Identity")
+}
+
+// getUserIdentity returns the user identity of the given tenant with the given id, or nil if it does not exist
+func getUserIdentity(stub shim.ChaincodeStubInterface, tenantID string, userID string) (*UserIdentity, error) {
+	userIdentityAsBytes, err := stub.GetState(tenantKey(tenantID, userID))
+	if err != nil {
+		return nil, err
+	}
+	if len(userIdentityAsBytes) == 0 || !isValidID(userID) {
+		return nil, nil
+	}
+
+	var userIdentity UserIdentity
+	err = json.Unmarshal(userIdentityAsBytes, &userIdentity)
+	if err != nil {
+		if err.Error() == "Not a UserIdentity" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &userIdentity, nil
 }
@@ -0,0 +1,80 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// putUserSigningIndexes points the index of every user at their latest signing among the given
+// ones, unless it already points at a later signing. A transaction does not read its own writes,
+// so the signings of a user are compared with each other before the index is read.
+func putUserSigningIndexes(stub shim.ChaincodeStubInterface, legalAgreementSignings []LegalAgreementSigning) error {
+	legalAgreementSigningsByTenant := map[string][]LegalAgreementSigning{}
+	for _, legalAgreementSigning := range legalAgreementSignings {
+		tenantID := legalAgreementSigning.TenantID
+		legalAgreementSigningsByTenant[tenantID] = append(legalAgreementSigningsByTenant[tenantID], legalAgreementSigning)
+	}
+
+	for _, tenantLegalAgreementSignings := range legalAgreementSigningsByTenant {
+		for _, legalAgreementSigning := range latestSigningsByUser(tenantLegalAgreementSignings) {
+			if err := putUserSigningIndex(stub, legalAgreementSigning); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// putUserSigningIndex points the index of the user of a legal agreement signing at it, unless it
+// already points at a later signing. Signings are ordered as by latestSigningsByUser.
+func putUserSigningIndex(stub shim.ChaincodeStubInterface, legalAgreementSigning LegalAgreementSigning) error {
+	key := userSigningIndexKey(legalAgreementSigning.TenantID, legalAgreementSigning.UserID)
+	indexAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+	if len(indexAsBytes) != 0 {
+		var index UserSigningIndex
+		if err := json.Unmarshal(indexAsBytes, &index); err != nil {
+			return fmt.Errorf("Error unmarshaling the signing index of user %s: %s", legalAgreementSigning.UserID, err)
+		}
+		if index.Timestamp > legalAgreementSigning.Timestamp ||
+			(index.Timestamp == legalAgreementSigning.Timestamp && index.LegalAgreementSigningID >= legalAgreementSigning.ID) {
+			return nil
+		}
+	}
+
+	index := UserSigningIndex{
+		SchemaVersion:           CurrentSchemaVersion,
+		TenantID:                legalAgreementSigning.TenantID,
+		UserID:                  legalAgreementSigning.UserID,
+		LegalAgreementSigningID: legalAgreementSigning.ID,
+		Timestamp:               legalAgreementSigning.Timestamp,
+	}
+	indexAsBytes, _ = MarshalCanonical(index)
+	return stub.PutState(key, indexAsBytes)
+}
+
+// userSigningIndexKey returns the key of the signing index of a user of the given tenant. It
+// starts with the separator, which tenant IDs cannot contain, so it never falls within the range
+// of a tenant, and the indexes of a tenant are ordered by user ID.
+func userSigningIndexKey(tenantID string, userID string) string {
+	return userSigningIndexPrefix(tenantID) + userID
+}
+
+// userSigningIndexRange returns the key range holding the signing indexes of the given tenant
+func userSigningIndexRange(tenantID string) (string, string) {
+	prefix := userSigningIndexPrefix(tenantID)
+	return prefix, prefix + string(utf8.MaxRune)
+}
+
+// userSigningIndexPrefix returns the prefix of the keys of the signing indexes of the given tenant
+func userSigningIndexPrefix(tenantID string) string {
+	return tenantKeySeparator + "signer" + tenantKeySeparator + tenantID + tenantKeySeparator
+}
@@ -0,0 +1,33 @@
+package lglagrmt
+
+import (
+	. "github.com/chaincode/common"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// newWriteResponse completes the response envelope of a write transaction with the transaction ID
+// and timestamp, emits its events and returns it marshaled. Fabric delivers a single chaincode event
+// per transaction, so the chaincode event is named after the first event and its payload lists
+// every event.
+func newWriteResponse(stub shim.ChaincodeStubInterface, response WriteResponse) ([]byte, error) {
+	timestamp, err := getTxTimestamp(stub)
+	if err != nil {
+		return nil, err
+	}
+	response.TxID = stub.GetTxID()
+	response.Timestamp = timestamp
+
+	if response.Events == nil {
+		response.Events = []Event{}
+	}
+	if len(response.Events) > 0 {
+		eventsAsBytes, _ := MarshalCanonical(response.Events)
+		if err := stub.SetEvent(response.Events[0].Name, eventsAsBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	responseAsBytes, _ := MarshalCanonical(response)
+	return responseAsBytes, nil
+}
@@ -0,0 +1,78 @@
+package lglagrmt
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteResponse(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	txID := "mockTxID"
+	var mockStub *shim.MockStub
+	chaincode := new(SmartContract)
+
+	g.Describe("Init", func() {
+		g.It("should initialize successfully", func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+
+			mockStub.MockTransactionStart(txID)
+			response := chaincode.Init(mockStub)
+			chaincode.logger.SetLevel(shim.LogError)
+			mockStub.MockTransactionEnd(txID)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+		})
+	})
+
+	g.Describe("Write Response", func() {
+		g.BeforeEach(func() {
+			mockStub = NewMockStub("mockstub", chaincode)
+		})
+
+		g.It("should return the stored document and the events of the transaction", func() {
+			args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1,"idempotencyKey":"k-001"}`)}
+			response := mockStub.MockInvoke("tx1", args)
+
+			var legalAgreement LegalAgreement
+			result := WriteResponse{Document: &legalAgreement}
+			json.Unmarshal(response.Payload, &result)
+
+			Expect(response.Status).To(BeEquivalentTo(200))
+			Expect(result.CreatedID).To(Equal("001"))
+			Expect(result.TxID).To(Equal("tx1"))
+			Expect(result.Timestamp).NotTo(BeZero())
+			contentHash, _ := ComputeContentHash("first version", DefaultHashAlgorithm)
+			Expect(legalAgreement.ContentHash).To(Equal(contentHash))
+			Expect(result.Events).To(HaveLen(1))
+			Expect(result.Events[0].Name).To(Equal(EventLegalAgreementCreated))
+
+			// The events are emitted as a single chaincode event
+			event := <-mockStub.ChaincodeEventsChannel
+			var events []Event
+			json.Unmarshal(event.Payload, &events)
+
+			Expect(event.EventName).To(Equal(EventLegalAgreementCreated))
+			Expect(events).To(Equal(result.Events))
+		})
+
+		g.It("should return the original response to a retry without emitting events again", func() {
+			args := [][]byte{[]byte("createLegalAgreement"), []byte(`{"ID":"001","content":"first version","timestamp":1654027884,"version":1,"idempotencyKey":"k-001"}`)}
+			response1 := mockStub.MockInvoke("tx1", args)
+			<-mockStub.ChaincodeEventsChannel
+
+			response2 := mockStub.MockInvoke("tx2", args)
+
+			Expect(response2.Status).To(BeEquivalentTo(200))
+			Expect(response2.Payload).To(Equal(response1.Payload))
+			Expect(mockStub.ChaincodeEventsChannel).To(BeEmpty())
+		})
+	})
+}
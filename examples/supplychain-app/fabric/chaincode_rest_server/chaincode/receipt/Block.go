@@ -0,0 +1,264 @@
+package receipt
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/chaincode/common"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// DefaultChaincode is the name the legal agreement contract is deployed with
+const DefaultChaincode = "legalagreement"
+
+// BlockOptions configures the verification of a receipt against a block
+type BlockOptions struct {
+	// Chaincode is the name of the chaincode that wrote the signing, DefaultChaincode if empty
+	Chaincode string
+	// Roots are the CA certificates the certificates of the endorsers must chain to. Without
+	// roots, an endorsement only shows that the key of its certificate signed the transaction.
+	Roots *x509.CertPool
+}
+
+// Endorser is a peer whose endorsement of the transaction of a receipt was verified
+type Endorser struct {
+	MSPID   string `json:"mspID"`
+	Subject string `json:"subject"`
+}
+
+// BlockVerification describes the transaction of a receipt in a block, and its endorsers
+type BlockVerification struct {
+	BlockNumber uint64     `json:"blockNumber"`
+	TxIndex     int        `json:"txIndex"`
+	Endorsers   []Endorser `json:"endorsers"`
+}
+
+// ecdsaSignature is the ASN.1 form of the ECDSA signatures of Fabric
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// VerifyBlock checks a receipt against the block of its transaction, as fetched from a peer or an
+// orderer. The transaction must be valid, have the timestamp of the receipt and write the signing
+// of the receipt, and every one of its endorsements must sign what it wrote.
+func VerifyBlock(receipt ConsentReceipt, blockAsBytes []byte, options BlockOptions) (*BlockVerification, error) {
+	verification, _, err := verifyBlock(receipt, blockAsBytes, options)
+	return verification, err
+}
+
+// verifyBlock verifies a receipt against the block of its transaction, and returns the endorsed
+// action of the transaction that wrote the signing of the receipt
+func verifyBlock(receipt ConsentReceipt, blockAsBytes []byte, options BlockOptions) (*BlockVerification, *pb.ChaincodeEndorsedAction, error) {
+	if err := receipt.Verify(); err != nil {
+		return nil, nil, err
+	}
+
+	var block common.Block
+	if err := proto.Unmarshal(blockAsBytes, &block); err != nil {
+		return nil, nil, fmt.Errorf("Error unmarshaling the block: %s", err)
+	}
+	if block.Header == nil || block.Data == nil {
+		return nil, nil, errors.New("The block has no header or no data")
+	}
+
+	for i, envelopeAsBytes := range block.Data.Data {
+		payload, channelHeader, err := unmarshalEnvelope(envelopeAsBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error reading transaction %d of the block: %s", i, err)
+		}
+		if channelHeader.TxId != receipt.TxID {
+			continue
+		}
+
+		if common.HeaderType(channelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+			return nil, nil, fmt.Errorf("Transaction %s is not an endorser transaction", receipt.TxID)
+		}
+		if err := verifyValidationCode(&block, i); err != nil {
+			return nil, nil, err
+		}
+		if channelHeader.Timestamp == nil || channelHeader.Timestamp.Seconds != receipt.Timestamp {
+			return nil, nil, fmt.Errorf("Transaction %s does not have the timestamp of the receipt", receipt.TxID)
+		}
+
+		action, err := findAction(receipt, payload.Data, chaincodeName(options))
+		if err != nil {
+			return nil, nil, err
+		}
+		endorsers, err := verifyEndorsements(action, time.Unix(receipt.Timestamp, 0), options.Roots)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &BlockVerification{BlockNumber: block.Header.Number, TxIndex: i, Endorsers: endorsers}, action, nil
+	}
+
+	return nil, nil, fmt.Errorf("Transaction %s is not in block %d", receipt.TxID, block.Header.Number)
+}
+
+// chaincodeName returns the name of the chaincode that wrote the signing of a receipt
+func chaincodeName(options BlockOptions) string {
+	if options.Chaincode == "" {
+		return DefaultChaincode
+	}
+	return options.Chaincode
+}
+
+// unmarshalEnvelope returns the payload of a transaction envelope and its channel header
+func unmarshalEnvelope(envelopeAsBytes []byte) (*common.Payload, *common.ChannelHeader, error) {
+	var envelope common.Envelope
+	if err := proto.Unmarshal(envelopeAsBytes, &envelope); err != nil {
+		return nil, nil, err
+	}
+	var payload common.Payload
+	if err := proto.Unmarshal(envelope.Payload, &payload); err != nil {
+		return nil, nil, err
+	}
+	if payload.Header == nil {
+		return nil, nil, errors.New("The transaction has no header")
+	}
+	var channelHeader common.ChannelHeader
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, &channelHeader); err != nil {
+		return nil, nil, err
+	}
+	return &payload, &channelHeader, nil
+}
+
+// verifyValidationCode checks that the committing peers marked a transaction of a block as valid
+func verifyValidationCode(block *common.Block, index int) error {
+	filterIndex := int(common.BlockMetadataIndex_TRANSACTIONS_FILTER)
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= filterIndex || len(block.Metadata.Metadata[filterIndex]) <= index {
+		return errors.New("The block has no validation code for the transaction")
+	}
+	code := pb.TxValidationCode(block.Metadata.Metadata[filterIndex][index])
+	if code != pb.TxValidationCode_VALID {
+		return fmt.Errorf("The transaction was invalidated by the committing peers: %s", code)
+	}
+	return nil
+}
+
+// findAction finds the action of a transaction that wrote the signing of a receipt, and checks the
+// written signing
+func findAction(receipt ConsentReceipt, transactionAsBytes []byte, chaincode string) (*pb.ChaincodeEndorsedAction, error) {
+	var transaction pb.Transaction
+	if err := proto.Unmarshal(transactionAsBytes, &transaction); err != nil {
+		return nil, fmt.Errorf("Error unmarshaling transaction %s: %s", receipt.TxID, err)
+	}
+
+	key := signingKey(receipt)
+	for _, action := range transaction.Actions {
+		var actionPayload pb.ChaincodeActionPayload
+		if err := proto.Unmarshal(action.Payload, &actionPayload); err != nil {
+			return nil, fmt.Errorf("Error unmarshaling an action of transaction %s: %s", receipt.TxID, err)
+		}
+		if actionPayload.Action == nil {
+			continue
+		}
+
+		value, err := writtenValue(actionPayload.Action.ProposalResponsePayload, chaincode, key)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading the writes of transaction %s: %s", receipt.TxID, err)
+		}
+		if value == nil {
+			continue
+		}
+		if err := verifyRecord(receipt, value); err != nil {
+			return nil, err
+		}
+		return actionPayload.Action, nil
+	}
+
+	return nil, fmt.Errorf("Transaction %s did not write signing %s", receipt.TxID, receipt.SigningID)
+}
+
+// writtenValue returns the value an action wrote at a key of a chaincode, or nil if it did not
+// write the key
+func writtenValue(proposalResponsePayloadAsBytes []byte, chaincode string, key string) ([]byte, error) {
+	var proposalResponsePayload pb.ProposalResponsePayload
+	if err := proto.Unmarshal(proposalResponsePayloadAsBytes, &proposalResponsePayload); err != nil {
+		return nil, err
+	}
+	var chaincodeAction pb.ChaincodeAction
+	if err := proto.Unmarshal(proposalResponsePayload.Extension, &chaincodeAction); err != nil {
+		return nil, err
+	}
+	var txReadWriteSet rwset.TxReadWriteSet
+	if err := proto.Unmarshal(chaincodeAction.Results, &txReadWriteSet); err != nil {
+		return nil, err
+	}
+
+	for _, nsReadWriteSet := range txReadWriteSet.NsRwset {
+		if nsReadWriteSet.Namespace != chaincode {
+			continue
+		}
+		var kvReadWriteSet kvrwset.KVRWSet
+		if err := proto.Unmarshal(nsReadWriteSet.Rwset, &kvReadWriteSet); err != nil {
+			return nil, err
+		}
+		for _, write := range kvReadWriteSet.Writes {
+			if write.Key == key && !write.IsDelete {
+				return write.Value, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// verifyEndorsements verifies the signature of every endorsement of an action over its proposal
+// response payload, and that the certificate of every endorser chains to the roots, if any, at
+// the time of the transaction
+func verifyEndorsements(action *pb.ChaincodeEndorsedAction, at time.Time, roots *x509.CertPool) ([]Endorser, error) {
+	if len(action.Endorsements) == 0 {
+		return nil, errors.New("The transaction has no endorsements")
+	}
+
+	endorsers := []Endorser{}
+	for i, endorsement := range action.Endorsements {
+		var identity msp.SerializedIdentity
+		if err := proto.Unmarshal(endorsement.Endorser, &identity); err != nil {
+			return nil, fmt.Errorf("Error unmarshaling endorser %d: %s", i, err)
+		}
+		block, _ := pem.Decode(identity.IdBytes)
+		if block == nil {
+			return nil, fmt.Errorf("Endorser %d has no PEM encoded certificate", i)
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing the certificate of endorser %d: %s", i, err)
+		}
+		if roots != nil {
+			options := x509.VerifyOptions{Roots: roots, CurrentTime: at, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+			if _, err := certificate.Verify(options); err != nil {
+				return nil, fmt.Errorf("The certificate of endorser %d is not trusted: %s", i, err)
+			}
+		}
+
+		publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("Endorser %d does not have an ECDSA key", i)
+		}
+		var signature ecdsaSignature
+		if _, err := asn1.Unmarshal(endorsement.Signature, &signature); err != nil || signature.R == nil || signature.S == nil {
+			return nil, fmt.Errorf("Invalid signature of endorser %d", i)
+		}
+		signed := append(append([]byte{}, action.ProposalResponsePayload...), endorsement.Endorser...)
+		digest := sha256.Sum256(signed)
+		if !ecdsa.Verify(publicKey, digest[:], signature.R, signature.S) {
+			return nil, fmt.Errorf("The signature of endorser %d does not match the transaction", i)
+		}
+
+		endorsers = append(endorsers, Endorser{MSPID: identity.Mspid, Subject: certificate.Subject.String()})
+	}
+	return endorsers, nil
+}
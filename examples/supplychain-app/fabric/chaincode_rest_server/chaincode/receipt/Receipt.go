@@ -0,0 +1,40 @@
+// Package receipt verifies the consent receipts of legal agreement signings without trusting the
+// server that returned them. A receipt is checked against its commitment, and then against the
+// block of the transaction that recorded it, whose endorsements sign the written signing, or
+// against a state export of the ledger. A signed receipt carries the endorsements taken from the
+// block, so it is verified without the block.
+package receipt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/chaincode/common"
+)
+
+// tenantKeySeparator separates the tenant ID from the ID in the key of a record, as in the contract
+const tenantKeySeparator = "~"
+
+// signingKey returns the key the contract writes the signing of a receipt at
+func signingKey(receipt ConsentReceipt) string {
+	if receipt.TenantID == "" {
+		return receipt.SigningID
+	}
+	return receipt.TenantID + tenantKeySeparator + receipt.SigningID
+}
+
+// verifyRecord checks that a stored legal agreement signing holds the receipt, and that the
+// fields of the receipt are the ones of the signing
+func verifyRecord(receipt ConsentReceipt, value []byte) error {
+	var legalAgreementSigning LegalAgreementSigning
+	if err := json.Unmarshal(value, &legalAgreementSigning); err != nil {
+		return fmt.Errorf("The record of signing %s is not a LegalAgreementSigning: %s", receipt.SigningID, err)
+	}
+	if legalAgreementSigning.Receipt == nil || *legalAgreementSigning.Receipt != receipt {
+		return fmt.Errorf("The receipt is not the one recorded with signing %s", receipt.SigningID)
+	}
+	if NewConsentReceipt(legalAgreementSigning, receipt.TxID, receipt.Timestamp) != receipt {
+		return fmt.Errorf("The receipt does not match the fields of signing %s", receipt.SigningID)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package receipt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/chaincode/common"
+	"github.com/chaincode/lglagrmt"
+	"github.com/chaincode/stubtest"
+
+	"github.com/franela/goblin"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+func TestReceipt(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var ledger *stubtest.Stub
+	var peer0, peer1 *stubtest.Identity
+	var receipt ConsentReceipt
+	var recorded []byte
+	var now time.Time
+
+	// newBlock returns a block holding a transaction that wrote the given value at the key of the
+	// signing, endorsed by the given peers, with the given validation code
+	newBlock := func(txID string, seconds int64, value []byte, code pb.TxValidationCode, endorsers ...*stubtest.Identity) []byte {
+		kvReadWriteSet, _ := proto.Marshal(&kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: "acme~0001", Value: value}}})
+		results, _ := proto.Marshal(&rwset.TxReadWriteSet{NsRwset: []*rwset.NsReadWriteSet{{Namespace: "legalagreement", Rwset: kvReadWriteSet}}})
+		extension, _ := proto.Marshal(&pb.ChaincodeAction{Results: results, Response: &pb.Response{Status: 200}})
+		proposalResponsePayload, _ := proto.Marshal(&pb.ProposalResponsePayload{Extension: extension})
+
+		action := &pb.ChaincodeEndorsedAction{ProposalResponsePayload: proposalResponsePayload}
+		for _, endorser := range endorsers {
+			digest := sha256.Sum256(append(append([]byte{}, proposalResponsePayload...), endorser.Serialize()...))
+			r, s, _ := ecdsa.Sign(rand.Reader, endorser.PrivateKey, digest[:])
+			signature, _ := asn1.Marshal(ecdsaSignature{R: r, S: s})
+			action.Endorsements = append(action.Endorsements, &pb.Endorsement{Endorser: endorser.Serialize(), Signature: signature})
+		}
+
+		actionPayload, _ := proto.Marshal(&pb.ChaincodeActionPayload{Action: action})
+		transaction, _ := proto.Marshal(&pb.Transaction{Actions: []*pb.TransactionAction{{Payload: actionPayload}}})
+		channelHeader, _ := proto.Marshal(&common.ChannelHeader{
+			Type:      int32(common.HeaderType_ENDORSER_TRANSACTION),
+			TxId:      txID,
+			Timestamp: &timestamp.Timestamp{Seconds: seconds},
+		})
+		payload, _ := proto.Marshal(&common.Payload{Header: &common.Header{ChannelHeader: channelHeader}, Data: transaction})
+		envelope, _ := proto.Marshal(&common.Envelope{Payload: payload})
+
+		block, _ := proto.Marshal(&common.Block{
+			Header:   &common.BlockHeader{Number: 7},
+			Data:     &common.BlockData{Data: [][]byte{envelope}},
+			Metadata: &common.BlockMetadata{Metadata: [][]byte{{}, {}, {byte(code)}}},
+		})
+		return block
+	}
+
+	g.Describe("Receipt", func() {
+		g.BeforeEach(func() {
+			admin, _ := stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			peer0, _ = stubtest.NewIdentity("Org1MSP", "peer0", nil)
+			peer1, _ = stubtest.NewIdentity("Org2MSP", "peer1", nil)
+
+			ledger = stubtest.New("legalagreement", new(lglagrmt.SmartContract))
+			ledger.SetCreator(admin)
+			// The certificates of the endorsers are checked at the time of the transaction, so it is
+			// within their validity
+			now = time.Unix(time.Now().Unix(), 0)
+			ledger.SetClock(func() time.Time { return now })
+			Expect(ledger.Init("tx0", [][]byte{[]byte("init")}).Status).To(BeEquivalentTo(200))
+
+			hash, _ := ComputeContentHash("first version", "")
+			for i, request := range []struct{ function, request string }{
+				{"onboardTenant", `{"tenantID":"acme","name":"Acme","allowedRoles":["user"]}`},
+				{"createLegalAgreement", `{"tenantID":"acme","ID":"001","content":"first version","timestamp":1654027884,"version":1}`},
+			} {
+				response := ledger.Invoke("tx"+string(rune('1'+i)), [][]byte{[]byte(request.function), []byte(request.request)})
+				Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+			}
+
+			// The user signs for themselves
+			user, _ := stubtest.NewIdentity("Org1MSP", "user", map[string]string{"role": "user", "tenantID": "acme", "userID": "u-001"})
+			ledger.SetCreator(user)
+			response := ledger.Invoke("tx3", [][]byte{[]byte("createLegalAgreementSigning"), []byte(`{"tenantID":"acme","ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"` + hash + `","accepted":true,"timestamp":1654027900}`)})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			recorded, _ = ledger.GetState("acme~0001")
+			var legalAgreementSigning LegalAgreementSigning
+			json.Unmarshal(recorded, &legalAgreementSigning)
+			receipt = *legalAgreementSigning.Receipt
+		})
+
+		g.It("should hold the signing, its transaction and a commitment", func() {
+			Expect(receipt.SigningID).To(Equal("0001"))
+			Expect(receipt.TxID).To(Equal("tx3"))
+			Expect(receipt.Timestamp).To(Equal(now.Unix()))
+			Expect(receipt.Verify()).To(Succeed())
+
+			receipt.UserID = "u-002"
+			Expect(receipt.Verify()).To(MatchError("The fields of the receipt do not match its commitment"))
+		})
+
+		g.It("should prove a single field against the commitment", func() {
+			proof, err := receipt.Prove("contentHash")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proof.Verify(receipt.Commitment)).To(Succeed())
+
+			proof.Value = json.RawMessage(`"0000"`)
+			Expect(proof.Verify(receipt.Commitment)).To(MatchError("The proof of field contentHash does not match the commitment"))
+
+			_, err = receipt.Prove("signature")
+			Expect(err).To(MatchError("Receipts have no field signature"))
+		})
+
+		g.It("should verify a receipt against the block of its transaction", func() {
+			roots := x509.NewCertPool()
+			for _, endorser := range []*stubtest.Identity{peer0, peer1} {
+				roots.AppendCertsFromPEM(endorser.Certificate)
+			}
+
+			verification, err := VerifyBlock(receipt, newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0, peer1), BlockOptions{Roots: roots})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verification.BlockNumber).To(BeEquivalentTo(7))
+			Expect(verification.Endorsers).To(Equal([]Endorser{{MSPID: "Org1MSP", Subject: "CN=peer0"}, {MSPID: "Org2MSP", Subject: "CN=peer1"}}))
+		})
+
+		g.It("should reject a tampered receipt even with a recomputed commitment", func() {
+			block := newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0)
+
+			tampered := receipt
+			tampered.Accepted = false
+			_, err := VerifyBlock(tampered, block, BlockOptions{})
+			Expect(err).To(MatchError("The fields of the receipt do not match its commitment"))
+
+			var legalAgreementSigning LegalAgreementSigning
+			json.Unmarshal(recorded, &legalAgreementSigning)
+			legalAgreementSigning.Accepted = false
+			tampered = NewConsentReceipt(legalAgreementSigning, receipt.TxID, receipt.Timestamp)
+			_, err = VerifyBlock(tampered, block, BlockOptions{})
+			Expect(err).To(MatchError("The receipt is not the one recorded with signing 0001"))
+		})
+
+		g.It("should reject a block that is not endorsed as it should be", func() {
+			block := newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID)
+			_, err := VerifyBlock(receipt, block, BlockOptions{})
+			Expect(err).To(MatchError("The transaction has no endorsements"))
+
+			other, _ := stubtest.NewIdentity("Org3MSP", "other", nil)
+			roots := x509.NewCertPool()
+			roots.AppendCertsFromPEM(other.Certificate)
+			block = newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0)
+			_, err = VerifyBlock(receipt, block, BlockOptions{Roots: roots})
+			Expect(err).To(MatchError(ContainSubstring("The certificate of endorser 0 is not trusted")))
+
+			// An endorsement signed with another key than the one of its certificate
+			impostor := &stubtest.Identity{MSPID: peer1.MSPID, Certificate: peer1.Certificate, PrivateKey: other.PrivateKey}
+			block = newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0, impostor)
+			_, err = VerifyBlock(receipt, block, BlockOptions{})
+			Expect(err).To(MatchError("The signature of endorser 1 does not match the transaction"))
+		})
+
+		g.It("should reject a transaction that is invalid, missing or from another time", func() {
+			_, err := VerifyBlock(receipt, newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_MVCC_READ_CONFLICT, peer0), BlockOptions{})
+			Expect(err).To(MatchError("The transaction was invalidated by the committing peers: MVCC_READ_CONFLICT"))
+
+			_, err = VerifyBlock(receipt, newBlock("tx4", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0), BlockOptions{})
+			Expect(err).To(MatchError("Transaction tx3 is not in block 7"))
+
+			_, err = VerifyBlock(receipt, newBlock("tx3", receipt.Timestamp+1, recorded, pb.TxValidationCode_VALID, peer0), BlockOptions{})
+			Expect(err).To(MatchError("Transaction tx3 does not have the timestamp of the receipt"))
+
+			_, err = VerifyBlock(receipt, newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0), BlockOptions{Chaincode: "other"})
+			Expect(err).To(MatchError("Transaction tx3 did not write signing 0001"))
+		})
+
+		g.It("should sign a receipt with the endorsements of its block", func() {
+			roots := x509.NewCertPool()
+			roots.AppendCertsFromPEM(peer0.Certificate)
+			block := newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0)
+
+			signedReceipt, err := NewSignedReceipt(receipt, block, BlockOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signedReceipt.Transaction.BlockNumber).To(BeEquivalentTo(7))
+			Expect(signedReceipt.Transaction.Endorsements).To(HaveLen(1))
+
+			// The signed receipt is verified without the block, after a round trip through JSON
+			signedReceiptAsBytes, _ := json.Marshal(signedReceipt)
+			var decoded SignedReceipt
+			json.Unmarshal(signedReceiptAsBytes, &decoded)
+			verification, err := VerifySignedReceipt(decoded, BlockOptions{Roots: roots})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verification.Endorsers).To(Equal([]Endorser{{MSPID: "Org1MSP", Subject: "CN=peer0"}}))
+
+			_, err = NewSignedReceipt(receipt, newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_MVCC_READ_CONFLICT, peer0), BlockOptions{})
+			Expect(err).To(MatchError("The transaction was invalidated by the committing peers: MVCC_READ_CONFLICT"))
+		})
+
+		g.It("should reject a signed receipt that was tampered with", func() {
+			signedReceipt, _ := NewSignedReceipt(receipt, newBlock("tx3", receipt.Timestamp, recorded, pb.TxValidationCode_VALID, peer0), BlockOptions{})
+
+			// A receipt with a recomputed commitment is not the one the endorsers signed
+			var legalAgreementSigning LegalAgreementSigning
+			json.Unmarshal(recorded, &legalAgreementSigning)
+			legalAgreementSigning.Accepted = false
+			tampered := *signedReceipt
+			tampered.Receipt = NewConsentReceipt(legalAgreementSigning, receipt.TxID, receipt.Timestamp)
+			_, err := VerifySignedReceipt(tampered, BlockOptions{})
+			Expect(err).To(MatchError("The receipt is not the one recorded with signing 0001"))
+
+			// A proposal response payload that writes another value is not the one the endorsers signed
+			other := newBlock("tx3", receipt.Timestamp, bytes.Replace(recorded, []byte(`"accepted":true`), []byte(`"accepted":true `), 1), pb.TxValidationCode_VALID, peer1)
+			otherReceipt, _ := NewSignedReceipt(receipt, other, BlockOptions{})
+			tampered = *signedReceipt
+			tampered.Transaction.ProposalResponsePayload = otherReceipt.Transaction.ProposalResponsePayload
+			_, err = VerifySignedReceipt(tampered, BlockOptions{})
+			Expect(err).To(MatchError("The signature of endorser 0 does not match the transaction"))
+
+			tampered = *signedReceipt
+			tampered.Transaction.Endorsements = nil
+			_, err = VerifySignedReceipt(tampered, BlockOptions{})
+			Expect(err).To(MatchError("The transaction has no endorsements"))
+		})
+
+		g.It("should verify a receipt against a state export", func() {
+			admin, _ := stubtest.NewIdentity("Org1MSP", "admin", map[string]string{"role": "admin"})
+			ledger.SetCreator(admin)
+			response := ledger.Query("export", [][]byte{[]byte("exportState"), []byte(`{}`)})
+			Expect(response.Status).To(BeEquivalentTo(200), response.Message)
+
+			Expect(VerifyStateExport(receipt, response.Payload)).To(Succeed())
+
+			receipt.SigningID = "0002"
+			receipt.Commitment = NewConsentReceipt(LegalAgreementSigning{
+				TenantID: receipt.TenantID, ID: "0002", UserID: receipt.UserID, LegalAgreementID: receipt.LegalAgreementID,
+				LegalAgreementContentHash: receipt.ContentHash, Accepted: receipt.Accepted,
+			}, receipt.TxID, receipt.Timestamp).Commitment
+			Expect(VerifyStateExport(receipt, response.Payload)).To(MatchError("Signing 0002 is not in the state export"))
+		})
+	})
+}
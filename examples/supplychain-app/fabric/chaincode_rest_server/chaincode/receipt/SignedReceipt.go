@@ -0,0 +1,85 @@
+package receipt
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/chaincode/common"
+
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SignedReceipt is a consent receipt with the signatures of the peers that endorsed the
+// transaction that recorded it, so it can be verified without the block
+type SignedReceipt struct {
+	Receipt     ConsentReceipt       `json:"receipt"`
+	Transaction TransactionReference `json:"transaction"`
+}
+
+// TransactionReference locates the transaction of a receipt in the ledger, and holds the proposal
+// response payload its endorsers signed, which writes the signing of the receipt
+type TransactionReference struct {
+	BlockNumber             uint64                 `json:"blockNumber"`
+	TxIndex                 int                    `json:"txIndex"`
+	ProposalResponsePayload []byte                 `json:"proposalResponsePayload"`
+	Endorsements            []EndorsementSignature `json:"endorsements"`
+}
+
+// EndorsementSignature is the signature of an endorser over the proposal response payload of a
+// transaction and its serialized identity, which holds its MSP ID and certificate
+type EndorsementSignature struct {
+	Endorser  []byte `json:"endorser"`
+	Signature []byte `json:"signature"`
+}
+
+// NewSignedReceipt verifies a receipt against the block of its transaction, and returns it with
+// the endorsements of the transaction
+func NewSignedReceipt(receipt ConsentReceipt, blockAsBytes []byte, options BlockOptions) (*SignedReceipt, error) {
+	verification, action, err := verifyBlock(receipt, blockAsBytes, options)
+	if err != nil {
+		return nil, err
+	}
+
+	reference := TransactionReference{
+		BlockNumber:             verification.BlockNumber,
+		TxIndex:                 verification.TxIndex,
+		ProposalResponsePayload: action.ProposalResponsePayload,
+		Endorsements:            []EndorsementSignature{},
+	}
+	for _, endorsement := range action.Endorsements {
+		reference.Endorsements = append(reference.Endorsements, EndorsementSignature{Endorser: endorsement.Endorser, Signature: endorsement.Signature})
+	}
+	return &SignedReceipt{Receipt: receipt, Transaction: reference}, nil
+}
+
+// VerifySignedReceipt checks a signed receipt against its commitment and the signatures of its
+// endorsers, whose proposal response payload must write the signing of the receipt. The
+// endorsers do not sign the block number, and the committing peers only mark the transaction as
+// valid in the block, so the block remains the proof that the transaction was committed.
+func VerifySignedReceipt(signedReceipt SignedReceipt, options BlockOptions) (*BlockVerification, error) {
+	receipt := signedReceipt.Receipt
+	if err := receipt.Verify(); err != nil {
+		return nil, err
+	}
+
+	value, err := writtenValue(signedReceipt.Transaction.ProposalResponsePayload, chaincodeName(options), signingKey(receipt))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the writes of transaction %s: %s", receipt.TxID, err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("Transaction %s did not write signing %s", receipt.TxID, receipt.SigningID)
+	}
+	if err := verifyRecord(receipt, value); err != nil {
+		return nil, err
+	}
+
+	action := &pb.ChaincodeEndorsedAction{ProposalResponsePayload: signedReceipt.Transaction.ProposalResponsePayload}
+	for _, endorsement := range signedReceipt.Transaction.Endorsements {
+		action.Endorsements = append(action.Endorsements, &pb.Endorsement{Endorser: endorsement.Endorser, Signature: endorsement.Signature})
+	}
+	endorsers, err := verifyEndorsements(action, time.Unix(receipt.Timestamp, 0), options.Roots)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockVerification{BlockNumber: signedReceipt.Transaction.BlockNumber, TxIndex: signedReceipt.Transaction.TxIndex, Endorsers: endorsers}, nil
+}
@@ -0,0 +1,29 @@
+package receipt
+
+import (
+	"fmt"
+
+	. "github.com/chaincode/common"
+)
+
+// VerifyStateExport checks a receipt against the chunks of a state export. The export is checked
+// against the hashes of its headers, but it is only as trustworthy as the admin who exported it,
+// so a block is the stronger proof.
+func VerifyStateExport(receipt ConsentReceipt, export []byte) error {
+	if err := receipt.Verify(); err != nil {
+		return err
+	}
+
+	_, records, err := DecodeStateExport(export)
+	if err != nil {
+		return fmt.Errorf("Invalid state export: %s", err)
+	}
+
+	key := signingKey(receipt)
+	for _, record := range records {
+		if record.Key == key && record.Type == RecordTypeLegalAgreementSigning {
+			return verifyRecord(receipt, record.Value)
+		}
+	}
+	return fmt.Errorf("Signing %s is not in the state export", receipt.SigningID)
+}
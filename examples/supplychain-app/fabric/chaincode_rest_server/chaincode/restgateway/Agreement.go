@@ -0,0 +1,54 @@
+package restgateway
+
+import (
+	"net/http"
+
+	"github.com/chaincode/client"
+	. "github.com/chaincode/common"
+)
+
+// createAgreement handles POST /agreements, which creates a legal agreement
+func createAgreement(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client) {
+	var request LegalAgreementRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	_, writeResponse, err := legalAgreements.CreateLegalAgreement(r.Context(), request)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", Prefix+"/agreements/"+writeResponse.CreatedID)
+	writeJSON(w, http.StatusCreated, writeResponse)
+}
+
+// readAgreement handles GET /agreements/{id}, which returns a legal agreement
+func readAgreement(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client, id string) {
+	legalAgreement, err := legalAgreements.ReadLegalAgreement(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, legalAgreement)
+}
+
+// readLatestAgreement handles GET /agreements/latest, which returns the latest published version
+// of the legal agreement
+func readLatestAgreement(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client) {
+	legalAgreement, err := legalAgreements.ReadLatestVersionLegalAgreement(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// The contract returns an empty legal agreement if no version is published
+	if legalAgreement.ID == "" {
+		writeMessage(w, http.StatusNotFound, "No Legal Agreement is published")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, legalAgreement)
+}
@@ -0,0 +1,19 @@
+package restgateway
+
+import (
+	"net/http"
+
+	"github.com/chaincode/client"
+)
+
+// readConsent handles GET /users/{id}/consent, which returns the consent status of a user against
+// the legal agreement in force
+func readConsent(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client, userID string) {
+	consentStatus, err := legalAgreements.ReadConsentStatus(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, consentStatus)
+}
@@ -0,0 +1,28 @@
+package restgateway
+
+import (
+	"net/http"
+
+	"github.com/chaincode/client"
+)
+
+// writeError translates an error of the contract into an HTTP error response with the same
+// status, or 502 when the contract cannot be reached
+func writeError(w http.ResponseWriter, err error) {
+	switch err := err.(type) {
+	case *client.NotFoundError:
+		writeMessage(w, http.StatusNotFound, err.Message)
+	case *client.ForbiddenError:
+		writeMessage(w, http.StatusForbidden, err.Message)
+	case *client.ConflictError:
+		writeMessage(w, http.StatusConflict, err.Message)
+	case *client.Error:
+		if err.Status == http.StatusBadRequest {
+			writeMessage(w, http.StatusBadRequest, err.Message)
+			return
+		}
+		writeMessage(w, http.StatusInternalServerError, err.Message)
+	default:
+		writeMessage(w, http.StatusBadGateway, err.Error())
+	}
+}
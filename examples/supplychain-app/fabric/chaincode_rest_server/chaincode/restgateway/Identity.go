@@ -0,0 +1,36 @@
+package restgateway
+
+import (
+	"net/http"
+
+	"github.com/chaincode/client"
+	. "github.com/chaincode/common"
+)
+
+// createIdentity handles POST /identities, which creates a user identity
+func createIdentity(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client) {
+	var request UserIdentityRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	_, writeResponse, err := legalAgreements.CreateUserIdentity(r.Context(), request)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", Prefix+"/identities/"+writeResponse.CreatedID)
+	writeJSON(w, http.StatusCreated, writeResponse)
+}
+
+// readIdentity handles GET /identities/{id}, which returns the identity of a user
+func readIdentity(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client, userID string) {
+	userIdentity, err := legalAgreements.ReadUserIdentity(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userIdentity)
+}
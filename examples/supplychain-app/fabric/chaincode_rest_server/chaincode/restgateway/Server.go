@@ -0,0 +1,120 @@
+package restgateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/chaincode/client"
+)
+
+// Prefix is the path prefix of the resources of the gateway
+const Prefix = "/api/v1"
+
+// HeaderTenantID names the header selecting the tenant of a request. Requests without it use the
+// default tenant.
+const HeaderTenantID = "X-Tenant-ID"
+
+// Server serves the transactions of the legal agreement contract as REST resources
+type Server struct {
+	transport client.Transport
+}
+
+// New returns a server calling the contract over the given transport
+func New(transport client.Transport) *Server {
+	return &Server{transport: transport}
+}
+
+// ServeHTTP routes a request to the handler of its resource
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, Prefix+"/") {
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, Prefix), "/"), "/")
+	legalAgreements := client.New(server.transport, r.Header.Get(HeaderTenantID))
+
+	switch {
+	case match(segments, "agreements"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodPost: func(w http.ResponseWriter, r *http.Request) { createAgreement(w, r, legalAgreements) },
+		})
+	case match(segments, "agreements", "latest"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { readLatestAgreement(w, r, legalAgreements) },
+		})
+	case match(segments, "agreements", "*"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { readAgreement(w, r, legalAgreements, segments[1]) },
+		})
+	case match(segments, "signings"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodPost: func(w http.ResponseWriter, r *http.Request) { createSigning(w, r, legalAgreements) },
+		})
+	case match(segments, "signings", "*"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { readSigning(w, r, legalAgreements, segments[1]) },
+		})
+	case match(segments, "users", "*", "consent"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { readConsent(w, r, legalAgreements, segments[1]) },
+		})
+	case match(segments, "identities"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodPost: func(w http.ResponseWriter, r *http.Request) { createIdentity(w, r, legalAgreements) },
+		})
+	case match(segments, "identities", "*"):
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { readIdentity(w, r, legalAgreements, segments[1]) },
+		})
+	default:
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+	}
+}
+
+// match reports whether the segments of a path match a pattern, where * matches any non-empty segment
+func match(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, segment := range segments {
+		if segment == "" || (pattern[i] != "*" && pattern[i] != segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// route calls the handler of the method of a request, or responds 405 if there is none
+func route(w http.ResponseWriter, r *http.Request, handlers map[string]http.HandlerFunc) {
+	handler, ok := handlers[r.Method]
+	if !ok {
+		for method := range handlers {
+			w.Header().Add("Allow", method)
+		}
+		writeMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	handler(w, r)
+}
+
+// decode reads the JSON body of a request, and responds 400 if it is invalid
+func decode(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		writeMessage(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON writes a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeMessage writes an error response with the given status and message
+func writeMessage(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,92 @@
+package restgateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chaincode/clienttest"
+	. "github.com/chaincode/common"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestServer(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var server *Server
+
+	// request sends a request to the server and returns the response
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(method, Prefix+path, strings.NewReader(body)))
+		return recorder
+	}
+
+	g.Describe("Server", func() {
+		g.BeforeEach(func() {
+			transport, err := clienttest.New()
+			Expect(err).NotTo(HaveOccurred())
+			server = New(transport)
+
+			response := request(http.MethodPost, "/agreements", `{"ID":"001","content":"first version","timestamp":1654027884,"version":1}`)
+			Expect(response.Code).To(Equal(http.StatusCreated))
+			Expect(response.Header().Get("Location")).To(Equal(Prefix + "/agreements/001"))
+		})
+
+		g.It("should map the resources onto the transactions", func() {
+			response := request(http.MethodGet, "/agreements/latest", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			var legalAgreement LegalAgreement
+			json.Unmarshal(response.Body.Bytes(), &legalAgreement)
+			Expect(legalAgreement.ID).To(Equal("001"))
+
+			response = request(http.MethodPost, "/signings", `{"ID":"0001","userID":"u-001","legalAgreementID":"001","legalAgreementContentHash":"`+legalAgreement.ContentHash+`","accepted":true,"timestamp":1654027900}`)
+			Expect(response.Code).To(Equal(http.StatusCreated))
+			Expect(response.Body.String()).To(ContainSubstring(`"createdID":"0001"`))
+
+			response = request(http.MethodGet, "/signings/0001", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			response = request(http.MethodGet, "/users/u-001/consent", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(ContainSubstring(`"status":"up-to-date"`))
+
+			response = request(http.MethodPost, "/identities", `{"userID":"u-001","legalAgreementSigningTxID":"tx2"}`)
+			Expect(response.Code).To(Equal(http.StatusCreated))
+			Expect(response.Header().Get("Location")).To(Equal(Prefix + "/identities/u-001"))
+
+			response = request(http.MethodGet, "/identities/u-001", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+		})
+
+		g.It("should translate the status codes of the contract", func() {
+			response := request(http.MethodGet, "/agreements/002", "")
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+			Expect(response.Body.String()).To(MatchJSON(`{"message":"Legal Agreement 002 does not exist"}`))
+
+			response = request(http.MethodPost, "/agreements", `{"ID":"001","content":"first version","version":2}`)
+			Expect(response.Code).To(Equal(http.StatusConflict))
+
+			response = request(http.MethodPost, "/agreements", `{"ID":"002","content":"second version","version":1}`)
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+			recorder := httptest.NewRecorder()
+			readRequest := httptest.NewRequest(http.MethodGet, Prefix+"/agreements/001", nil)
+			readRequest.Header.Set(HeaderTenantID, "acme")
+			server.ServeHTTP(recorder, readRequest)
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+
+		g.It("should reject unknown resources, methods and bodies", func() {
+			Expect(request(http.MethodGet, "/containers", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodGet, "/agreements//consent", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodDelete, "/agreements/001", "").Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(request(http.MethodPost, "/signings", "{").Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+}
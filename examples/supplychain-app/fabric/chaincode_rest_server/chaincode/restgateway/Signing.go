@@ -0,0 +1,37 @@
+package restgateway
+
+import (
+	"net/http"
+
+	"github.com/chaincode/client"
+	. "github.com/chaincode/common"
+)
+
+// createSigning handles POST /signings, which records that a user accepted or declined a legal
+// agreement
+func createSigning(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client) {
+	var request LegalAgreementSigningRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	_, writeResponse, err := legalAgreements.CreateLegalAgreementSigning(r.Context(), request)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", Prefix+"/signings/"+writeResponse.CreatedID)
+	writeJSON(w, http.StatusCreated, writeResponse)
+}
+
+// readSigning handles GET /signings/{id}, which returns a legal agreement signing
+func readSigning(w http.ResponseWriter, r *http.Request, legalAgreements *client.Client, id string) {
+	legalAgreementSigning, err := legalAgreements.ReadLegalAgreementSigning(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, legalAgreementSigning)
+}
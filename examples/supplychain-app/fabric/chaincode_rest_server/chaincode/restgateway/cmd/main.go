@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/chaincode/clienttest"
+	"github.com/chaincode/restgateway"
+	"github.com/chaincode/stubtest"
+)
+
+// main function serves the legal agreement contract over HTTP, backed by an in-process contract
+// on an in-memory ledger for local development
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	mspID := flag.String("msp", "Org1MSP", "MSP ID of the identity calling the in-process contract")
+	role := flag.String("role", "", "role attribute of the identity calling the in-process contract, none if empty")
+	tenantID := flag.String("tenant", "", "tenant attribute of the identity calling the in-process contract, none if empty")
+	userID := flag.String("user", "", "userID attribute of the identity calling the in-process contract, none if empty")
+	flag.Parse()
+
+	transport, err := clienttest.New()
+	if err != nil {
+		fmt.Printf("Error starting the in-process contract: %s\n", err)
+		os.Exit(1)
+	}
+
+	// The in-process contract is called without an identity unless attributes are given
+	if *role != "" || *tenantID != "" || *userID != "" {
+		attrs := map[string]string{}
+		if *role != "" {
+			attrs["role"] = *role
+		}
+		if *tenantID != "" {
+			attrs["tenantID"] = *tenantID
+		}
+		if *userID != "" {
+			attrs["userID"] = *userID
+		}
+		identity, err := stubtest.NewIdentity(*mspID, "rest-gateway", attrs)
+		if err != nil {
+			fmt.Printf("Error creating the identity: %s\n", err)
+			os.Exit(1)
+		}
+		transport.Ledger().SetCreator(identity)
+	}
+
+	fmt.Printf("Serving the legal agreement contract on %s%s\n", *addr, restgateway.Prefix)
+	if err := http.ListenAndServe(*addr, restgateway.New(transport)); err != nil {
+		fmt.Printf("Error serving HTTP: %s\n", err)
+		os.Exit(1)
+	}
+}
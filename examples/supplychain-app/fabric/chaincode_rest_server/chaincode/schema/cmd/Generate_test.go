@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Generate", func() {
+		g.It("should match the committed schemas, so they are regenerated with the types", func() {
+			files, err := generate("../../common", "../../lglagrmt")
+			Expect(err).NotTo(HaveOccurred())
+
+			paths, _ := filepath.Glob("../*.json")
+			Expect(paths).To(HaveLen(len(files)), "run go generate in the common package")
+			for name, content := range files {
+				committed, err := ioutil.ReadFile(filepath.Join("..", name))
+				Expect(err).NotTo(HaveOccurred(), "run go generate in the common package")
+				Expect(string(committed)).To(Equal(string(content)), "%s is out of date, run go generate in the common package", name)
+			}
+		})
+
+		g.It("should describe every transaction of the contract", func() {
+			contract, err := parseContract("../../lglagrmt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checkTransactions(contract)).To(Succeed())
+
+			contract.functions = append(contract.functions, "archiveLegalAgreement")
+			Expect(checkTransactions(contract)).To(MatchError("Transaction archiveLegalAgreement is not described in the transactions of the generator"))
+		})
+
+		g.It("should return an error for an unsupported field type", func() {
+			generator := &schemaGenerator{types: map[string]bool{}, ref: func(name string) string { return name }}
+			types, err := parseStructTypes("../../common")
+			Expect(err).NotTo(HaveOccurred())
+			for _, structType := range types {
+				if structType.name == "LegalAgreement" {
+					_, err := generator.structSchema(structType)
+					Expect(err).To(MatchError("LegalAgreement.Scopes: unsupported type ConsentScope"))
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// transaction describes a transaction of the contract. A transaction without a request takes no
+// argument, and one with an optional request can be called without it.
+type transaction struct {
+	function        string
+	request         string
+	optionalRequest bool
+	response        string
+	// submit is true for transactions that write, which respond with a WriteResponse whose
+	// document is of the response type
+	submit bool
+	// responseLines lists the types of the lines of a response in JSON Lines, instead of a
+	// response type
+	responseLines []string
+}
+
+// transactions lists the transactions of the contract, in the order of Invoke. Instantiation,
+// which Invoke also runs for init, is not a transaction.
+var transactions = []transaction{
+	{function: "createLegalAgreement", request: "LegalAgreementRequest", response: "LegalAgreement", submit: true},
+	{function: "readLegalAgreement", request: "ReadLegalAgreementRequest", response: "LegalAgreement"},
+	{function: "readLatestVersionLegalAgreement", request: "ReadLatestVersionLegalAgreementRequest", optionalRequest: true, response: "LegalAgreement"},
+	{function: "readEffectiveLegalAgreement", request: "ReadEffectiveLegalAgreementRequest", optionalRequest: true, response: "LegalAgreement"},
+	{function: "updateLegalAgreementStatus", request: "UpdateLegalAgreementStatusRequest", response: "LegalAgreement", submit: true},
+	{function: "computeContentHash", request: "ComputeContentHashRequest", response: "ComputeContentHashResponse"},
+	{function: "createLegalAgreementSigning", request: "LegalAgreementSigningRequest", response: "LegalAgreementSigning", submit: true},
+	{function: "readLegalAgreementSigning", request: "ReadLegalAgreementSigningRequest", response: "LegalAgreementSigning"},
+	{function: "readLatestLegalAgreementSigningByUserID", request: "ReadLatestLegalAgreementSigningByUserIDRequest", response: "LegalAgreementSigning"},
+	{function: "readAgreementExecutionStatus", request: "ReadAgreementExecutionStatusRequest", response: "AgreementExecutionStatus"},
+	{function: "readConsentStatus", request: "ReadConsentStatusRequest", response: "ConsentStatus"},
+	{function: "readScopeConsent", request: "ReadScopeConsentRequest", response: "ScopeConsent"},
+	{function: "listUsersRequiringReconsent", request: "ListUsersRequiringReconsentRequest", response: "ListUsersRequiringReconsentResponse"},
+	{function: "createUserIdentity", request: "UserIdentityRequest", response: "UserIdentity", submit: true},
+	{function: "readUserIdentity", request: "ReadUserIdentityRequest", response: "UserIdentity"},
+	{function: "onboardTenant", request: "OnboardTenantRequest", response: "Tenant", submit: true},
+	{function: "grantDelegation", request: "GrantDelegationRequest", response: "Delegation", submit: true},
+	{function: "revokeDelegation", request: "RevokeDelegationRequest", response: "Delegation", submit: true},
+	{function: "readDelegation", request: "ReadDelegationRequest", response: "Delegation"},
+	{function: "readTenant", request: "ReadTenantRequest", response: "Tenant"},
+	{function: "readConfig", response: "Config"},
+	{function: "updateConfig", request: "UpdateConfigRequest", response: "Config", submit: true},
+	{function: "migrate", request: "MigrateRequest", optionalRequest: true, response: "MigrationStatus", submit: true},
+	{function: "migrationStatus", response: "MigrationStatus"},
+	{function: "exportState", request: "ExportStateRequest", optionalRequest: true, responseLines: []string{"StateExportHeader", "StateExportRecord"}},
+	{function: "importState", request: "ImportStateRequest", response: "ImportStateResponse", submit: true},
+}
+
+// contract is what the generator reads from the source of the contract
+type contract struct {
+	// functions lists the functions Invoke dispatches on
+	functions []string
+	// docs holds the doc comment of the method of every function
+	docs map[string]string
+}
+
+// parseContract reads the functions of Invoke and the doc comments of the methods of the
+// SmartContract declared in the Go files of a directory
+func parseContract(dir string) (*contract, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &contract{docs: map[string]string{}}
+	fileSet := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil {
+				continue
+			}
+			result.docs[funcDecl.Name.Name] = docText(funcDecl.Doc)
+			if funcDecl.Name.Name != "Invoke" {
+				continue
+			}
+
+			ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+				if caseClause, ok := node.(*ast.CaseClause); ok {
+					for _, expr := range caseClause.List {
+						if literal, ok := expr.(*ast.BasicLit); ok && literal.Kind == token.STRING {
+							function, _ := strconv.Unquote(literal.Value)
+							result.functions = append(result.functions, function)
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	if len(result.functions) == 0 {
+		return nil, fmt.Errorf("No Invoke function found in %s", dir)
+	}
+	return result, nil
+}
+
+// checkTransactions returns an error if the transactions do not match the functions of Invoke
+func checkTransactions(contract *contract) error {
+	listed := map[string]bool{}
+	for _, transaction := range transactions {
+		listed[transaction.function] = true
+	}
+
+	invoked := map[string]bool{}
+	for _, function := range contract.functions {
+		invoked[function] = true
+		if function != "init" && !listed[function] {
+			return fmt.Errorf("Transaction %s is not described in the transactions of the generator", function)
+		}
+	}
+	for _, transaction := range transactions {
+		if !invoked[transaction.function] {
+			return fmt.Errorf("Transaction %s of the generator is not a function of Invoke", transaction.function)
+		}
+	}
+	return nil
+}
+
+// openAPIDocument returns the OpenAPI document of the transactions of the contract
+func openAPIDocument(contract *contract, schemas *orderedMap) *orderedMap {
+	ref := func(name string) *Schema { return &Schema{Ref: "#/components/schemas/" + name} }
+
+	paths := newOrderedMap()
+	for _, transaction := range transactions {
+		responseSchema := ref(transaction.response)
+		if transaction.submit {
+			document := newOrderedMap()
+			document.Set("document", ref(transaction.response))
+			responseSchema = &Schema{AllOf: []*Schema{ref("WriteResponse"), {Properties: document}}}
+		}
+
+		operation := newOrderedMap()
+		operation.Set("operationId", transaction.function)
+		if doc := methodDescription(contract.docs[transaction.function], transaction.function); doc != "" {
+			operation.Set("description", doc)
+		}
+		if transaction.submit {
+			operation.Set("x-fabric-transaction", "submit")
+		} else {
+			operation.Set("x-fabric-transaction", "evaluate")
+		}
+		if transaction.request != "" {
+			requestBody := newOrderedMap()
+			requestBody.Set("required", !transaction.optionalRequest)
+			requestBody.Set("content", jsonContent(ref(transaction.request)))
+			operation.Set("requestBody", requestBody)
+		}
+
+		success := newOrderedMap()
+		if transaction.responseLines != nil {
+			lineSchema := &Schema{}
+			for _, line := range transaction.responseLines {
+				lineSchema.OneOf = append(lineSchema.OneOf, ref(line))
+			}
+			content := newOrderedMap()
+			content.Set("application/x-ndjson", map[string]*Schema{"schema": lineSchema})
+			success.Set("description", "Payload of the successful response, with a JSON object per line")
+			success.Set("content", content)
+		} else {
+			success.Set("description", "Payload of the successful response")
+			success.Set("content", jsonContent(responseSchema))
+		}
+		responses := newOrderedMap()
+		responses.Set("200", success)
+		responses.Set("default", map[string]string{"$ref": "#/components/responses/Error"})
+		operation.Set("responses", responses)
+
+		path := newOrderedMap()
+		path.Set("post", operation)
+		paths.Set("/"+transaction.function, path)
+	}
+
+	errorContent := newOrderedMap()
+	errorContent.Set("text/plain", map[string]*Schema{"schema": {Type: "string"}})
+	errorResponse := newOrderedMap()
+	errorResponse.Set("description", "Error response of the chaincode, whose message describes the error. "+
+		"The status is 400 when the request is invalid, 403 when the identity is not allowed to run the request, "+
+		"404 when the record does not exist, 409 when the record to create already exists or the idempotency key was "+
+		"used by another request, and 500 when the ledger cannot be read or written.")
+	errorResponse.Set("content", errorContent)
+	responses := newOrderedMap()
+	responses.Set("Error", errorResponse)
+
+	info := newOrderedMap()
+	info.Set("title", "Legal Agreement Chaincode")
+	info.Set("description", "Transactions of the legal agreement chaincode. Every path names the function of a transaction, "+
+		"which is called with its JSON request body as its only argument. Transactions marked submit write to the ledger "+
+		"and must be submitted, the others only read it and can be evaluated.")
+	info.Set("version", "1")
+
+	components := newOrderedMap()
+	components.Set("schemas", schemas)
+	components.Set("responses", responses)
+
+	document := newOrderedMap()
+	document.Set("openapi", "3.1.0")
+	document.Set("info", info)
+	document.Set("paths", paths)
+	document.Set("components", components)
+	return document
+}
+
+// jsonContent returns the content of a request or response body with a JSON schema
+func jsonContent(schema *Schema) *orderedMap {
+	content := newOrderedMap()
+	content.Set("application/json", map[string]*Schema{"schema": schema})
+	return content
+}
+
+// methodDescription turns the doc comment of a method, which starts with its name, into a sentence
+func methodDescription(doc string, name string) string {
+	doc = strings.TrimSpace(strings.TrimPrefix(doc, name))
+	if doc == "" {
+		return ""
+	}
+	runes := []rune(doc)
+	runes[0] = unicode.ToUpper(runes[0])
+	description := string(runes)
+	if !strings.HasSuffix(description, ".") {
+		description += "."
+	}
+	return description
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// orderedMap is a JSON object that keeps its members in insertion order, so that properties are
+// listed in the order of the struct fields
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: map[string]interface{}{}}
+}
+
+// Set adds a member, or replaces the value of an existing one
+func (m *orderedMap) Set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		keyAsBytes, _ := marshal(key)
+		valueAsBytes, err := marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyAsBytes)
+		buffer.WriteByte(':')
+		buffer.Write(valueAsBytes)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// marshal encodes a value as JSON without escaping HTML characters
+func marshal(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+}
+
+// Schema is a JSON Schema, also used as an OpenAPI 3.1 schema object
+type Schema struct {
+	Schema               string      `json:"$schema,omitempty"`
+	ID                   string      `json:"$id,omitempty"`
+	Ref                  string      `json:"$ref,omitempty"`
+	Title                string      `json:"title,omitempty"`
+	Description          string      `json:"description,omitempty"`
+	Type                 interface{} `json:"type,omitempty"`
+	Format               string      `json:"format,omitempty"`
+	Properties           *orderedMap `json:"properties,omitempty"`
+	Required             []string    `json:"required,omitempty"`
+	Items                *Schema     `json:"items,omitempty"`
+	AdditionalProperties *Schema     `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema   `json:"oneOf,omitempty"`
+	AllOf                []*Schema   `json:"allOf,omitempty"`
+}
+
+// nullable returns a schema that also accepts null, as Go encodes nil pointers, slices and maps
+func nullable(schema *Schema) *Schema {
+	if typeName, ok := schema.Type.(string); ok {
+		schema.Type = []string{typeName, "null"}
+		return schema
+	}
+	return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+}
+
+// structType is a struct type declared in the common package
+type structType struct {
+	name string
+	doc  string
+	spec *ast.StructType
+}
+
+// parseStructTypes returns the exported struct types declared in the Go files of a directory,
+// ordered by name
+func parseStructTypes(dir string) ([]structType, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var types []structType
+	fileSet := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structSpec, ok := typeSpec.Type.(*ast.StructType)
+				if !ok || !typeSpec.Name.IsExported() {
+					continue
+				}
+				doc := typeSpec.Doc
+				if doc == nil {
+					doc = genDecl.Doc
+				}
+				types = append(types, structType{name: typeSpec.Name.Name, doc: docText(doc), spec: structSpec})
+			}
+		}
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i].name < types[j].name })
+	return types, nil
+}
+
+// docText returns the text of a doc comment, with the lines of every paragraph joined
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(doc.Text()), "\n\n") {
+		paragraphs = append(paragraphs, strings.Join(strings.Fields(paragraph), " "))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// schemaGenerator generates the schemas of the struct types of a package
+type schemaGenerator struct {
+	types map[string]bool
+	// ref returns the reference to the schema of a type
+	ref func(name string) string
+}
+
+// structSchema returns the schema of a struct type. Every field of a type that is not a request
+// is always present in its encoding, so it is required unless it is omitted when empty.
+func (generator *schemaGenerator) structSchema(structType structType) (*Schema, error) {
+	schema := &Schema{Title: structType.name, Description: structType.doc, Type: "object", Properties: newOrderedMap()}
+	isRequest := strings.HasSuffix(structType.name, "Request")
+
+	for _, field := range structType.spec.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded fields are not supported", structType.name)
+		}
+
+		name, options := field.Names[0].Name, ""
+		if field.Tag != nil {
+			tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("json")
+			if tag == "-" {
+				continue
+			}
+			if parts := strings.SplitN(tag, ",", 2); parts[0] != "" {
+				name = parts[0]
+			}
+			if parts := strings.SplitN(tag, ",", 2); len(parts) == 2 {
+				options = parts[1]
+			}
+		}
+		if !field.Names[0].IsExported() {
+			continue
+		}
+
+		fieldSchema, err := generator.schemaOf(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", structType.name, field.Names[0].Name, err)
+		}
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+		if description := docText(doc); description != "" {
+			if fieldSchema.OneOf != nil {
+				fieldSchema = &Schema{AllOf: []*Schema{fieldSchema}}
+			}
+			fieldSchema.Description = description
+		}
+
+		schema.Properties.Set(name, fieldSchema)
+		if !isRequest && !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema, nil
+}
+
+// schemaOf returns the schema of the encoding of a Go type
+func (generator *schemaGenerator) schemaOf(expr ast.Expr) (*Schema, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		switch expr.Name {
+		case "string":
+			return &Schema{Type: "string"}, nil
+		case "bool":
+			return &Schema{Type: "boolean"}, nil
+		case "int", "int64", "uint64":
+			return &Schema{Type: "integer", Format: "int64"}, nil
+		case "int32", "uint32", "int16", "uint16", "int8", "uint8":
+			return &Schema{Type: "integer", Format: "int32"}, nil
+		case "float32", "float64":
+			return &Schema{Type: "number"}, nil
+		}
+		if generator.types[expr.Name] {
+			return &Schema{Ref: generator.ref(expr.Name)}, nil
+		}
+		return nil, fmt.Errorf("unsupported type %s", expr.Name)
+	case *ast.StarExpr:
+		schema, err := generator.schemaOf(expr.X)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(schema), nil
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := generator.schemaOf(expr.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(&Schema{Type: "array", Items: items}), nil
+	case *ast.MapType:
+		if ident, ok := expr.Key.(*ast.Ident); !ok || ident.Name != "string" {
+			return nil, fmt.Errorf("unsupported map key")
+		}
+		values, err := generator.schemaOf(expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(&Schema{Type: "object", AdditionalProperties: values}), nil
+	case *ast.InterfaceType:
+		return &Schema{}, nil
+	case *ast.SelectorExpr:
+		if ident, ok := expr.X.(*ast.Ident); ok && ident.Name == "json" && expr.Sel.Name == "RawMessage" {
+			return &Schema{}, nil
+		}
+		return nil, fmt.Errorf("unsupported type %s", expr.Sel.Name)
+	default:
+		return nil, fmt.Errorf("unsupported type %T", expr)
+	}
+}
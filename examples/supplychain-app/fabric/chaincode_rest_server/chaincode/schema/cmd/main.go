@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// main function writes the JSON Schema of every struct type of the common package and the OpenAPI
+// document of the transactions of the contract. It runs with go generate in the common package.
+func main() {
+	commonDir := flag.String("common", ".", "directory of the common package")
+	contractDir := flag.String("contract", "../lglagrmt", "directory of the contract package")
+	outDir := flag.String("out", "../schema", "directory to write the schemas to")
+	flag.Parse()
+
+	files, err := generate(*commonDir, *contractDir)
+	if err != nil {
+		fmt.Printf("Error generating the schemas: %s\n", err)
+		os.Exit(1)
+	}
+
+	// Remove the schemas of types that no longer exist
+	paths, _ := filepath.Glob(filepath.Join(*outDir, "*.json"))
+	for _, path := range paths {
+		if _, ok := files[filepath.Base(path)]; !ok {
+			os.Remove(path)
+		}
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(*outDir, name), content, 0644); err != nil {
+			fmt.Printf("Error writing %s: %s\n", name, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// generate returns the content of the schema files by file name
+func generate(commonDir string, contractDir string) (map[string][]byte, error) {
+	types, err := parseStructTypes(commonDir)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := parseContract(contractDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransactions(contract); err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, structType := range types {
+		names[structType.name] = true
+	}
+	fileGenerator := &schemaGenerator{types: names, ref: func(name string) string { return name + ".schema.json" }}
+	componentGenerator := &schemaGenerator{types: names, ref: func(name string) string { return "#/components/schemas/" + name }}
+
+	files := map[string][]byte{}
+	components := newOrderedMap()
+	for _, structType := range types {
+		schema, err := fileGenerator.structSchema(structType)
+		if err != nil {
+			return nil, err
+		}
+		schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+		schema.ID = structType.name + ".schema.json"
+		if files[schema.ID], err = indent(schema); err != nil {
+			return nil, err
+		}
+
+		component, err := componentGenerator.structSchema(structType)
+		if err != nil {
+			return nil, err
+		}
+		components.Set(structType.name, component)
+	}
+
+	if files["openapi.json"], err = indent(openAPIDocument(contract, components)); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// indent encodes a value as indented JSON ending with a newline
+func indent(value interface{}) ([]byte, error) {
+	compact, err := marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err := json.Indent(&buffer, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
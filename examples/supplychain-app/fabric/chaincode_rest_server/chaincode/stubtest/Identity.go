@@ -0,0 +1,66 @@
+package stubtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/msp"
+)
+
+// attributesOID is the OID of the certificate extension holding Fabric CA attributes
+var attributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// Identity is a creator identity with a self-signed X.509 certificate
+type Identity struct {
+	MSPID       string
+	Certificate []byte
+	PrivateKey  *ecdsa.PrivateKey
+}
+
+// NewIdentity returns an identity of the given MSP whose certificate carries the given Fabric CA
+// attributes, so they can be read with the cid package
+func NewIdentity(mspID string, commonName string, attrs map[string]string) (*Identity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	attrsAsBytes, err := json.Marshal(map[string]interface{}{"attrs": attrs})
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		ExtraExtensions: []pkix.Extension{
+			{Id: attributesOID, Value: attrsAsBytes},
+		},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		MSPID:       mspID,
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}),
+		PrivateKey:  key,
+	}, nil
+}
+
+// Serialize returns the serialized identity returned by GetCreator
+func (identity *Identity) Serialize() []byte {
+	serializedIdentity, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: identity.MSPID, IdBytes: identity.Certificate})
+	return serializedIdentity
+}
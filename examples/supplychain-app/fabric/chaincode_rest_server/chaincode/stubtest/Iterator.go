@@ -0,0 +1,63 @@
+package stubtest
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+)
+
+// stateIterator iterates over the results of a query
+type stateIterator struct {
+	results []*queryresult.KV
+	index   int
+	closed  bool
+}
+
+// HasNext reports whether the iterator has another result
+func (iterator *stateIterator) HasNext() bool {
+	return !iterator.closed && iterator.index < len(iterator.results)
+}
+
+// Next returns the next result
+func (iterator *stateIterator) Next() (*queryresult.KV, error) {
+	if !iterator.HasNext() {
+		return nil, errors.New("No more results")
+	}
+	result := iterator.results[iterator.index]
+	iterator.index++
+	return result, nil
+}
+
+// Close closes the iterator
+func (iterator *stateIterator) Close() error {
+	iterator.closed = true
+	return nil
+}
+
+// historyIterator iterates over the modifications of a key
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	index         int
+	closed        bool
+}
+
+// HasNext reports whether the iterator has another modification
+func (iterator *historyIterator) HasNext() bool {
+	return !iterator.closed && iterator.index < len(iterator.modifications)
+}
+
+// Next returns the next modification
+func (iterator *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !iterator.HasNext() {
+		return nil, errors.New("No more results")
+	}
+	modification := iterator.modifications[iterator.index]
+	iterator.index++
+	return modification, nil
+}
+
+// Close closes the iterator
+func (iterator *historyIterator) Close() error {
+	iterator.closed = true
+	return nil
+}
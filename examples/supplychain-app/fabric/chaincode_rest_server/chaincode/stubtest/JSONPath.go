@@ -0,0 +1,67 @@
+package stubtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evaluateJSONPath returns the value at a JSONPath of a decoded JSON value. It supports the root
+// $, dotted fields, quoted fields in brackets and array indexes, such as $.events[0]['name'].
+func evaluateJSONPath(value interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath %s does not start with $", path)
+	}
+
+	rest := path[1:]
+	for rest != "" {
+		var segment string
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			segment, rest = rest[1:end+1], rest[end+1:]
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSONPath %s: %s is not an object", path, segment)
+			}
+			if value, ok = object[segment]; !ok {
+				return nil, fmt.Errorf("JSONPath %s: field %s does not exist", path, segment)
+			}
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %s: missing ]", path)
+			}
+			segment, rest = rest[1:end], rest[end+1:]
+			if quoted := strings.Trim(segment, `'"`); quoted != segment {
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("JSONPath %s: %s is not an object", path, quoted)
+				}
+				if value, ok = object[quoted]; !ok {
+					return nil, fmt.Errorf("JSONPath %s: field %s does not exist", path, quoted)
+				}
+				continue
+			}
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, fmt.Errorf("JSONPath %s: invalid index %s", path, segment)
+			}
+			array, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSONPath %s: index %d of a value that is not an array", path, index)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("JSONPath %s: index %d is out of range", path, index)
+			}
+			value = array[index]
+		default:
+			return nil, fmt.Errorf("JSONPath %s: unexpected %q", path, rest[0])
+		}
+	}
+
+	return value, nil
+}
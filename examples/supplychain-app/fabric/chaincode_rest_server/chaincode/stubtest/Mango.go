@@ -0,0 +1,474 @@
+package stubtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+)
+
+// mangoQuery is the subset of a CouchDB Mango query the stub supports. Indexes are not needed, so
+// use_index is accepted and ignored.
+type mangoQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+	Fields   []string               `json:"fields"`
+	UseIndex interface{}            `json:"use_index"`
+}
+
+// sortField is a field of the sort of a query
+type sortField struct {
+	path       string
+	descending bool
+}
+
+// document is a JSON value of the state matched by a query
+type document struct {
+	key   string
+	value interface{}
+}
+
+// richQuery runs a Mango query against the JSON values of a state, skipping values that are not
+// JSON objects. Results are ordered by key unless the query sorts them. Paginated queries ignore
+// the limit and skip of the query.
+func richQuery(namespace string, state map[string][]byte, queryString string, paginated bool) ([]*queryresult.KV, error) {
+	var query mangoQuery
+	if err := json.Unmarshal([]byte(queryString), &query); err != nil {
+		return nil, fmt.Errorf("Invalid query: %s", err)
+	}
+	if query.Selector == nil {
+		return nil, errors.New("Invalid query: the selector is missing")
+	}
+	sortFields, err := parseSort(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []document
+	for _, key := range sortedKeys(state) {
+		var value map[string]interface{}
+		if err := json.Unmarshal(state[key], &value); err != nil {
+			continue
+		}
+		matched, err := matchSelector(query.Selector, value)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			documents = append(documents, document{key: key, value: value})
+		}
+	}
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		for _, field := range sortFields {
+			a, _ := lookup(documents[i].value, field.path)
+			b, _ := lookup(documents[j].value, field.path)
+			if c := collate(a, b); c != 0 {
+				return (c < 0) != field.descending
+			}
+		}
+		return false
+	})
+
+	if !paginated {
+		if query.Skip > 0 {
+			if query.Skip > len(documents) {
+				query.Skip = len(documents)
+			}
+			documents = documents[query.Skip:]
+		}
+		if query.Limit > 0 && len(documents) > query.Limit {
+			documents = documents[:query.Limit]
+		}
+	}
+
+	results := make([]*queryresult.KV, 0, len(documents))
+	for _, document := range documents {
+		value := state[document.key]
+		if len(query.Fields) > 0 {
+			value, _ = json.Marshal(project(document.value, query.Fields))
+		}
+		results = append(results, &queryresult.KV{Namespace: namespace, Key: document.key, Value: value})
+	}
+	return results, nil
+}
+
+// parseSort parses a sort given as field names or as objects mapping a field name to asc or desc
+func parseSort(sortSpec []interface{}) ([]sortField, error) {
+	var fields []sortField
+	for _, item := range sortSpec {
+		switch item := item.(type) {
+		case string:
+			fields = append(fields, sortField{path: item})
+		case map[string]interface{}:
+			if len(item) != 1 {
+				return nil, errors.New("Invalid sort: each field is sorted in its own object")
+			}
+			for path, direction := range item {
+				if direction != "asc" && direction != "desc" {
+					return nil, fmt.Errorf("Invalid sort direction %v. Expecting asc or desc", direction)
+				}
+				fields = append(fields, sortField{path: path, descending: direction == "desc"})
+			}
+		default:
+			return nil, fmt.Errorf("Invalid sort field %v", item)
+		}
+	}
+	return fields, nil
+}
+
+// matchSelector reports whether a value matches a selector. Every field of the selector must match.
+func matchSelector(selector map[string]interface{}, value interface{}) (bool, error) {
+	for field, condition := range selector {
+		var matched bool
+		var err error
+
+		switch field {
+		case "$and", "$or", "$nor":
+			matched, err = matchCombination(field, condition, value)
+		case "$not":
+			subselector, ok := condition.(map[string]interface{})
+			if !ok {
+				return false, errors.New("Invalid query: $not expects a selector")
+			}
+			matched, err = matchSelector(subselector, value)
+			matched = !matched
+		default:
+			fieldValue, exists := lookup(value, field)
+			matched, err = matchCondition(fieldValue, exists, condition)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchCombination applies $and, $or or $nor to a list of selectors
+func matchCombination(operator string, condition interface{}, value interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("Invalid query: %s expects a list of selectors", operator)
+	}
+
+	matchedCount := 0
+	for _, item := range selectors {
+		selector, ok := item.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("Invalid query: %s expects a list of selectors", operator)
+		}
+		matched, err := matchSelector(selector, value)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			matchedCount++
+		}
+	}
+
+	switch operator {
+	case "$and":
+		return matchedCount == len(selectors), nil
+	case "$or":
+		return matchedCount > 0, nil
+	default:
+		return matchedCount == 0, nil
+	}
+}
+
+// matchCondition reports whether the value of a field matches a condition. The condition is either
+// an object of operators, a nested selector, or a value the field must equal.
+func matchCondition(value interface{}, exists bool, condition interface{}) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return exists && collate(value, condition) == 0, nil
+	}
+	if !isOperatorObject(operators) {
+		if _, isObject := value.(map[string]interface{}); !exists || !isObject {
+			return false, nil
+		}
+		return matchSelector(operators, value)
+	}
+
+	for operator, argument := range operators {
+		matched, err := matchOperator(value, exists, operator, argument)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchOperator reports whether the value of a field matches a single operator. Missing fields
+// only match $exists false.
+func matchOperator(value interface{}, exists bool, operator string, argument interface{}) (bool, error) {
+	if operator == "$exists" {
+		expected, ok := argument.(bool)
+		if !ok {
+			return false, errors.New("Invalid query: $exists expects a boolean")
+		}
+		return exists == expected, nil
+	}
+	if operator == "$not" {
+		matched, err := matchCondition(value, exists, argument)
+		return !matched, err
+	}
+	if !exists {
+		return false, nil
+	}
+
+	switch operator {
+	case "$eq":
+		return collate(value, argument) == 0, nil
+	case "$ne":
+		return collate(value, argument) != 0, nil
+	case "$gt":
+		return collate(value, argument) > 0, nil
+	case "$gte":
+		return collate(value, argument) >= 0, nil
+	case "$lt":
+		return collate(value, argument) < 0, nil
+	case "$lte":
+		return collate(value, argument) <= 0, nil
+	case "$in", "$nin":
+		candidates, ok := argument.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("Invalid query: %s expects a list", operator)
+		}
+		found := false
+		for _, candidate := range candidates {
+			if collate(value, candidate) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (operator == "$in"), nil
+	case "$type":
+		return typeName(value) == argument, nil
+	case "$size":
+		array, ok := value.([]interface{})
+		size, isNumber := argument.(float64)
+		if !isNumber {
+			return false, errors.New("Invalid query: $size expects a number")
+		}
+		return ok && float64(len(array)) == size, nil
+	case "$mod":
+		parameters, ok := argument.([]interface{})
+		if !ok || len(parameters) != 2 {
+			return false, errors.New("Invalid query: $mod expects a divisor and a remainder")
+		}
+		divisor, ok1 := parameters[0].(float64)
+		remainder, ok2 := parameters[1].(float64)
+		number, isNumber := value.(float64)
+		if !ok1 || !ok2 || divisor == 0 {
+			return false, errors.New("Invalid query: $mod expects a non zero divisor and a remainder")
+		}
+		return isNumber && number == math.Trunc(number) && math.Mod(number, divisor) == remainder, nil
+	case "$regex":
+		pattern, ok := argument.(string)
+		if !ok {
+			return false, errors.New("Invalid query: $regex expects a string")
+		}
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("Invalid query: %s", err)
+		}
+		text, isString := value.(string)
+		return isString && expression.MatchString(text), nil
+	case "$all":
+		expected, ok := argument.([]interface{})
+		if !ok {
+			return false, errors.New("Invalid query: $all expects a list")
+		}
+		array, isArray := value.([]interface{})
+		if !isArray {
+			return false, nil
+		}
+		for _, item := range expected {
+			if !containsValue(array, item) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$elemMatch", "$allMatch":
+		array, isArray := value.([]interface{})
+		if !isArray || (operator == "$allMatch" && len(array) == 0) {
+			return false, nil
+		}
+		for _, element := range array {
+			matched, err := matchCondition(element, true, argument)
+			if err != nil {
+				return false, err
+			}
+			if matched && operator == "$elemMatch" {
+				return true, nil
+			}
+			if !matched && operator == "$allMatch" {
+				return false, nil
+			}
+		}
+		return operator == "$allMatch", nil
+	default:
+		return false, fmt.Errorf("Invalid query: unsupported operator %s", operator)
+	}
+}
+
+// isOperatorObject reports whether every field of a condition is an operator
+func isOperatorObject(condition map[string]interface{}) bool {
+	if len(condition) == 0 {
+		return false
+	}
+	for field := range condition {
+		if !strings.HasPrefix(field, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the value at a dotted path of a JSON value
+func lookup(value interface{}, path string) (interface{}, bool) {
+	for _, field := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[field]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// project returns the fields of a JSON object at the given dotted paths
+func project(value interface{}, paths []string) map[string]interface{} {
+	projection := map[string]interface{}{}
+	for _, path := range paths {
+		fieldValue, ok := lookup(value, path)
+		if !ok {
+			continue
+		}
+		fields := strings.Split(path, ".")
+		object := projection
+		for _, field := range fields[:len(fields)-1] {
+			next, ok := object[field].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				object[field] = next
+			}
+			object = next
+		}
+		object[fields[len(fields)-1]] = fieldValue
+	}
+	return projection
+}
+
+// containsValue reports whether an array contains a value
+func containsValue(array []interface{}, value interface{}) bool {
+	for _, item := range array {
+		if collate(item, value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the Mango type name of a JSON value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// typeRank orders JSON types as CouchDB collates them
+func typeRank(value interface{}) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// collate compares two JSON values in CouchDB collation order: null, false, true, numbers,
+// strings, arrays and objects. Strings are compared by code point rather than with ICU.
+func collate(a interface{}, b interface{}) int {
+	if rankA, rankB := typeRank(a), typeRank(b); rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := collate(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(b)
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		keysA, keysB := objectKeys(a), objectKeys(b)
+		for i := 0; i < len(keysA) && i < len(keysB); i++ {
+			if c := strings.Compare(keysA[i], keysB[i]); c != 0 {
+				return c
+			}
+			if c := collate(a[keysA[i]], b[keysB[i]]); c != 0 {
+				return c
+			}
+		}
+		return len(keysA) - len(keysB)
+	}
+	return 0
+}
+
+// objectKeys returns the keys of a JSON object in order
+func objectKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
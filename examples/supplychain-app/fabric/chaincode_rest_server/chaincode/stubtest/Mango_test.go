@@ -0,0 +1,73 @@
+package stubtest
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestMango(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var stub *Stub
+
+	// query runs a rich query and returns the keys of its results
+	query := func(queryString string) []string {
+		iterator, err := stub.GetQueryResult(queryString)
+		Expect(err).NotTo(HaveOccurred())
+		return keys(iterator)
+	}
+
+	g.Describe("Rich Queries", func() {
+		g.BeforeEach(func() {
+			stub = New("kv", kvChaincode{})
+			stub.Begin("tx1", nil)
+			stub.PutState("001", []byte(`{"version":1,"status":"superseded","owner":{"msp":"Org1MSP"},"tags":["a","b"]}`))
+			stub.PutState("002", []byte(`{"version":2,"status":"published","owner":{"msp":"Org2MSP"},"tags":["b"]}`))
+			stub.PutState("003", []byte(`{"version":3,"status":"draft","owner":{"msp":"Org1MSP"}}`))
+			stub.PutState("004", []byte(`not json`))
+			stub.Commit()
+		})
+
+		g.It("should match fields by value, operator and nested path", func() {
+			Expect(query(`{"selector":{"status":"published"}}`)).To(Equal([]string{"002"}))
+			Expect(query(`{"selector":{"version":{"$gte":2}}}`)).To(Equal([]string{"002", "003"}))
+			Expect(query(`{"selector":{"owner.msp":"Org1MSP"}}`)).To(Equal([]string{"001", "003"}))
+			Expect(query(`{"selector":{"owner":{"msp":"Org2MSP"}}}`)).To(Equal([]string{"002"}))
+			Expect(query(`{"selector":{"status":{"$in":["draft","published"]}}}`)).To(Equal([]string{"002", "003"}))
+			Expect(query(`{"selector":{"tags":{"$exists":false}}}`)).To(Equal([]string{"003"}))
+			Expect(query(`{"selector":{"tags":{"$elemMatch":{"$eq":"a"}}}}`)).To(Equal([]string{"001"}))
+			Expect(query(`{"selector":{"status":{"$regex":"^d"}}}`)).To(Equal([]string{"003"}))
+		})
+
+		g.It("should combine selectors", func() {
+			Expect(query(`{"selector":{"$or":[{"version":1},{"status":"draft"}]}}`)).To(Equal([]string{"001", "003"}))
+			Expect(query(`{"selector":{"$and":[{"owner.msp":"Org1MSP"},{"version":{"$lt":3}}]}}`)).To(Equal([]string{"001"}))
+			Expect(query(`{"selector":{"$not":{"owner.msp":"Org1MSP"}}}`)).To(Equal([]string{"002"}))
+		})
+
+		g.It("should sort, skip, limit and project the results", func() {
+			Expect(query(`{"selector":{},"sort":[{"version":"desc"}],"skip":1,"limit":1}`)).To(Equal([]string{"002"}))
+
+			iterator, _ := stub.GetQueryResult(`{"selector":{"version":1},"fields":["owner.msp"]}`)
+			item, _ := iterator.Next()
+			Expect(item.Value).To(MatchJSON(`{"owner":{"msp":"Org1MSP"}}`))
+		})
+
+		g.It("should page through the results with bookmarks", func() {
+			iterator, metadata, _ := stub.GetQueryResultWithPagination(`{"selector":{}}`, 2, "")
+			Expect(keys(iterator)).To(Equal([]string{"001", "002"}))
+
+			iterator, metadata, _ = stub.GetQueryResultWithPagination(`{"selector":{}}`, 2, metadata.Bookmark)
+			Expect(keys(iterator)).To(Equal([]string{"003"}))
+			Expect(metadata.Bookmark).To(BeEmpty())
+		})
+
+		g.It("should return an error for an unsupported operator", func() {
+			_, err := stub.GetQueryResult(`{"selector":{"version":{"$near":1}}}`)
+			Expect(err).To(MatchError("Invalid query: unsupported operator $near"))
+		})
+	})
+}
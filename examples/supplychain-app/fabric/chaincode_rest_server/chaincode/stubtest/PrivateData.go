@@ -0,0 +1,168 @@
+package stubtest
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// collection holds the committed state of a private data collection
+type collection struct {
+	state                map[string][]byte
+	validationParameters map[string][]byte
+}
+
+// apply commits the writes of a transaction to the collection
+func (collection *collection) apply(writes map[string]*write, validationParameters map[string][]byte) {
+	for key, write := range writes {
+		if write.deleted {
+			delete(collection.state, key)
+		} else {
+			collection.state[key] = write.value
+		}
+	}
+	for key, parameter := range validationParameters {
+		collection.validationParameters[key] = parameter
+	}
+}
+
+// DefineCollection defines a private data collection. Like on a peer, private data can only be
+// read from and written to defined collections.
+func (stub *Stub) DefineCollection(name string) {
+	stub.collections[name] = &collection{state: map[string][]byte{}, validationParameters: map[string][]byte{}}
+}
+
+// GetPrivateData returns the committed value of a key of a collection
+func (stub *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	c, err := stub.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	return c.state[key], nil
+}
+
+// GetPrivateDataHash returns the SHA-256 hash of the committed value of a key of a collection
+func (stub *Stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	c, err := stub.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := c.state[key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+// PutPrivateData writes a key of a collection when the transaction commits
+func (stub *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if err := stub.validatePrivateWrite(collection, key); err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return fmt.Errorf("value for key %s in collection %s must not be empty", key, collection)
+	}
+	stub.privateWrites(collection)[key] = &write{value: value}
+	return nil
+}
+
+// DelPrivateData deletes a key of a collection when the transaction commits
+func (stub *Stub) DelPrivateData(collection, key string) error {
+	if err := stub.validatePrivateWrite(collection, key); err != nil {
+		return err
+	}
+	stub.privateWrites(collection)[key] = &write{deleted: true}
+	return nil
+}
+
+// SetPrivateDataValidationParameter sets the key-level endorsement policy of a key of a collection
+// when the transaction commits
+func (stub *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	if err := stub.validatePrivateWrite(collection, key); err != nil {
+		return err
+	}
+	if stub.tx.privateValidationParameters[collection] == nil {
+		stub.tx.privateValidationParameters[collection] = map[string][]byte{}
+	}
+	stub.tx.privateValidationParameters[collection][key] = ep
+	return nil
+}
+
+// GetPrivateDataValidationParameter returns the committed key-level endorsement policy of a key of a collection
+func (stub *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	c, err := stub.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	return c.validationParameters[key], nil
+}
+
+// GetPrivateDataByRange returns the committed keys of a collection between startKey and endKey
+func (stub *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	c, err := stub.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	results, err := rangeQuery(stub.Name, c.state, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return &stateIterator{results: results}, nil
+}
+
+// GetPrivateDataByPartialCompositeKey returns the committed composite keys of a collection starting
+// with the given object type and attributes
+func (stub *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	c, err := stub.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	results, err := partialCompositeKeyQuery(stub.Name, c.state, objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &stateIterator{results: results}, nil
+}
+
+// GetPrivateDataQueryResult runs a Mango query against the committed JSON values of a collection
+func (stub *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	c, err := stub.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	results, err := richQuery(stub.Name, c.state, query, false)
+	if err != nil {
+		return nil, err
+	}
+	return &stateIterator{results: results}, nil
+}
+
+// collection returns the defined collection with the given name
+func (stub *Stub) collection(name string) (*collection, error) {
+	if name == "" {
+		return nil, fmt.Errorf("collection must not be an empty string")
+	}
+	c, ok := stub.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("collection %s is not defined", name)
+	}
+	return c, nil
+}
+
+// validatePrivateWrite checks that a key of a defined collection can be written
+func (stub *Stub) validatePrivateWrite(collection string, key string) error {
+	if _, err := stub.collection(collection); err != nil {
+		return err
+	}
+	return stub.validateWrite(key)
+}
+
+// privateWrites returns the pending writes of a collection in the transaction in progress
+func (stub *Stub) privateWrites(collection string) map[string]*write {
+	if stub.tx.privateWrites[collection] == nil {
+		stub.tx.privateWrites[collection] = map[string]*write{}
+	}
+	return stub.tx.privateWrites[collection]
+}
@@ -0,0 +1,229 @@
+package stubtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	compositeKeyNamespace = "\x00"
+	minUnicodeRuneValue   = 0
+	maxUnicodeRuneValue   = utf8.MaxRune
+	// emptyKeySubstitute replaces an empty start key, so simple range queries skip composite keys
+	emptyKeySubstitute = "\x01"
+)
+
+// GetStateByRange returns the committed keys from startKey, inclusive, to endKey, exclusive, in
+// byte order. An empty endKey leaves the range open ended.
+func (stub *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	results, err := rangeQuery(stub.Name, stub.state, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return &stateIterator{results: results}, nil
+}
+
+// GetStateByRangeWithPagination returns a page of a range query. The bookmark is the key the next
+// page starts from, and is empty on the last page.
+func (stub *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	results, err := rangeQuery(stub.Name, stub.state, startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return paginateByKey(results, pageSize)
+}
+
+// GetStateByPartialCompositeKey returns the committed composite keys starting with the given
+// object type and attributes
+func (stub *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	results, err := partialCompositeKeyQuery(stub.Name, stub.state, objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &stateIterator{results: results}, nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination returns a page of a partial composite key query
+func (stub *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := partialCompositeKeyQuery(stub.Name, stub.state, objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bookmark != "" {
+		results = keysFrom(results, bookmark)
+	}
+	return paginateByKey(results, pageSize)
+}
+
+// CreateCompositeKey joins the object type and the attributes into a composite key
+func (stub *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return createCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits a composite key into its object type and attributes
+func (stub *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if len(compositeKey) < 2 || compositeKey[:1] != compositeKeyNamespace {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+
+	components := []string{}
+	componentIndex := 1
+	for i := 1; i < len(compositeKey); i++ {
+		if compositeKey[i] == minUnicodeRuneValue {
+			components = append(components, compositeKey[componentIndex:i])
+			componentIndex = i + 1
+		}
+	}
+	return components[0], components[1:], nil
+}
+
+// GetQueryResult runs a Mango query against the committed JSON values
+func (stub *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := richQuery(stub.Name, stub.state, query, false)
+	if err != nil {
+		return nil, err
+	}
+	return &stateIterator{results: results}, nil
+}
+
+// GetQueryResultWithPagination returns a page of a Mango query. The page size replaces the limit
+// of the query, and the bookmark is opaque.
+func (stub *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := richQuery(stub.Name, stub.state, query, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offset := 0
+	if bookmark != "" {
+		if offset, err = strconv.Atoi(bookmark); err != nil || offset < 0 {
+			return nil, nil, fmt.Errorf("Invalid bookmark %s", bookmark)
+		}
+	}
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+
+	nextBookmark := ""
+	if pageSize > 0 && len(results) > int(pageSize) {
+		results = results[:pageSize]
+		nextBookmark = strconv.Itoa(offset + int(pageSize))
+	}
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(results)), Bookmark: nextBookmark}
+	return &stateIterator{results: results}, metadata, nil
+}
+
+// rangeQuery returns the keys of a state between startKey and endKey in byte order
+func rangeQuery(namespace string, state map[string][]byte, startKey string, endKey string) ([]*queryresult.KV, error) {
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+
+	var results []*queryresult.KV
+	for _, key := range sortedKeys(state) {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			results = append(results, &queryresult.KV{Namespace: namespace, Key: key, Value: state[key]})
+		}
+	}
+	return results, nil
+}
+
+// partialCompositeKeyQuery returns the composite keys of a state starting with the given prefix
+func partialCompositeKeyQuery(namespace string, state map[string][]byte, objectType string, attributes []string) ([]*queryresult.KV, error) {
+	prefix, err := createCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*queryresult.KV
+	for _, key := range sortedKeys(state) {
+		if key >= prefix && key < prefix+string(maxUnicodeRuneValue) {
+			results = append(results, &queryresult.KV{Namespace: namespace, Key: key, Value: state[key]})
+		}
+	}
+	return results, nil
+}
+
+// paginateByKey returns the first page of the results, with the next key as the bookmark
+func paginateByKey(results []*queryresult.KV, pageSize int32) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.New("pageSize must be greater than zero")
+	}
+
+	bookmark := ""
+	if len(results) > int(pageSize) {
+		bookmark = results[pageSize].Key
+		results = results[:pageSize]
+	}
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(results)), Bookmark: bookmark}
+	return &stateIterator{results: results}, metadata, nil
+}
+
+// keysFrom returns the results whose key is not before the given key
+func keysFrom(results []*queryresult.KV, key string) []*queryresult.KV {
+	i := sort.Search(len(results), func(i int) bool { return results[i].Key >= key })
+	return results[i:]
+}
+
+// sortedKeys returns the keys of a state in byte order
+func sortedKeys(state map[string][]byte) []string {
+	keys := make([]string, 0, len(state))
+	for key := range state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// createCompositeKey joins the object type and the attributes, each followed by U+0000, after the
+// composite key namespace
+func createCompositeKey(objectType string, attributes []string) (string, error) {
+	if err := validateCompositeKeyAttribute(objectType); err != nil {
+		return "", err
+	}
+	compositeKey := compositeKeyNamespace + objectType + string(rune(minUnicodeRuneValue))
+	for _, attribute := range attributes {
+		if err := validateCompositeKeyAttribute(attribute); err != nil {
+			return "", err
+		}
+		compositeKey += attribute + string(rune(minUnicodeRuneValue))
+	}
+	return compositeKey, nil
+}
+
+// validateCompositeKeyAttribute checks that an attribute is valid utf8 without U+0000 or U+10FFFF
+func validateCompositeKeyAttribute(attribute string) error {
+	if !utf8.ValidString(attribute) {
+		return fmt.Errorf("not a valid utf8 string: [%x]", attribute)
+	}
+	for index, runeValue := range attribute {
+		if runeValue == minUnicodeRuneValue || runeValue == maxUnicodeRuneValue {
+			return fmt.Errorf("input contains unicode %#U starting at position [%d]", runeValue, index)
+		}
+	}
+	return nil
+}
+
+// validateSimpleKeys checks that range query keys do not fall in the composite key namespace
+func validateSimpleKeys(keys ...string) error {
+	for _, key := range keys {
+		if len(key) > 0 && key[:1] == compositeKeyNamespace {
+			return fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+	return nil
+}
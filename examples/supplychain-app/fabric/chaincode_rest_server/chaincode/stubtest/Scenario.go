@@ -0,0 +1,300 @@
+package stubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"gopkg.in/yaml.v2"
+)
+
+// Scenario describes a sequence of invocations of a chaincode and their expected results. It is
+// read from a YAML or JSON file, so cases can be added without writing Go.
+type Scenario struct {
+	Name        string                      `yaml:"name"`
+	Description string                      `yaml:"description"`
+	Identities  map[string]ScenarioIdentity `yaml:"identities"`
+	Collections []string                    `yaml:"collections"`
+	Time        int64                       `yaml:"time"`
+	Steps       []Step                      `yaml:"steps"`
+}
+
+// ScenarioIdentity describes an identity that submits steps of a scenario
+type ScenarioIdentity struct {
+	MSPID string            `yaml:"mspID"`
+	Attrs map[string]string `yaml:"attrs"`
+}
+
+// Step is an invocation of a scenario. Arguments that are not strings are passed as JSON.
+type Step struct {
+	Name      string            `yaml:"name"`
+	TxID      string            `yaml:"txID"`
+	Identity  string            `yaml:"identity"`
+	Time      int64             `yaml:"time"`
+	Init      bool              `yaml:"init"`
+	Function  string            `yaml:"function"`
+	Args      []interface{}     `yaml:"args"`
+	Transient map[string]string `yaml:"transient"`
+	Expect    Expectation       `yaml:"expect"`
+}
+
+// Expectation is the expected result of a step. The status defaults to 200, and the payload and
+// events are only checked when given. An empty list of events expects none.
+type Expectation struct {
+	Status   int32                  `yaml:"status"`
+	Message  string                 `yaml:"message"`
+	Payload  interface{}            `yaml:"payload"`
+	JSONPath map[string]interface{} `yaml:"jsonPath"`
+	Events   []ExpectedEvent        `yaml:"events"`
+}
+
+// ExpectedEvent is an expected chaincode event of a step
+type ExpectedEvent struct {
+	Name     string                 `yaml:"name"`
+	Payload  interface{}            `yaml:"payload"`
+	JSONPath map[string]interface{} `yaml:"jsonPath"`
+}
+
+// LoadScenario reads a scenario from a YAML or JSON file
+func LoadScenario(path string) (*Scenario, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario Scenario
+	if err := yaml.UnmarshalStrict(bytes, &scenario); err != nil {
+		return nil, fmt.Errorf("Error reading scenario %s: %s", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &scenario, nil
+}
+
+// RunScenarios runs every scenario file of a directory as a subtest, each against a new ledger
+// with a new chaincode
+func RunScenarios(t *testing.T, dir string, newChaincode func() shim.Chaincode) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		t.Fatalf("No scenarios in %s", dir)
+	}
+
+	for _, path := range paths {
+		scenario, err := LoadScenario(path)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		t.Run(scenario.Name, func(t *testing.T) {
+			RunScenario(t, scenario, newChaincode())
+		})
+	}
+}
+
+// RunScenario runs the steps of a scenario in order. It stops at the first step whose result is
+// not the expected one, as the next steps depend on it.
+func RunScenario(t *testing.T, scenario *Scenario, chaincode shim.Chaincode) {
+	stub := New(scenario.Name, chaincode)
+	for _, collection := range scenario.Collections {
+		stub.DefineCollection(collection)
+	}
+
+	identities := map[string]*Identity{}
+	for name, scenarioIdentity := range scenario.Identities {
+		identity, err := NewIdentity(scenarioIdentity.MSPID, name, scenarioIdentity.Attrs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		identities[name] = identity
+	}
+
+	for i, step := range scenario.Steps {
+		name := step.Name
+		if name == "" {
+			name = step.Function
+		}
+
+		errs := runStep(stub, identities, scenario.Time, i, step)
+		if len(errs) > 0 {
+			t.Fatalf("Step %d (%s):\n  %s", i+1, name, strings.Join(errs, "\n  "))
+		}
+	}
+}
+
+// runStep runs a step and returns how its result differs from the expected one
+func runStep(stub *Stub, identities map[string]*Identity, scenarioTime int64, index int, step Step) []string {
+	var identity *Identity
+	if step.Identity != "" {
+		var ok bool
+		if identity, ok = identities[step.Identity]; !ok {
+			return []string{fmt.Sprintf("Unknown identity %s", step.Identity)}
+		}
+	}
+	stub.SetCreator(identity)
+
+	transient := map[string][]byte{}
+	for key, value := range step.Transient {
+		transient[key] = []byte(value)
+	}
+	stub.SetTransient(transient)
+
+	stepTime := step.Time
+	if stepTime == 0 {
+		stepTime = scenarioTime
+	}
+	if stepTime != 0 {
+		stub.SetClock(func() time.Time { return time.Unix(stepTime, 0) })
+	} else {
+		stub.SetClock(time.Now)
+	}
+
+	args := [][]byte{[]byte(step.Function)}
+	for _, arg := range step.Args {
+		if text, ok := arg.(string); ok {
+			args = append(args, []byte(text))
+			continue
+		}
+		argAsBytes, err := json.Marshal(normalizeYAML(arg))
+		if err != nil {
+			return []string{fmt.Sprintf("Invalid argument: %s", err)}
+		}
+		args = append(args, argAsBytes)
+	}
+
+	txID := step.TxID
+	if txID == "" {
+		txID = fmt.Sprintf("tx%d", index+1)
+	}
+	eventsCount := len(stub.Events())
+
+	var response pb.Response
+	if step.Init {
+		response = stub.Init(txID, args)
+	} else {
+		response = stub.Invoke(txID, args)
+	}
+
+	return checkResponse(step.Expect, response, stub.Events()[eventsCount:])
+}
+
+// checkResponse compares the response and the events of a step with the expected ones
+func checkResponse(expect Expectation, response pb.Response, events []*pb.ChaincodeEvent) []string {
+	var errs []string
+
+	status := expect.Status
+	if status == 0 {
+		status = shim.OK
+	}
+	if response.Status != status {
+		errs = append(errs, fmt.Sprintf("Expected status %d, got %d: %s", status, response.Status, response.Message))
+	}
+	if expect.Message != "" && response.Message != expect.Message {
+		errs = append(errs, fmt.Sprintf("Expected message %q, got %q", expect.Message, response.Message))
+	}
+	errs = append(errs, checkJSON("payload", response.Payload, expect.Payload, expect.JSONPath)...)
+
+	if expect.Events != nil {
+		if len(events) != len(expect.Events) {
+			return append(errs, fmt.Sprintf("Expected %d events, got %d", len(expect.Events), len(events)))
+		}
+		for i, expected := range expect.Events {
+			if events[i].EventName != expected.Name {
+				errs = append(errs, fmt.Sprintf("Expected event %s, got %s", expected.Name, events[i].EventName))
+			}
+			errs = append(errs, checkJSON("event "+expected.Name, events[i].Payload, expected.Payload, expected.JSONPath)...)
+		}
+	}
+
+	return errs
+}
+
+// checkJSON compares a JSON value with the expected value and JSONPath assertions
+func checkJSON(what string, actualAsBytes []byte, expected interface{}, jsonPath map[string]interface{}) []string {
+	if expected == nil && len(jsonPath) == 0 {
+		return nil
+	}
+
+	var actual interface{}
+	if err := json.Unmarshal(actualAsBytes, &actual); err != nil {
+		return []string{fmt.Sprintf("The %s is not JSON: %s", what, err)}
+	}
+
+	var errs []string
+	if expected != nil && !jsonEqual(actual, expected) {
+		errs = append(errs, fmt.Sprintf("Expected %s %s, got %s", what, toJSON(expected), actualAsBytes))
+	}
+
+	paths := make([]string, 0, len(jsonPath))
+	for path := range jsonPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		value, err := evaluateJSONPath(actual, path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("In the %s: %s", what, err))
+			continue
+		}
+		if !jsonEqual(value, jsonPath[path]) {
+			errs = append(errs, fmt.Sprintf("Expected %s of the %s to be %s, got %s", path, what, toJSON(jsonPath[path]), toJSON(value)))
+		}
+	}
+	return errs
+}
+
+// jsonEqual reports whether a decoded JSON value equals an expected value read from a scenario
+func jsonEqual(actual interface{}, expected interface{}) bool {
+	var normalized interface{}
+	json.Unmarshal([]byte(toJSON(expected)), &normalized)
+	return reflect.DeepEqual(actual, normalized)
+}
+
+// toJSON returns the JSON encoding of a value read from a scenario
+func toJSON(value interface{}) string {
+	bytes, _ := json.Marshal(normalizeYAML(value))
+	return string(bytes)
+}
+
+// normalizeYAML converts the maps decoded by YAML, whose keys can be of any type, to maps with
+// string keys that can be encoded as JSON
+func normalizeYAML(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := map[string]interface{}{}
+		for key, item := range value {
+			object[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return object
+	case map[string]interface{}:
+		object := map[string]interface{}{}
+		for key, item := range value {
+			object[key] = normalizeYAML(item)
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			array = append(array, normalizeYAML(item))
+		}
+		return array
+	default:
+		return value
+	}
+}
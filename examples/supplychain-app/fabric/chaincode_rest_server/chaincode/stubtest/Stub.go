@@ -0,0 +1,454 @@
+// Package stubtest provides an in-memory ledger implementing shim.ChaincodeStubInterface, so
+// chaincode can be tested offline with the semantics of a real peer. Unlike shim.MockStub,
+// writes are buffered until the transaction commits and are discarded when it fails, reads
+// return the committed state, and the stub keeps the history of every key, private data
+// collections, transient data, creator identities, events and a subset of Mango queries.
+package stubtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+var _ shim.ChaincodeStubInterface = (*Stub)(nil)
+
+// write is a pending write of a key, or its deletion
+type write struct {
+	value   []byte
+	deleted bool
+}
+
+// transaction holds the arguments and the pending writes of the transaction in progress
+type transaction struct {
+	id                          string
+	args                        [][]byte
+	timestamp                   *timestamp.Timestamp
+	writes                      map[string]*write
+	validationParameters        map[string][]byte
+	privateWrites               map[string]map[string]*write
+	privateValidationParameters map[string]map[string][]byte
+	event                       *pb.ChaincodeEvent
+	invoked                     []*Stub
+}
+
+// Stub is an in-memory ledger for a single chaincode
+type Stub struct {
+	Name      string
+	ChannelID string
+
+	chaincode shim.Chaincode
+	clock     func() time.Time
+
+	state                map[string][]byte
+	history              map[string][]*queryresult.KeyModification
+	validationParameters map[string][]byte
+	collections          map[string]*collection
+	peers                map[string]*Stub
+	events               []*pb.ChaincodeEvent
+
+	creator     []byte
+	transient   map[string][]byte
+	decorations map[string][]byte
+
+	tx *transaction
+}
+
+// New returns an empty ledger for the given chaincode
+func New(name string, chaincode shim.Chaincode) *Stub {
+	return &Stub{
+		Name:                 name,
+		ChannelID:            "testchannel",
+		chaincode:            chaincode,
+		clock:                time.Now,
+		state:                map[string][]byte{},
+		history:              map[string][]*queryresult.KeyModification{},
+		validationParameters: map[string][]byte{},
+		collections:          map[string]*collection{},
+		peers:                map[string]*Stub{},
+		transient:            map[string][]byte{},
+		decorations:          map[string][]byte{},
+	}
+}
+
+// SetClock sets the clock giving the timestamp of the next transactions
+func (stub *Stub) SetClock(clock func() time.Time) {
+	stub.clock = clock
+}
+
+// SetCreator sets the identity submitting the next transactions, or none if it is nil
+func (stub *Stub) SetCreator(identity *Identity) {
+	if identity == nil {
+		stub.creator = nil
+		return
+	}
+	stub.creator = identity.Serialize()
+}
+
+// SetTransient sets the transient data of the next transactions
+func (stub *Stub) SetTransient(transient map[string][]byte) {
+	stub.transient = transient
+}
+
+// RegisterPeerChaincode lets the chaincode invoke the chaincode of another stub by name
+func (stub *Stub) RegisterPeerChaincode(name string, peer *Stub) {
+	stub.peers[name] = peer
+}
+
+// Init runs the Init function of the chaincode in a transaction, committed if it succeeds
+func (stub *Stub) Init(txID string, args [][]byte) pb.Response {
+	return stub.run(txID, args, stub.chaincode.Init)
+}
+
+// Invoke runs the Invoke function of the chaincode in a transaction, committed if it succeeds
+func (stub *Stub) Invoke(txID string, args [][]byte) pb.Response {
+	return stub.run(txID, args, stub.chaincode.Invoke)
+}
+
+// Query runs the Invoke function of the chaincode in a transaction that is never committed, like
+// a transaction that is evaluated rather than submitted
+func (stub *Stub) Query(txID string, args [][]byte) pb.Response {
+	if err := stub.Begin(txID, args); err != nil {
+		return shim.Error(err.Error())
+	}
+	defer stub.Rollback()
+
+	return stub.chaincode.Invoke(stub)
+}
+
+// run runs a function of the chaincode in a transaction. Like a peer, it only commits the writes
+// of responses below the error threshold.
+func (stub *Stub) run(txID string, args [][]byte, function func(shim.ChaincodeStubInterface) pb.Response) pb.Response {
+	if err := stub.Begin(txID, args); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	response := function(stub)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		stub.Rollback()
+	} else {
+		stub.Commit()
+	}
+
+	return response
+}
+
+// Begin starts a transaction, so chaincode functions can be called directly with the stub
+func (stub *Stub) Begin(txID string, args [][]byte) error {
+	if stub.tx != nil {
+		return fmt.Errorf("Transaction %s is in progress", stub.tx.id)
+	}
+
+	timestamp, err := ptypes.TimestampProto(stub.clock())
+	if err != nil {
+		return err
+	}
+
+	stub.tx = &transaction{
+		id:                          txID,
+		args:                        args,
+		timestamp:                   timestamp,
+		writes:                      map[string]*write{},
+		validationParameters:        map[string][]byte{},
+		privateWrites:               map[string]map[string]*write{},
+		privateValidationParameters: map[string]map[string][]byte{},
+	}
+	return nil
+}
+
+// Commit applies the writes and the event of the transaction in progress, and records them in the
+// history of their keys
+func (stub *Stub) Commit() {
+	tx := stub.tx
+	if tx == nil {
+		return
+	}
+	stub.tx = nil
+
+	for key, write := range tx.writes {
+		if write.deleted {
+			delete(stub.state, key)
+		} else {
+			stub.state[key] = write.value
+		}
+		modification := &queryresult.KeyModification{TxId: tx.id, Value: write.value, Timestamp: tx.timestamp, IsDelete: write.deleted}
+		stub.history[key] = append(stub.history[key], modification)
+	}
+	for key, parameter := range tx.validationParameters {
+		stub.validationParameters[key] = parameter
+	}
+	for name, writes := range tx.privateWrites {
+		stub.collections[name].apply(writes, tx.privateValidationParameters[name])
+	}
+	if tx.event != nil {
+		stub.events = append(stub.events, tx.event)
+	}
+	for _, peer := range tx.invoked {
+		peer.Commit()
+	}
+}
+
+// Rollback discards the writes and the event of the transaction in progress
+func (stub *Stub) Rollback() {
+	tx := stub.tx
+	if tx == nil {
+		return
+	}
+	stub.tx = nil
+
+	for _, peer := range tx.invoked {
+		peer.Rollback()
+	}
+}
+
+// State returns a copy of the committed world state
+func (stub *Stub) State() map[string][]byte {
+	state := make(map[string][]byte, len(stub.state))
+	for key, value := range stub.state {
+		state[key] = value
+	}
+	return state
+}
+
+// Load replaces the committed world state, such as with a state saved from another ledger. The
+// loaded keys have no history.
+func (stub *Stub) Load(state map[string][]byte) {
+	stub.state = make(map[string][]byte, len(state))
+	for key, value := range state {
+		stub.state[key] = value
+	}
+	stub.history = map[string][]*queryresult.KeyModification{}
+}
+
+// Events returns the events of the committed transactions, in order
+func (stub *Stub) Events() []*pb.ChaincodeEvent {
+	return stub.events
+}
+
+// GetArgs returns the arguments of the transaction
+func (stub *Stub) GetArgs() [][]byte {
+	if stub.tx == nil {
+		return nil
+	}
+	return stub.tx.args
+}
+
+// GetStringArgs returns the arguments of the transaction as strings
+func (stub *Stub) GetStringArgs() []string {
+	args := stub.GetArgs()
+	strargs := make([]string, 0, len(args))
+	for _, arg := range args {
+		strargs = append(strargs, string(arg))
+	}
+	return strargs
+}
+
+// GetFunctionAndParameters returns the first argument as the function and the rest as its parameters
+func (stub *Stub) GetFunctionAndParameters() (string, []string) {
+	args := stub.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+// GetArgsSlice returns the arguments of the transaction joined in a single slice
+func (stub *Stub) GetArgsSlice() ([]byte, error) {
+	var argsSlice []byte
+	for _, arg := range stub.GetArgs() {
+		argsSlice = append(argsSlice, arg...)
+	}
+	return argsSlice, nil
+}
+
+// GetTxID returns the ID of the transaction
+func (stub *Stub) GetTxID() string {
+	if stub.tx == nil {
+		return ""
+	}
+	return stub.tx.id
+}
+
+// GetChannelID returns the channel of the stub
+func (stub *Stub) GetChannelID() string {
+	return stub.ChannelID
+}
+
+// InvokeChaincode invokes a registered peer chaincode within the transaction. Its writes are
+// committed or discarded with the transaction, except on another channel, where it is read only.
+func (stub *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	if stub.tx == nil {
+		return shim.Error("No transaction in progress")
+	}
+
+	// A chaincode name can carry the channel after a slash
+	if i := strings.Index(chaincodeName, "/"); i >= 0 {
+		chaincodeName, channel = chaincodeName[:i], chaincodeName[i+1:]
+	}
+	peer, ok := stub.peers[chaincodeName]
+	if !ok {
+		return shim.Error(fmt.Sprintf("Chaincode %s is not registered", chaincodeName))
+	}
+	if err := peer.Begin(stub.tx.id, args); err != nil {
+		return shim.Error(err.Error())
+	}
+	peer.tx.timestamp = stub.tx.timestamp
+
+	response := peer.chaincode.Invoke(peer)
+	if channel != "" && channel != stub.ChannelID {
+		peer.Rollback()
+	} else {
+		stub.tx.invoked = append(stub.tx.invoked, peer)
+	}
+
+	return response
+}
+
+// GetState returns the committed value of a key, ignoring the writes of the transaction in progress
+func (stub *Stub) GetState(key string) ([]byte, error) {
+	return stub.state[key], nil
+}
+
+// PutState writes a key when the transaction commits
+func (stub *Stub) PutState(key string, value []byte) error {
+	if err := stub.validateWrite(key); err != nil {
+		return err
+	}
+	stub.tx.writes[key] = &write{value: value}
+	return nil
+}
+
+// DelState deletes a key when the transaction commits
+func (stub *Stub) DelState(key string) error {
+	if err := stub.validateWrite(key); err != nil {
+		return err
+	}
+	stub.tx.writes[key] = &write{deleted: true}
+	return nil
+}
+
+// SetStateValidationParameter sets the key-level endorsement policy of a key when the transaction commits
+func (stub *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	if err := stub.validateWrite(key); err != nil {
+		return err
+	}
+	stub.tx.validationParameters[key] = ep
+	return nil
+}
+
+// GetStateValidationParameter returns the committed key-level endorsement policy of a key
+func (stub *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return stub.validationParameters[key], nil
+}
+
+// GetHistoryForKey returns the committed modifications of a key, from the newest to the oldest
+func (stub *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	modifications := stub.history[key]
+	reversed := make([]*queryresult.KeyModification, 0, len(modifications))
+	for i := len(modifications) - 1; i >= 0; i-- {
+		reversed = append(reversed, modifications[i])
+	}
+	return &historyIterator{modifications: reversed}, nil
+}
+
+// GetCreator returns the serialized identity set with SetCreator
+func (stub *Stub) GetCreator() ([]byte, error) {
+	return stub.creator, nil
+}
+
+// GetTransient returns the transient data set with SetTransient
+func (stub *Stub) GetTransient() (map[string][]byte, error) {
+	return stub.transient, nil
+}
+
+// GetBinding returns nil, as the stub does not sign proposals
+func (stub *Stub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+// GetDecorations returns the decorations of the proposal, which are always empty
+func (stub *Stub) GetDecorations() map[string][]byte {
+	return stub.decorations
+}
+
+// GetSignedProposal returns an unsigned proposal carrying the creator, the arguments and the
+// transient data of the transaction
+func (stub *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	if stub.tx == nil {
+		return nil, errors.New("No transaction in progress")
+	}
+
+	signatureHeader, err := proto.Marshal(&common.SignatureHeader{Creator: stub.creator})
+	if err != nil {
+		return nil, err
+	}
+	header, err := proto.Marshal(&common.Header{SignatureHeader: signatureHeader})
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := proto.Marshal(&pb.ChaincodeInvocationSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			ChaincodeId: &pb.ChaincodeID{Name: stub.Name},
+			Input:       &pb.ChaincodeInput{Args: stub.tx.args},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&pb.ChaincodeProposalPayload{Input: input, TransientMap: stub.transient})
+	if err != nil {
+		return nil, err
+	}
+
+	proposal, err := proto.Marshal(&pb.Proposal{Header: header, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SignedProposal{ProposalBytes: proposal}, nil
+}
+
+// GetTxTimestamp returns the timestamp of the transaction, taken from the clock when it began
+func (stub *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if stub.tx == nil {
+		return nil, errors.New("No transaction in progress")
+	}
+	return stub.tx.timestamp, nil
+}
+
+// SetEvent sets the event of the transaction. Like on a peer, a transaction has a single event,
+// so a later call replaces it.
+func (stub *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+	if stub.tx == nil {
+		return errors.New("No transaction in progress")
+	}
+	stub.tx.event = &pb.ChaincodeEvent{ChaincodeId: stub.Name, TxId: stub.tx.id, EventName: name, Payload: payload}
+	return nil
+}
+
+// validateWrite checks that a transaction is in progress and that the key can be written
+func (stub *Stub) validateWrite(key string) error {
+	if stub.tx == nil {
+		return errors.New("No transaction in progress")
+	}
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if !utf8.ValidString(key) {
+		return fmt.Errorf("key %x is not a valid utf8 string", key)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package stubtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/cid"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	. "github.com/onsi/gomega"
+)
+
+// kvChaincode puts the value of the second argument under the key of the first one, fails when
+// the third argument is "fail", and emits an event named after the key
+type kvChaincode struct{}
+
+func (kvChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (kvChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	args := stub.GetStringArgs()
+	if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+		return shim.Error(err.Error())
+	}
+	stub.SetEvent(args[0], []byte(args[1]))
+	if len(args) > 2 && args[2] == "fail" {
+		return shim.Error("failed")
+	}
+	return shim.Success(nil)
+}
+
+// put runs a transaction of kvChaincode
+func put(stub *Stub, txID string, args ...string) pb.Response {
+	byteArgs := make([][]byte, 0, len(args))
+	for _, arg := range args {
+		byteArgs = append(byteArgs, []byte(arg))
+	}
+	return stub.Invoke(txID, byteArgs)
+}
+
+// keys returns the keys of the results of a query
+func keys(iterator shim.StateQueryIteratorInterface) []string {
+	result := []string{}
+	for iterator.HasNext() {
+		item, _ := iterator.Next()
+		result = append(result, item.Key)
+	}
+	iterator.Close()
+	return result
+}
+
+func TestStub(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var stub *Stub
+
+	g.Describe("Transactions", func() {
+		g.BeforeEach(func() {
+			stub = New("kv", kvChaincode{})
+		})
+
+		g.It("should only read the committed state", func() {
+			stub.Begin("tx1", nil)
+			stub.PutState("a", []byte("1"))
+			value, _ := stub.GetState("a")
+			Expect(value).To(BeNil())
+			stub.Commit()
+
+			value, _ = stub.GetState("a")
+			Expect(value).To(Equal([]byte("1")))
+		})
+
+		g.It("should discard the writes and the event of a failed transaction", func() {
+			response := put(stub, "tx1", "a", "1", "fail")
+
+			Expect(response.Status).To(BeEquivalentTo(500))
+			value, _ := stub.GetState("a")
+			Expect(value).To(BeNil())
+			Expect(stub.Events()).To(BeEmpty())
+		})
+
+		g.It("should keep the last event of each committed transaction", func() {
+			put(stub, "tx1", "a", "1")
+			put(stub, "tx2", "b", "2")
+
+			Expect(stub.Events()).To(HaveLen(2))
+			Expect(stub.Events()[1].EventName).To(Equal("b"))
+			Expect(stub.Events()[1].TxId).To(Equal("tx2"))
+		})
+
+		g.It("should return the history of a key from the newest modification", func() {
+			now := time.Unix(1654027884, 0)
+			stub.SetClock(func() time.Time { return now })
+			put(stub, "tx1", "a", "1")
+			put(stub, "tx2", "a", "2")
+			stub.Begin("tx3", nil)
+			stub.DelState("a")
+			stub.Commit()
+
+			iterator, _ := stub.GetHistoryForKey("a")
+			var txIDs []string
+			for iterator.HasNext() {
+				modification, _ := iterator.Next()
+				txIDs = append(txIDs, modification.TxId)
+				Expect(modification.Timestamp.Seconds).To(BeEquivalentTo(1654027884))
+				Expect(modification.IsDelete).To(Equal(modification.TxId == "tx3"))
+			}
+			Expect(txIDs).To(Equal([]string{"tx3", "tx2", "tx1"}))
+		})
+
+		g.It("should return an error when writing without a transaction", func() {
+			Expect(stub.PutState("a", []byte("1"))).To(HaveOccurred())
+		})
+	})
+
+	g.Describe("Range Queries", func() {
+		g.BeforeEach(func() {
+			stub = New("kv", kvChaincode{})
+			stub.Begin("tx1", nil)
+			for _, key := range []string{"b", "a", "c", "~config"} {
+				stub.PutState(key, []byte("{}"))
+			}
+			compositeKey, _ := stub.CreateCompositeKey("owner", []string{"acme", "001"})
+			stub.PutState(compositeKey, []byte("{}"))
+			stub.Commit()
+		})
+
+		g.It("should return the simple keys in byte order with an open end", func() {
+			iterator, _ := stub.GetStateByRange("", "")
+			Expect(keys(iterator)).To(Equal([]string{"a", "b", "c", "~config"}))
+
+			iterator, _ = stub.GetStateByRange("b", "c")
+			Expect(keys(iterator)).To(Equal([]string{"b"}))
+		})
+
+		g.It("should page through a range with bookmarks", func() {
+			iterator, metadata, _ := stub.GetStateByRangeWithPagination("", "", 2, "")
+			Expect(keys(iterator)).To(Equal([]string{"a", "b"}))
+			Expect(metadata.Bookmark).To(Equal("c"))
+
+			iterator, metadata, _ = stub.GetStateByRangeWithPagination("", "", 2, metadata.Bookmark)
+			Expect(keys(iterator)).To(Equal([]string{"c", "~config"}))
+			Expect(metadata.Bookmark).To(BeEmpty())
+		})
+
+		g.It("should find composite keys by a partial key", func() {
+			iterator, _ := stub.GetStateByPartialCompositeKey("owner", []string{"acme"})
+			results := keys(iterator)
+			Expect(results).To(HaveLen(1))
+
+			objectType, attributes, _ := stub.SplitCompositeKey(results[0])
+			Expect(objectType).To(Equal("owner"))
+			Expect(attributes).To(Equal([]string{"acme", "001"}))
+		})
+	})
+
+	g.Describe("Private Data", func() {
+		g.BeforeEach(func() {
+			stub = New("kv", kvChaincode{})
+			stub.DefineCollection("secrets")
+		})
+
+		g.It("should write private data when the transaction commits", func() {
+			stub.Begin("tx1", nil)
+			Expect(stub.PutPrivateData("secrets", "a", []byte("1"))).To(Succeed())
+			stub.Commit()
+
+			value, _ := stub.GetPrivateData("secrets", "a")
+			hash, _ := stub.GetPrivateDataHash("secrets", "a")
+			Expect(value).To(Equal([]byte("1")))
+			Expect(hash).To(HaveLen(32))
+		})
+
+		g.It("should return an error for an undefined collection", func() {
+			_, err := stub.GetPrivateData("unknown", "a")
+			Expect(err).To(MatchError("collection unknown is not defined"))
+		})
+	})
+
+	g.Describe("Proposal", func() {
+		g.It("should return the attributes of the creator and the transient data", func() {
+			stub = New("kv", kvChaincode{})
+			identity, _ := NewIdentity("Org1MSP", "user", map[string]string{"role": "admin"})
+			stub.SetCreator(identity)
+			stub.SetTransient(map[string][]byte{"secret": []byte("1")})
+
+			stub.Begin("tx1", nil)
+			role, found, err := cid.GetAttributeValue(stub, "role")
+			mspID, _ := cid.GetMSPID(stub)
+			transient, _ := stub.GetTransient()
+			stub.Rollback()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(role).To(Equal("admin"))
+			Expect(mspID).To(Equal("Org1MSP"))
+			Expect(transient["secret"]).To(Equal([]byte("1")))
+		})
+	})
+}